@@ -0,0 +1,22 @@
+package audit
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// NewAuditCommand creates the 'audit' command to work with the audit log.
+func NewAuditCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Query and verify the audit log of executed commands.",
+		Long: `
+Every command run or previewed from the shell is recorded in the audit log of the workspace (~/.pops/audit.log,
+or the file in POPS_AUDIT_LOG), one JSON line per command. Each entry includes the hash of the previous entry, so edited,
+inserted or removed entries are detected by 'pops audit verify'.`,
+	}
+
+	cmd.AddCommand(newQueryCmd())
+	cmd.AddCommand(newVerifyCmd())
+
+	return cmd
+}
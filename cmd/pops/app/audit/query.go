@@ -0,0 +1,142 @@
+package audit
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/prompt-ops/pops/pkg/audit"
+	"github.com/prompt-ops/pops/pkg/ui"
+
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// newQueryCmd creates the query command for the audit log.
+func newQueryCmd() *cobra.Command {
+	var (
+		filter    audit.Filter
+		since     string
+		until     string
+		auditFile string
+		output    string
+	)
+
+	queryCmd := &cobra.Command{
+		Use:     "query",
+		Aliases: []string{"list"},
+		Short:   "Show the commands recorded in the audit log",
+		Example: `
+- **pops audit query** - Show all recorded commands.
+- **pops audit query --connection prod-db --since 24h** - Show the commands run on prod-db in the last day.
+- **pops audit query --status failed --limit 10** - Show the last 10 commands that failed.
+- **pops audit query --contains delete -o json** - Show the commands mentioning delete as JSON.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			if filter.Since, err = parseTime(since); err != nil {
+				color.Red("Error: invalid --since: %v", err)
+				os.Exit(1)
+			}
+			if filter.Until, err = parseTime(until); err != nil {
+				color.Red("Error: invalid --until: %v", err)
+				os.Exit(1)
+			}
+
+			if auditFile == "" {
+				auditFile = audit.Path()
+			}
+			entries, err := audit.Query(auditFile, filter)
+			if err != nil {
+				color.Red("Error reading audit log: %v", err)
+				os.Exit(1)
+			}
+
+			if err := printEntries(entries, output); err != nil {
+				color.Red("Error: %v", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	queryCmd.Flags().StringVarP(&filter.Connection, "connection", "c", "", "Only show commands run on this connection")
+	queryCmd.Flags().StringVar(&filter.User, "user", "", "Only show commands run by this OS user")
+	queryCmd.Flags().StringVar(&filter.Status, "status", "", "Only show commands with this status (succeeded, failed or denied)")
+	queryCmd.Flags().StringVar(&filter.Contains, "contains", "", "Only show commands whose prompt or command contains this text")
+	queryCmd.Flags().StringVar(&since, "since", "", "Only show commands run after this time (RFC 3339, YYYY-MM-DD or a duration like 24h)")
+	queryCmd.Flags().StringVar(&until, "until", "", "Only show commands run before this time (RFC 3339, YYYY-MM-DD or a duration like 24h)")
+	queryCmd.Flags().IntVar(&filter.Limit, "limit", 0, "Only show the most recent commands")
+	queryCmd.Flags().StringVar(&auditFile, "file", "", "Audit log to read (defaults to the audit.log of the workspace or POPS_AUDIT_LOG)")
+	queryCmd.Flags().StringVarP(&output, "output", "o", "table", "Output format (table, json, yaml, csv or markdown)")
+
+	return queryCmd
+}
+
+// parseTime parses an RFC 3339 time, a date, or a duration before now. An empty value is the zero time.
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, value, time.Local); err == nil {
+		return t, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("%q is not a time, a date or a duration", value)
+}
+
+// printEntries prints the entries of the audit log in the output format.
+func printEntries(entries []audit.Entry, output string) error {
+	columns := []table.Column{
+		{Title: "Time"},
+		{Title: "User"},
+		{Title: "Connection"},
+		{Title: "Action"},
+		{Title: "Prompt"},
+		{Title: "Command"},
+		{Title: "Risk"},
+		{Title: "Status"},
+		{Title: "Exit code"},
+		{Title: "Duration"},
+		{Title: "Rows"},
+	}
+
+	rows := make([]table.Row, len(entries))
+	for i, entry := range entries {
+		exitCode := ""
+		if entry.ExitCode != nil {
+			exitCode = strconv.Itoa(*entry.ExitCode)
+		}
+
+		status := entry.Status
+		if entry.Error != "" {
+			status += ": " + entry.Error
+		}
+
+		rowCount := strconv.Itoa(entry.Rows)
+		if entry.MoreRows {
+			rowCount += "+"
+		}
+
+		rows[i] = table.Row{
+			entry.Time.Local().Format(time.DateTime),
+			entry.User,
+			entry.Connection,
+			entry.Action,
+			entry.Prompt,
+			entry.Command,
+			entry.Risk,
+			status,
+			exitCode,
+			(time.Duration(entry.DurationMs) * time.Millisecond).String(),
+			rowCount,
+		}
+	}
+
+	return ui.PrintTable(columns, rows, output)
+}
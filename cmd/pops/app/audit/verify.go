@@ -0,0 +1,39 @@
+package audit
+
+import (
+	"os"
+
+	"github.com/prompt-ops/pops/pkg/audit"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// newVerifyCmd creates the verify command for the audit log.
+func newVerifyCmd() *cobra.Command {
+	var auditFile string
+
+	verifyCmd := &cobra.Command{
+		Use:   "verify",
+		Short: "Check that the audit log has not been tampered with",
+		Example: `
+- **pops audit verify** - Check the hash chain of the audit log.
+- **pops audit verify --file ./audit.log** - Check a copy of an audit log.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if auditFile == "" {
+				auditFile = audit.Path()
+			}
+
+			count, err := audit.Verify(auditFile)
+			if err != nil {
+				color.Red("Error: %v", err)
+				os.Exit(1)
+			}
+			color.Green("The audit log %s is intact (%d entries).", auditFile, count)
+		},
+	}
+
+	verifyCmd.Flags().StringVar(&auditFile, "file", "", "Audit log to verify (defaults to the audit.log of the workspace or POPS_AUDIT_LOG)")
+
+	return verifyCmd
+}
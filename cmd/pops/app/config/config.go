@@ -0,0 +1,64 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/prompt-ops/pops/pkg/config"
+	"github.com/prompt-ops/pops/pkg/settings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// NewConfigCommand creates the 'config' command to manage the settings file.
+func NewConfigCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Manage settings like the AI model, output format and confirmations.",
+		Long: `
+Settings are kept in ~/.pops/config.yaml. A named workspace can override them in its own config.yaml,
+and both files can override them per connection under 'connections'. Values are checked against the
+settings pops knows; 'pops config list' shows them with their allowed values.
+
+Settings are written to the file of the current workspace, or to ~/.pops/config.yaml with --global.`,
+		Example: `
+- **pops config list** - List the settings with their values and where they come from.
+- **pops config get ai.model** - Show a setting.
+- **pops config set ai.model gpt-4o-mini** - Change a setting.
+- **pops config set confirmation.strictness all --connection prod-db** - Change a setting for one connection.
+- **pops config unset ai.model** - Go back to the default of a setting.`,
+	}
+
+	cmd.AddCommand(newGetCmd())
+	cmd.AddCommand(newSetCmd())
+	cmd.AddCommand(newUnsetCmd())
+	cmd.AddCommand(newListCmd())
+
+	return cmd
+}
+
+// filePath returns the path of the settings file that set and unset change.
+func filePath(global bool) string {
+	if global {
+		return settings.GlobalPath()
+	}
+	return settings.Path()
+}
+
+// warnUnknownConnection warns if there is no connection with the name, as its settings would have no effect.
+func warnUnknownConnection(name string) {
+	if name == "" {
+		return
+	}
+	if _, err := config.GetConnectionByName(name); err != nil {
+		color.Yellow("There is no connection '%s' in this workspace; its settings apply once there is one.", name)
+	}
+}
+
+// describe returns where the setting is changed, for messages.
+func describe(key, connection string) string {
+	if connection == "" {
+		return key
+	}
+	return fmt.Sprintf("%s for connection '%s'", key, connection)
+}
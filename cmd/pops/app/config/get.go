@@ -0,0 +1,40 @@
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/prompt-ops/pops/pkg/settings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// newGetCmd creates the get command for settings.
+func newGetCmd() *cobra.Command {
+	var connection string
+
+	getCmd := &cobra.Command{
+		Use:   "get [key]",
+		Short: "Show the value of a setting",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			all, err := settings.Load()
+			if err != nil {
+				color.Red("Error reading settings: %v", err)
+				os.Exit(1)
+			}
+
+			value, err := all.Get(args[0], connection)
+			if err != nil {
+				color.Red("Error getting setting: %v", err)
+				os.Exit(1)
+			}
+			fmt.Println(value.Value)
+		},
+	}
+
+	getCmd.Flags().StringVarP(&connection, "connection", "c", "", "Show the value for this connection, with its overrides")
+
+	return getCmd
+}
@@ -0,0 +1,60 @@
+package config
+
+import (
+	"os"
+
+	"github.com/prompt-ops/pops/pkg/settings"
+	"github.com/prompt-ops/pops/pkg/ui"
+
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// newListCmd creates the list command for settings.
+func newListCmd() *cobra.Command {
+	var (
+		connection string
+		output     string
+	)
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the settings with their values and where they come from",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runList(connection, output); err != nil {
+				color.Red("Error listing settings: %v", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	listCmd.Flags().StringVarP(&connection, "connection", "c", "", "List the values for this connection, with its overrides")
+	listCmd.Flags().StringVarP(&output, "output", "o", "table", "Output format (table, json, yaml, csv or markdown)")
+
+	return listCmd
+}
+
+// runList prints the settings with their effective values.
+func runList(connection, output string) error {
+	all, err := settings.Load()
+	if err != nil {
+		return err
+	}
+
+	values := all.List(connection)
+	rows := make([]table.Row, 0, len(values))
+	for _, value := range values {
+		rows = append(rows, table.Row{value.Key, value.Value, value.Source, value.Allowed(), value.Description})
+	}
+
+	columns := []table.Column{
+		{Title: "Key"},
+		{Title: "Value"},
+		{Title: "Source"},
+		{Title: "Allowed"},
+		{Title: "Description"},
+	}
+	return ui.PrintTable(columns, rows, output)
+}
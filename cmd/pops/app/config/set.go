@@ -0,0 +1,53 @@
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/prompt-ops/pops/pkg/settings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// newSetCmd creates the set command for settings.
+func newSetCmd() *cobra.Command {
+	var (
+		connection string
+		global     bool
+	)
+
+	setCmd := &cobra.Command{
+		Use:   "set [key] [value]",
+		Short: "Change a setting",
+		Long: `Change a setting in the settings file of the current workspace.
+
+The value is checked against the setting; 'pops config list' shows the allowed values.`,
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			path := filePath(global)
+			file, err := settings.LoadFile(path)
+			if err != nil {
+				color.Red("Error reading settings: %v", err)
+				os.Exit(1)
+			}
+
+			if err := file.Set(args[0], args[1], connection); err != nil {
+				color.Red("Error setting %s: %v", args[0], err)
+				os.Exit(1)
+			}
+			if err := file.Save(); err != nil {
+				color.Red("Error saving settings: %v", err)
+				os.Exit(1)
+			}
+
+			fmt.Printf("✅ Set %s in %s.\n", describe(args[0], connection), path)
+			warnUnknownConnection(connection)
+		},
+	}
+
+	setCmd.Flags().StringVarP(&connection, "connection", "c", "", "Set the value for this connection only")
+	setCmd.Flags().BoolVar(&global, "global", false, "Write to ~/.pops/config.yaml instead of the file of the current workspace")
+
+	return setCmd
+}
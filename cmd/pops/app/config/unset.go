@@ -0,0 +1,54 @@
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/prompt-ops/pops/pkg/settings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// newUnsetCmd creates the unset command for settings.
+func newUnsetCmd() *cobra.Command {
+	var (
+		connection string
+		global     bool
+	)
+
+	unsetCmd := &cobra.Command{
+		Use:   "unset [key]",
+		Short: "Remove a setting, so its default applies again",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			path := filePath(global)
+			file, err := settings.LoadFile(path)
+			if err != nil {
+				color.Red("Error reading settings: %v", err)
+				os.Exit(1)
+			}
+
+			removed, err := file.Unset(args[0], connection)
+			if err != nil {
+				color.Red("Error unsetting %s: %v", args[0], err)
+				os.Exit(1)
+			}
+			if !removed {
+				fmt.Printf("%s is not set in %s.\n", describe(args[0], connection), path)
+				return
+			}
+			if err := file.Save(); err != nil {
+				color.Red("Error saving settings: %v", err)
+				os.Exit(1)
+			}
+
+			fmt.Printf("✅ Unset %s in %s.\n", describe(args[0], connection), path)
+		},
+	}
+
+	unsetCmd.Flags().StringVarP(&connection, "connection", "c", "", "Remove the override of this connection")
+	unsetCmd.Flags().BoolVar(&global, "global", false, "Change ~/.pops/config.yaml instead of the file of the current workspace")
+
+	return unsetCmd
+}
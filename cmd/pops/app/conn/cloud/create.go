@@ -51,6 +51,7 @@ func (m *createModel) View() string {
 func newCreateCmd() *cobra.Command {
 	var name string
 	var provider string
+	var readOnly bool
 
 	cmd := &cobra.Command{
 		Use:   "create",
@@ -63,22 +64,25 @@ Cloud Connection:
 - Examples:
  * 'pops conn cloud create' creates a connection interactively.
  * 'pops conn cloud create --name my-azure-conn --provider azure' creates a connection non-interactively.
+ * 'pops conn cloud create --name my-azure-conn --provider azure --read-only' creates a connection that only runs list and show commands.
 `,
 		Run: func(cmd *cobra.Command, args []string) {
 			// Non-interactive mode
 			if name != "" && provider != "" {
-				err := createCloudConnection(name, provider)
+				err := createCloudConnection(name, provider, readOnly)
 				if err != nil {
 					fmt.Printf("Error creating cloud connection: %v\n", err)
 					return
 				}
 
+				connection := conn.NewCloudConnection(name,
+					conn.AvailableCloudConnectionType{
+						Subtype: strings.Title(provider),
+					},
+				)
+				connection.ReadOnly = readOnly
 				transitionMsg := ui.TransitionToShellMsg{
-					Connection: conn.NewCloudConnection(name,
-						conn.AvailableCloudConnectionType{
-							Subtype: strings.Title(provider),
-						},
-					),
+					Connection: connection,
 				}
 
 				p := tea.NewProgram(initialCreateModel())
@@ -103,11 +107,12 @@ Cloud Connection:
 
 	cmd.Flags().StringVar(&name, "name", "", "Name of the cloud connection")
 	cmd.Flags().StringVar(&provider, "provider", "", "Cloud provider (azure, aws, gcp)")
+	cmd.Flags().BoolVar(&readOnly, "read-only", false, "Only allow commands that don't change anything")
 
 	return cmd
 }
 
-func createCloudConnection(name, provider string) error {
+func createCloudConnection(name, provider string, readOnly bool) error {
 	name = strings.TrimSpace(name)
 	provider = strings.ToLower(strings.TrimSpace(provider))
 
@@ -131,6 +136,7 @@ func createCloudConnection(name, provider string) error {
 	}
 
 	connection := conn.NewCloudConnection(name, selectedProvider)
+	connection.ReadOnly = readOnly
 	if err := config.SaveConnection(connection); err != nil {
 		return fmt.Errorf("failed to save connection: %w", err)
 	}
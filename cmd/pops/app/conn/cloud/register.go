@@ -0,0 +1,17 @@
+package cloud
+
+import (
+	"github.com/prompt-ops/pops/cmd/pops/app/conn/factory"
+	"github.com/prompt-ops/pops/pkg/conn"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func init() {
+	factory.Register(factory.Registration{
+		MainType:       conn.ConnectionTypeCloud,
+		NewCreateModel: func() tea.Model { return NewCreateModel() },
+		NewOpenModel:   func() tea.Model { return NewOpenModel() },
+		NewCommand:     NewRootCommand,
+	})
+}
@@ -14,23 +14,27 @@ import (
 )
 
 func newTypesCmd() *cobra.Command {
+	var output string
+
 	listCmd := &cobra.Command{
 		Use:   "types",
 		Short: "List all available cloud connection types",
 		Long:  "List all available cloud connection types",
 		Run: func(cmd *cobra.Command, args []string) {
-			if err := runListAvaibleCloudTypes(); err != nil {
+			if err := runListAvaibleCloudTypes(output); err != nil {
 				color.Red("Error listing cloud connections: %v", err)
 				os.Exit(1)
 			}
 		},
 	}
 
+	listCmd.Flags().StringVarP(&output, "output", "o", "", "Print in an output format (table, json, yaml, csv or markdown) instead of the interactive table")
+
 	return listCmd
 }
 
 // runListAvaibleCloudTypes lists all available cloud connection types
-func runListAvaibleCloudTypes() error {
+func runListAvaibleCloudTypes(output string) error {
 	cloudConnectionTypes := conn.AvailableCloudConnectionTypes
 
 	items := make([]table.Row, len(cloudConnectionTypes))
@@ -42,6 +46,10 @@ func runListAvaibleCloudTypes() error {
 		{Title: "Available Types", Width: 25},
 	}
 
+	if output != "" {
+		return ui.PrintTable(columns, items, output)
+	}
+
 	t := table.New(
 		table.WithColumns(columns),
 		table.WithRows(items),
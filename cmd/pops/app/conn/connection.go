@@ -1,9 +1,13 @@
 package conn
 
 import (
-	"github.com/prompt-ops/pops/cmd/pops/app/conn/cloud"
-	"github.com/prompt-ops/pops/cmd/pops/app/conn/db"
-	"github.com/prompt-ops/pops/cmd/pops/app/conn/k8s"
+	"github.com/prompt-ops/pops/cmd/pops/app/conn/factory"
+
+	// Connection types register their models and commands with the factory.
+	_ "github.com/prompt-ops/pops/cmd/pops/app/conn/cloud"
+	_ "github.com/prompt-ops/pops/cmd/pops/app/conn/db"
+	_ "github.com/prompt-ops/pops/cmd/pops/app/conn/k8s"
+	_ "github.com/prompt-ops/pops/cmd/pops/app/conn/plugin"
 
 	"github.com/spf13/cobra"
 )
@@ -32,6 +36,11 @@ Prompt-Ops manages your infrastructure using natural language.
 - **Commands**: create, delete, open, list, types
 - **Example**: 'pops connection kubernetes create' creates a connection to a Kubernetes cluster.
 
+**Plugin Connection:**
+- **Types**: Any 'pops-plugin-<type>' executable on your PATH
+- **Commands**: create, open, list, types
+- **Example**: 'pops connection plugin create' creates a connection served by a plugin.
+
 More connection types and features are coming soon!`,
 		Example: `
 - **pops connection create** - Create a connection by selecting from available types.
@@ -39,13 +48,18 @@ More connection types and features are coming soon!`,
 - **pops connection delete** - Delete a connection by selecting from available connections.
 - **pops connection delete --all** - Delete all available connections.
 - **pops connection list** - List all available connections.
+- **pops connection refresh my-conn** - Refresh the cached context of a connection.
+- **pops connection edit my-conn --read-only** - Only allow commands that don't change anything.
+- **pops connection doctor** - Report invalid connections and check that connections can be used.
+- **pops connection export --all -f team.yaml** - Export connections to a bundle to share.
+- **pops connection import team.yaml** - Import the connections of a bundle.
 						`,
 	}
 
-	// Add subcommands
-	cmd.AddCommand(cloud.NewRootCommand())
-	cmd.AddCommand(k8s.NewRootCommand())
-	cmd.AddCommand(db.NewRootCommand())
+	// Add subcommands of the registered connection types
+	for _, typeCmd := range factory.Commands() {
+		cmd.AddCommand(typeCmd)
+	}
 
 	// Add additional commands
 	cmd.AddCommand(newListCmd())
@@ -53,6 +67,12 @@ More connection types and features are coming soon!`,
 	cmd.AddCommand(newOpenCmd())
 	cmd.AddCommand(newCreateCmd())
 	cmd.AddCommand(newTypesCmd())
+	cmd.AddCommand(newRefreshCmd())
+	cmd.AddCommand(newEditCmd())
+	cmd.AddCommand(newDoctorCmd())
+	cmd.AddCommand(newExportCmd())
+	cmd.AddCommand(newImportCmd())
+	cmd.AddCommand(newKeyCmd())
 
 	return cmd
 }
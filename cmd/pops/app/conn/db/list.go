@@ -16,23 +16,27 @@ import (
 )
 
 func newListCmd() *cobra.Command {
+	var output string
+
 	listCmd := &cobra.Command{
 		Use:   "list",
 		Short: "List all database connections",
 		Long:  "List all database connections that have been set up.",
 		Run: func(cmd *cobra.Command, args []string) {
-			if err := runListConnections(); err != nil {
+			if err := runListConnections(output); err != nil {
 				color.Red("Error listing database connections: %v", err)
 				os.Exit(1)
 			}
 		},
 	}
 
+	listCmd.Flags().StringVarP(&output, "output", "o", "", "Print in an output format (table, json, yaml, csv or markdown) instead of the interactive table")
+
 	return listCmd
 }
 
 // runListConnections lists all connections
-func runListConnections() error {
+func runListConnections(output string) error {
 	connections, err := config.GetConnectionsByType(conn.ConnectionTypeDatabase)
 	if err != nil {
 		return fmt.Errorf("getting database connections: %w", err)
@@ -49,6 +53,10 @@ func runListConnections() error {
 		{Title: "Driver", Width: 20},
 	}
 
+	if output != "" {
+		return ui.PrintTable(columns, items, output)
+	}
+
 	t := table.New(
 		table.WithColumns(columns),
 		table.WithRows(items),
@@ -14,23 +14,27 @@ import (
 )
 
 func newTypesCmd() *cobra.Command {
+	var output string
+
 	listCmd := &cobra.Command{
 		Use:   "types",
 		Short: "List all available database connection types",
 		Long:  "List all available database connection types",
 		Run: func(cmd *cobra.Command, args []string) {
-			if err := runListAvaibleDatabaseTypes(); err != nil {
+			if err := runListAvaibleDatabaseTypes(output); err != nil {
 				color.Red("Error listing database connections: %v", err)
 				os.Exit(1)
 			}
 		},
 	}
 
+	listCmd.Flags().StringVarP(&output, "output", "o", "", "Print in an output format (table, json, yaml, csv or markdown) instead of the interactive table")
+
 	return listCmd
 }
 
 // runListAvaibledatabaseTypes lists all available database connection types
-func runListAvaibleDatabaseTypes() error {
+func runListAvaibleDatabaseTypes(output string) error {
 	databaseConnections := conn.AvailableDatabaseConnectionTypes
 
 	items := make([]table.Row, len(databaseConnections))
@@ -42,6 +46,10 @@ func runListAvaibleDatabaseTypes() error {
 		{Title: "Available Types", Width: 25},
 	}
 
+	if output != "" {
+		return ui.PrintTable(columns, items, output)
+	}
+
 	t := table.New(
 		table.WithColumns(columns),
 		table.WithRows(items),
@@ -0,0 +1,150 @@
+package conn
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/prompt-ops/pops/pkg/config"
+	"github.com/prompt-ops/pops/pkg/conn"
+	"github.com/prompt-ops/pops/pkg/secret"
+	"github.com/prompt-ops/pops/pkg/ui"
+
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// newDoctorCmd creates the doctor command for the connections.
+func newDoctorCmd() *cobra.Command {
+	var (
+		restore bool
+		purge   bool
+		output  string
+	)
+
+	doctorCmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Check the connections file and report invalid connections",
+		Long: `Check the connections file and report invalid connections.
+
+Connections that can't be read, for example because they are of a type this version of pops doesn't know,
+are moved to a quarantine file next to the connections file instead of breaking the other connections.
+Use --restore to move them back once they can be read again, or --purge to delete them.`,
+		Example: `
+- **pops connection doctor** - Report problems with connections.
+- **pops connection doctor --restore** - Restore quarantined connections that can be read again.
+- **pops connection doctor --purge** - Delete the quarantined connections.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			store := config.DefaultStore()
+
+			if restore {
+				restored, err := store.RestoreQuarantined()
+				if err != nil {
+					color.Red("Error restoring connections: %v", err)
+					os.Exit(1)
+				}
+				for _, name := range restored {
+					fmt.Printf("✅ Connection '%s' restored.\n", name)
+				}
+			}
+
+			if purge {
+				if err := store.PurgeQuarantined(); err != nil {
+					color.Red("Error deleting quarantined connections: %v", err)
+					os.Exit(1)
+				}
+				fmt.Println("✅ Quarantined connections deleted.")
+			}
+
+			problems, err := runDoctor(store, output)
+			if err != nil {
+				color.Red("Error checking connections: %v", err)
+				os.Exit(1)
+			}
+			if problems > 0 {
+				os.Exit(1)
+			}
+		},
+	}
+
+	doctorCmd.Flags().BoolVar(&restore, "restore", false, "Restore quarantined connections that can be read again")
+	doctorCmd.Flags().BoolVar(&purge, "purge", false, "Delete the quarantined connections")
+	doctorCmd.Flags().StringVarP(&output, "output", "o", "table", "Output format (table, json, yaml, csv or markdown)")
+
+	return doctorCmd
+}
+
+// runDoctor prints the problems with the connections and returns how many there are.
+func runDoctor(store *config.Store, output string) (int, error) {
+	file, err := store.Inspect()
+	if err != nil {
+		return 0, err
+	}
+	quarantined, err := store.Quarantined()
+	if err != nil {
+		return 0, err
+	}
+
+	var rows []table.Row
+	for _, entry := range quarantined {
+		rows = append(rows, table.Row{entry.Name, "quarantined", entry.Error, entry.QuarantinedAt.Local().Format(time.DateTime)})
+	}
+	for _, entry := range file.Invalid {
+		rows = append(rows, table.Row{entry.Name, "invalid", entry.Error, ""})
+	}
+
+	seen := map[string]bool{}
+	for _, connection := range file.Connections {
+		name := strings.ToLower(connection.Name)
+		if seen[name] {
+			rows = append(rows, table.Row{connection.Name, "duplicate", "another connection has the same name", ""})
+		}
+		seen[name] = true
+
+		if err := checkConnection(connection); err != nil {
+			rows = append(rows, table.Row{connection.Name, "error", err.Error(), ""})
+		}
+	}
+
+	fmt.Printf("Connections file: %s (schema version %d, %d connections)\n", store.Path, file.Version, len(file.Connections))
+	if file.Version < config.SchemaVersion {
+		fmt.Printf("The file will be migrated to schema version %d the next time it is changed.\n", config.SchemaVersion)
+	}
+
+	if len(rows) == 0 {
+		color.Green("No problems found.")
+		return 0, nil
+	}
+
+	columns := []table.Column{
+		{Title: "Connection"},
+		{Title: "Status"},
+		{Title: "Problem"},
+		{Title: "Since"},
+	}
+	return len(rows), ui.PrintTable(columns, rows, output)
+}
+
+// checkConnection checks that the connection can be used, without connecting to it:
+// its type must be supported, for example its plugin installed, and its stored secrets readable.
+func checkConnection(connection conn.Connection) error {
+	popsConn, err := conn.GetConnection(connection)
+	if err != nil {
+		return err
+	}
+	if closer, ok := popsConn.(io.Closer); ok {
+		closer.Close()
+	}
+
+	if details, ok := connection.Details.(conn.SecretDetails); ok {
+		for _, ref := range details.SecretRefs() {
+			if _, err := secret.Resolve(ref); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
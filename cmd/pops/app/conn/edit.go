@@ -0,0 +1,159 @@
+package conn
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/prompt-ops/pops/pkg/config"
+	"github.com/prompt-ops/pops/pkg/conn"
+	"github.com/prompt-ops/pops/pkg/secret"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// newEditCmd creates the edit command for the connection.
+func newEditCmd() *cobra.Command {
+	var (
+		readOnly         bool
+		allowPipelines   bool
+		extraTools       []string
+		rowLimit         int
+		connectionString string
+		description      string
+		labels           []string
+		removeLabels     []string
+		environment      string
+		owner            string
+	)
+
+	editCmd := &cobra.Command{
+		Use:   "edit [conn-name]",
+		Short: "Edit the settings and metadata of a connection",
+		Long: `Edit the settings and metadata of a connection.
+
+Only the settings given as flags are changed.
+The description, labels, environment and owner are shown by 'pops connection list', which can select
+connections by label; the shell shows a banner for connections in the prod environment.
+Read-only connections only run commands that don't change anything: SELECT queries in read-only transactions
+for databases, get, describe, logs and top for Kubernetes, and list and show commands for Azure.`,
+		Example: `
+- **pops connection edit my-conn --read-only** - Only allow commands that don't change anything.
+- **pops connection edit my-conn --read-only=false** - Allow all commands again.
+- **pops connection edit my-conn --extra-tools jq,helm** - Allow generated commands to run jq and helm.
+- **pops connection edit my-db --environment prod --owner payments-team --description "Orders database"** - Describe a connection.
+- **pops connection edit my-db --label team=payments --label tier=db --remove-label legacy** - Change the labels of a connection.
+- **pops connection edit my-db --connection-string 'postgres://app:${env:PGPASSWORD}@db/app'** - Change the connection string of a database.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			connectionName := args[0]
+			connection, err := config.GetConnectionByName(connectionName)
+			if err != nil {
+				color.Red("Error editing connection: %v", err)
+				os.Exit(1)
+			}
+
+			var previousRef secret.Ref
+			flags := cmd.Flags()
+			if flags.NFlag() == 0 {
+				color.Red("Nothing to change; use --read-only, --allow-pipelines, --extra-tools, --row-limit, --connection-string, --description, --label, --remove-label, --environment or --owner")
+				os.Exit(1)
+			}
+
+			if flags.Changed("read-only") {
+				connection.ReadOnly = readOnly
+			}
+			if flags.Changed("allow-pipelines") {
+				connection.AllowPipelines = allowPipelines
+			}
+			if flags.Changed("extra-tools") {
+				connection.ExtraTools = extraTools
+			}
+			if flags.Changed("row-limit") {
+				if rowLimit < 0 {
+					color.Red("Error editing connection: row limit can't be negative")
+					os.Exit(1)
+				}
+				connection.RowLimit = rowLimit
+			}
+			if flags.Changed("description") {
+				connection.Description = strings.TrimSpace(description)
+			}
+			if flags.Changed("environment") {
+				if connection.Environment, err = conn.ParseEnvironment(environment); err != nil {
+					color.Red("Error editing connection: %v", err)
+					os.Exit(1)
+				}
+			}
+			if flags.Changed("owner") {
+				connection.Owner = strings.TrimSpace(owner)
+			}
+			if flags.Changed("label") || flags.Changed("remove-label") {
+				added, err := conn.ParseLabels(labels)
+				if err != nil {
+					color.Red("Error editing connection: %v", err)
+					os.Exit(1)
+				}
+
+				updated := map[string]string{}
+				for key, value := range connection.Labels {
+					updated[key] = value
+				}
+				for _, key := range removeLabels {
+					delete(updated, key)
+				}
+				for key, value := range added {
+					updated[key] = value
+				}
+				connection.Labels = updated
+				if len(updated) == 0 {
+					connection.Labels = nil
+				}
+			}
+			if flags.Changed("connection-string") {
+				details, err := conn.GetDatabaseConnectionDetails(connection)
+				if err != nil {
+					color.Red("Error editing connection: --connection-string: %v", err)
+					os.Exit(1)
+				}
+				// The connection string is moved to the secret store, replacing the stored one, when saving.
+				previousRef = details.ConnectionStringRef
+				details.ConnectionString = connectionString
+				details.ConnectionStringRef = ""
+				connection.Details = details
+			}
+
+			if err := config.SaveConnection(connection); err != nil {
+				color.Red("Error saving connection '%s': %v", connectionName, err)
+				os.Exit(1)
+			}
+
+			// A connection string with references isn't stored, so the stored one isn't needed anymore.
+			if previousRef != "" {
+				if saved, err := config.GetConnectionByName(connection.Name); err == nil {
+					if details, err := conn.GetDatabaseConnectionDetails(saved); err == nil && details.ConnectionStringRef != previousRef {
+						if err := secret.Delete(previousRef); err != nil {
+							color.Yellow("Warning: failed to delete the previous connection string from the secret store: %v", err)
+						}
+					}
+				}
+			}
+
+			fmt.Printf("✅ Connection '%s' updated.\n", connection.Name)
+		},
+	}
+
+	editCmd.Flags().BoolVar(&readOnly, "read-only", false, "Only allow commands that don't change anything")
+	editCmd.Flags().BoolVar(&allowPipelines, "allow-pipelines", false, "Allow generated commands that pipe into other programs")
+	editCmd.Flags().StringSliceVar(&extraTools, "extra-tools", nil, "Executables, besides the connection's own tool, that generated commands may run")
+	editCmd.Flags().IntVar(&rowLimit, "row-limit", 0, "How many rows of a result are fetched at once (0 for the default)")
+	editCmd.Flags().StringVar(&description, "description", "", "What the connection is for")
+	editCmd.Flags().StringSliceVar(&labels, "label", nil, "Labels to add or change, as key=value; can be repeated")
+	editCmd.Flags().StringSliceVar(&removeLabels, "remove-label", nil, "Keys of labels to remove; can be repeated")
+	editCmd.Flags().StringVar(&environment, "environment", "", "Environment of the connection: dev, staging or prod (empty for none)")
+	editCmd.Flags().StringVar(&owner, "owner", "", "Person or team responsible for the connection")
+	editCmd.Flags().StringVar(&connectionString, "connection-string", "", "Connection string of a database connection; prefer references like ${env:PGPASSWORD} for passwords")
+
+	return editCmd
+}
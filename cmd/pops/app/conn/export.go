@@ -0,0 +1,120 @@
+package conn
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/prompt-ops/pops/pkg/bundle"
+	"github.com/prompt-ops/pops/pkg/config"
+	"github.com/prompt-ops/pops/pkg/conn"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// newExportCmd creates the export command for the connections.
+func newExportCmd() *cobra.Command {
+	var (
+		all       bool
+		file      string
+		format    string
+		recipient string
+	)
+
+	exportCmd := &cobra.Command{
+		Use:   "export [conn-name...]",
+		Short: "Export connections to a bundle to share or import elsewhere",
+		Long: `Export connections to a bundle, a JSON or YAML file that 'pops connection import' reads.
+
+Secrets stored in the secret store, like database connection strings, are removed from the bundle unless
+--recipient is given: then they are encrypted for that public key, which the recipient prints with
+'pops connection key'. References like ${env:PGPASSWORD} are kept, as they hold no secrets.
+See docs/BUNDLE.md for the format.`,
+		Example: `
+- **pops connection export --all -f team.yaml** - Export all connections without their secrets.
+- **pops connection export prod-db staging-db -f dbs.json** - Export some connections.
+- **pops connection export prod-db --recipient <public key> -f prod-db.yaml** - Include secrets for a teammate.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if !all && len(args) == 0 {
+				color.Red("Name the connections to export, or use --all")
+				os.Exit(1)
+			}
+
+			connections, err := selectConnections(args, all)
+			if err != nil {
+				color.Red("Error exporting connections: %v", err)
+				os.Exit(1)
+			}
+
+			b, err := bundle.New(connections, recipient)
+			if err != nil {
+				color.Red("Error exporting connections: %v", err)
+				os.Exit(1)
+			}
+
+			if format == "" {
+				format = bundle.FormatYAML
+				if strings.EqualFold(filepath.Ext(file), ".json") {
+					format = bundle.FormatJSON
+				}
+			}
+
+			if err := writeBundle(b, file, format); err != nil {
+				color.Red("Error writing bundle: %v", err)
+				os.Exit(1)
+			}
+
+			for _, entry := range b.Connections {
+				if entry.SecretsRemoved {
+					// Warnings go to standard error, as the bundle may be written to standard output.
+					fmt.Fprintln(os.Stderr, color.YellowString("The secrets of connection '%s' are not in the bundle; use --recipient to include them encrypted.", entry.Connection.Name))
+				}
+			}
+			if file != "" {
+				fmt.Printf("✅ %d connection(s) exported to %s.\n", len(b.Connections), file)
+			}
+		},
+	}
+
+	exportCmd.Flags().BoolVar(&all, "all", false, "Export all connections of the workspace")
+	exportCmd.Flags().StringVarP(&file, "file", "f", "", "File to write the bundle to (defaults to standard output)")
+	exportCmd.Flags().StringVar(&format, "format", "", "Bundle format, json or yaml (defaults to the extension of --file, otherwise yaml)")
+	exportCmd.Flags().StringVar(&recipient, "recipient", "", "Public key to encrypt the secrets for, from 'pops connection key'")
+
+	return exportCmd
+}
+
+// selectConnections returns the connections with the names, or all connections.
+func selectConnections(names []string, all bool) ([]conn.Connection, error) {
+	if all {
+		return config.GetAllConnections()
+	}
+
+	connections := make([]conn.Connection, 0, len(names))
+	for _, name := range names {
+		connection, err := config.GetConnectionByName(name)
+		if err != nil {
+			return nil, err
+		}
+		connections = append(connections, connection)
+	}
+	return connections, nil
+}
+
+// writeBundle writes the bundle to the file, or to standard output if file is empty.
+// Bundles with encrypted secrets are only readable by the user.
+func writeBundle(b *bundle.Bundle, file, format string) error {
+	var w io.Writer = os.Stdout
+	if file != "" {
+		f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	return b.Encode(w, format)
+}
@@ -1,3 +1,4 @@
 package factory
 
-// This package provides factory functions for creating UI models based on the connection type.
+// This package provides the registry of connection type UI models and commands,
+// and factory functions for creating UI models based on the connection type.
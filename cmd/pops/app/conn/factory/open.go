@@ -2,11 +2,7 @@ package factory
 
 import (
 	"fmt"
-	"strings"
 
-	"github.com/prompt-ops/pops/cmd/pops/app/conn/cloud"
-	"github.com/prompt-ops/pops/cmd/pops/app/conn/db"
-	"github.com/prompt-ops/pops/cmd/pops/app/conn/k8s"
 	"github.com/prompt-ops/pops/pkg/conn"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -14,14 +10,9 @@ import (
 
 // GetOpenModel returns a new openModel based on the connection type
 func GetOpenModel(connection conn.Connection) (tea.Model, error) {
-	switch strings.ToLower(connection.Type.GetMainType()) {
-	case "cloud":
-		return cloud.NewOpenModel(), nil
-	case "kubernetes":
-		return k8s.NewOpenModel(), nil
-	case "database":
-		return db.NewOpenModel(), nil
-	default:
-		return nil, fmt.Errorf("[GetOpenModel] unsupported connection type: %s", connection.Type)
+	registration, ok := getRegistration(connection.Type.GetMainType())
+	if !ok || registration.NewOpenModel == nil {
+		return nil, fmt.Errorf("[GetOpenModel] unsupported connection type: %s", connection.Type.GetMainType())
 	}
+	return registration.NewOpenModel(), nil
 }
@@ -0,0 +1,82 @@
+package factory
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/prompt-ops/pops/pkg/conn"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/cobra"
+)
+
+// Registration describes the TUI models and the CLI of a connection type.
+// The connection type itself (decoders and implementation) is registered in pkg/conn
+// via conn.RegisterConnectionType; MainType must match that registration.
+type Registration struct {
+	// MainType is the main type of the connection.
+	// Example: "Database", "Cloud", "Kubernetes".
+	MainType string
+
+	// NewCreateModel returns the model that creates a connection of this type.
+	NewCreateModel func() tea.Model
+
+	// NewOpenModel returns the model that opens a connection of this type.
+	NewOpenModel func() tea.Model
+
+	// NewCommand returns the `pops connection <type>` command tree.
+	NewCommand func() *cobra.Command
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Registration{}
+)
+
+// Register registers the TUI models and the CLI of a connection type.
+// It panics if the connection type is not registered in pkg/conn or is registered twice.
+func Register(registration Registration) {
+	if _, err := conn.GetConnectionTypeRegistration(registration.MainType); err != nil {
+		panic(fmt.Sprintf("connection type %q must be registered in pkg/conn first", registration.MainType))
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	key := strings.ToLower(registration.MainType)
+	if _, exists := registry[key]; exists {
+		panic(fmt.Sprintf("connection type %q is already registered", registration.MainType))
+	}
+	registry[key] = registration
+}
+
+// getRegistration returns the registration of the given main type.
+func getRegistration(mainType string) (Registration, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	registration, ok := registry[strings.ToLower(mainType)]
+	return registration, ok
+}
+
+// Commands returns the `pops connection <type>` commands of all registered connection types.
+func Commands() []*cobra.Command {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	keys := make([]string, 0, len(registry))
+	for key := range registry {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var commands []*cobra.Command
+	for _, key := range keys {
+		if registry[key].NewCommand != nil {
+			commands = append(commands, registry[key].NewCommand())
+		}
+	}
+	return commands
+}
@@ -0,0 +1,108 @@
+package conn
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/prompt-ops/pops/pkg/bundle"
+	"github.com/prompt-ops/pops/pkg/config"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// newImportCmd creates the import command for the connections.
+func newImportCmd() *cobra.Command {
+	var onConflict string
+	var allowReferences bool
+
+	importCmd := &cobra.Command{
+		Use:   "import [file]",
+		Short: "Import the connections of a bundle",
+		Long: `Import the connections of a bundle written by 'pops connection export' into the current workspace.
+
+Encrypted secrets are decrypted with your key (see 'pops connection key') and saved in the secret store.
+Connections whose name is taken are handled as --on-conflict says: fail imports nothing, skip keeps the
+existing connection, overwrite replaces it, and rename imports the connection as "<name>-2".
+Connections with ${exec:...} or ${file:...} references, which run commands or read files on your machine
+when they are opened, are only imported with --allow-references, once you have checked them.
+Use - as the file to read the bundle from standard input.`,
+		Example: `
+- **pops connection import team.yaml** - Import the connections of a bundle.
+- **pops connection import team.yaml --on-conflict skip** - Keep existing connections with the same names.
+- **pops connection import team.yaml --on-conflict rename** - Import connections with taken names under new names.
+- **pops connection import team.yaml --allow-references** - Import connections that run secret helpers or read files.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			strategy, err := config.ParseOnConflict(onConflict)
+			if err != nil {
+				color.Red("Error: --on-conflict: %v", err)
+				os.Exit(1)
+			}
+
+			b, err := readBundle(args[0])
+			if err != nil {
+				color.Red("Error reading bundle: %v", err)
+				os.Exit(1)
+			}
+
+			var key *bundle.Key
+			if b.HasEncryptedSecrets() {
+				if key, err = bundle.LoadKey(); err != nil {
+					color.Red("Error reading your key to decrypt the secrets in the bundle: %v", err)
+					os.Exit(1)
+				}
+			}
+
+			connections, err := b.Decrypt(key)
+			if err != nil {
+				color.Red("Error importing connections: %v", err)
+				os.Exit(1)
+			}
+
+			results, err := config.ImportConnections(connections, strategy, allowReferences)
+			if err != nil {
+				color.Red("Error importing connections: %v", err)
+				os.Exit(1)
+			}
+
+			for _, result := range results {
+				switch result.Action {
+				case "skipped":
+					fmt.Printf("Skipped connection '%s', as a connection with its name exists.\n", result.Name)
+				case "renamed":
+					fmt.Printf("✅ Connection '%s' imported as '%s'.\n", result.Name, result.ImportedAs)
+				default:
+					fmt.Printf("✅ Connection '%s' %s.\n", result.ImportedAs, result.Action)
+				}
+			}
+			// Results are in the order of the connections in the bundle.
+			for i, result := range results {
+				if result.Action != "skipped" && b.Connections[i].SecretsRemoved {
+					color.Yellow("Connection '%s' was exported without its secrets; add them before using it, for example with 'pops connection edit %s --connection-string ...'.", result.ImportedAs, result.ImportedAs)
+				}
+			}
+		},
+	}
+
+	importCmd.Flags().StringVar(&onConflict, "on-conflict", string(config.OnConflictFail), "What to do with connections whose name is taken: fail, skip, overwrite or rename")
+	importCmd.Flags().BoolVar(&allowReferences, "allow-references", false, "Import connections with ${exec:...} or ${file:...} references")
+
+	return importCmd
+}
+
+// readBundle reads a bundle from the file, or from standard input if the file is "-".
+func readBundle(file string) (*bundle.Bundle, error) {
+	var data []byte
+	var err error
+	if file == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return bundle.Decode(data)
+}
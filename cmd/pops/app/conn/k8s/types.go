@@ -14,23 +14,27 @@ import (
 )
 
 func newTypesCmd() *cobra.Command {
+	var output string
+
 	listCmd := &cobra.Command{
 		Use:   "types",
 		Short: "List all available kubernetes connection types",
 		Long:  "List all available kubernetes connection types",
 		Run: func(cmd *cobra.Command, args []string) {
-			if err := runListAvaibleKubernetesTypes(); err != nil {
+			if err := runListAvaibleKubernetesTypes(output); err != nil {
 				color.Red("Error listing kubernetes connections: %v", err)
 				os.Exit(1)
 			}
 		},
 	}
 
+	listCmd.Flags().StringVarP(&output, "output", "o", "", "Print in an output format (table, json, yaml, csv or markdown) instead of the interactive table")
+
 	return listCmd
 }
 
 // runListAvaibleKubernetesTypes lists all available kubernetes connection types
-func runListAvaibleKubernetesTypes() error {
+func runListAvaibleKubernetesTypes(output string) error {
 	connectionTypes := conn.AvailableKubernetesConnectionTypes
 
 	items := make([]table.Row, len(connectionTypes))
@@ -42,6 +46,10 @@ func runListAvaibleKubernetesTypes() error {
 		{Title: "Available Types", Width: 25},
 	}
 
+	if output != "" {
+		return ui.PrintTable(columns, items, output)
+	}
+
 	t := table.New(
 		table.WithColumns(columns),
 		table.WithRows(items),
@@ -0,0 +1,35 @@
+package conn
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/prompt-ops/pops/pkg/bundle"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// newKeyCmd creates the key command, which prints the public key bundles are encrypted for.
+func newKeyCmd() *cobra.Command {
+	keyCmd := &cobra.Command{
+		Use:   "key",
+		Short: "Print your public key for receiving bundles with secrets",
+		Long: `Print your public key for receiving bundles with secrets.
+
+Give the key to whoever exports connections for you: 'pops connection export --recipient <key>' encrypts
+the secrets of the connections so only you can import them. The key pair is created on first use and kept
+in ~/.pops/bundle.key (or the file in POPS_BUNDLE_KEY).`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			key, err := bundle.LoadOrCreateKey()
+			if err != nil {
+				color.Red("Error reading your key: %v", err)
+				os.Exit(1)
+			}
+			fmt.Println(key.PublicKey())
+		},
+	}
+
+	return keyCmd
+}
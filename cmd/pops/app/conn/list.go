@@ -3,9 +3,15 @@ package conn
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 
 	config "github.com/prompt-ops/pops/pkg/config"
+	"github.com/prompt-ops/pops/pkg/conn"
 	"github.com/prompt-ops/pops/pkg/ui"
+	"github.com/prompt-ops/pops/pkg/workspace"
 
 	"github.com/charmbracelet/bubbles/table"
 	tea "github.com/charmbracelet/bubbletea"
@@ -14,38 +20,185 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// connectionSource describes where a connection is defined, like "workspace default" or "project /repo/.pops".
+func connectionSource(connection config.SourcedConnection) string {
+	if connection.Source == config.SourceProject {
+		source := "project " + filepath.Dir(connection.Path)
+		if connection.Overrides {
+			source += " (overrides workspace)"
+		}
+		return source
+	}
+	return "workspace " + connection.Workspace
+}
+
+// Orders of connections in 'pops connection list'.
+const (
+	sortNone   = ""
+	sortName   = "name"
+	sortRecent = "recent"
+)
+
+// lastUsed describes when the connection was last used, like "3h ago", or as a timestamp in output formats.
+func lastUsed(connection config.SourcedConnection, output string) string {
+	if connection.LastUsedAt == nil {
+		return "never"
+	}
+	if output != "" {
+		return connection.LastUsedAt.Local().Format(time.RFC3339)
+	}
+	return humanizeSince(*connection.LastUsedAt) + " ago"
+}
+
+// humanizeSince returns how long ago the time was, in its largest unit.
+func humanizeSince(t time.Time) string {
+	elapsed := time.Since(t)
+	switch {
+	case elapsed < time.Minute:
+		return "<1m"
+	case elapsed < time.Hour:
+		return fmt.Sprintf("%dm", int(elapsed.Minutes()))
+	case elapsed < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(elapsed.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(elapsed.Hours()/24))
+	}
+}
+
+// filterConnections returns the connections that match the selector and are in the environment, if it is set.
+func filterConnections(connections []config.SourcedConnection, selector conn.Selector, environment string) []config.SourcedConnection {
+	var filtered []config.SourcedConnection
+	for _, connection := range connections {
+		if environment != "" && connection.Environment != environment {
+			continue
+		}
+		if !selector.Matches(connection.Labels) {
+			continue
+		}
+		filtered = append(filtered, connection)
+	}
+	return filtered
+}
+
+// sortConnections orders the connections by name, or by when they were last used, most recent first.
+func sortConnections(connections []config.SourcedConnection, order string) {
+	switch order {
+	case sortName:
+		sort.SliceStable(connections, func(i, j int) bool {
+			return strings.ToLower(connections[i].Name) < strings.ToLower(connections[j].Name)
+		})
+	case sortRecent:
+		sort.SliceStable(connections, func(i, j int) bool {
+			// Connections that were never used come last.
+			if connections[j].LastUsedAt == nil {
+				return connections[i].LastUsedAt != nil
+			}
+			return connections[i].LastUsedAt != nil && connections[i].LastUsedAt.After(*connections[j].LastUsedAt)
+		})
+	}
+}
+
 func newListCmd() *cobra.Command {
+	var (
+		output        string
+		allWorkspaces bool
+		selector      string
+		environment   string
+		order         string
+	)
+
 	listCmd := &cobra.Command{
 		Use:   "list",
 		Short: "List all connections",
-		Long:  "List all connections that have been set up.",
+		Long: `List all connections that have been set up in the current workspace and the trusted project directory,
+or in all workspaces with --all-workspaces. The source column shows where each connection is defined.
+
+--selector selects connections by their labels: comma separated requirements that are key=value, key!=value,
+key for labels that are set, or !key for labels that aren't set. All of them must match.`,
+		Example: `
+- **pops connection list --environment prod** - List the production connections.
+- **pops connection list -l team=payments,!deprecated** - List the connections of a team that aren't deprecated.
+- **pops connection list --sort recent** - List the most recently used connections first.`,
 		Run: func(cmd *cobra.Command, args []string) {
-			if err := runListConnections(); err != nil {
+			if err := runListConnections(output, allWorkspaces, selector, environment, order); err != nil {
 				color.Red("Error listing connections: %v", err)
 				os.Exit(1)
 			}
 		},
 	}
 
+	listCmd.Flags().StringVarP(&output, "output", "o", "", "Print in an output format (table, json, yaml, csv or markdown) instead of the interactive table")
+	listCmd.Flags().BoolVarP(&allWorkspaces, "all-workspaces", "A", false, "List the connections of all workspaces")
+	listCmd.Flags().StringVarP(&selector, "selector", "l", "", "Only list connections whose labels match, like team=payments,tier!=db")
+	listCmd.Flags().StringVar(&environment, "environment", "", "Only list connections in the environment (dev, staging or prod)")
+	listCmd.Flags().StringVar(&order, "sort", sortNone, "Order of the connections: name, or recent for the most recently used first")
+
 	return listCmd
 }
 
-// runListConnections lists all connections
-func runListConnections() error {
-	connections, err := config.GetAllConnections()
+// runListConnections lists the connections of the current workspace and the project directory,
+// or of all workspaces, that match the selector and environment, with where each is defined.
+func runListConnections(output string, allWorkspaces bool, selector, environment, order string) error {
+	labelSelector, err := conn.ParseSelector(selector)
+	if err != nil {
+		return err
+	}
+	if environment, err = conn.ParseEnvironment(environment); err != nil {
+		return err
+	}
+	order = strings.ToLower(order)
+	if order != sortNone && order != sortName && order != sortRecent {
+		return fmt.Errorf("unknown order '%s'; use %s or %s", order, sortName, sortRecent)
+	}
+
+	var otherWorkspaces []string
+	if allWorkspaces {
+		if otherWorkspaces, err = workspace.List(); err != nil {
+			return fmt.Errorf("getting workspaces: %w", err)
+		}
+	}
+
+	connections, err := config.ListConnections(otherWorkspaces...)
 	if err != nil {
 		return fmt.Errorf("getting connections: %w", err)
 	}
+	connections = filterConnections(connections, labelSelector, environment)
+	sortConnections(connections, order)
 
 	items := make([]table.Row, len(connections))
-	for i, conn := range connections {
-		items[i] = table.Row{conn.Name, conn.Type.GetMainType(), conn.Type.GetSubtype()}
+	for i, connection := range connections {
+		readOnly := "no"
+		if connection.ReadOnly {
+			readOnly = "yes"
+		}
+		items[i] = table.Row{
+			connection.Name, connection.Type.GetMainType(), connection.Type.GetSubtype(), connection.Environment, conn.FormatLabels(connection.Labels),
+			readOnly, lastUsed(connection, output), connectionSource(connection),
+		}
 	}
 
 	columns := []table.Column{
 		{Title: "Name", Width: 25},
-		{Title: "Type", Width: 15},
-		{Title: "Subtype", Width: 20},
+		{Title: "Type", Width: 12},
+		{Title: "Subtype", Width: 15},
+		{Title: "Environment", Width: 12},
+		{Title: "Labels", Width: 25},
+		{Title: "Read-only", Width: 10},
+		{Title: "Last used", Width: 10},
+		{Title: "Source", Width: 30},
+	}
+
+	if output != "" {
+		// Output formats also have the description, owner and creation time, which don't fit the interactive table.
+		columns = append(columns, table.Column{Title: "Description"}, table.Column{Title: "Owner"}, table.Column{Title: "Created"})
+		for i, connection := range connections {
+			created := ""
+			if connection.CreatedAt != nil {
+				created = connection.CreatedAt.Local().Format(time.RFC3339)
+			}
+			items[i] = append(items[i], connection.Description, connection.Owner, created)
+		}
+		return ui.PrintTable(columns, items, output)
 	}
 
 	t := table.New(
@@ -0,0 +1,38 @@
+package plugin
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func NewRootCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "plugin",
+		Short: "Manage connections served by external plugins.",
+		Long: `
+Plugin Connection:
+
+- Available plugin connection types: Every executable named 'pops-plugin-<type>' on your PATH.
+- Plugins implement CheckAuthentication, SetContext/GetContext, ExecuteCommand and FormatResultAsTable
+  over JSON-RPC 2.0 on stdin/stdout.
+- Commands: create, open, list, types.
+- Examples:
+ * 'pops conn plugin create' creates a connection served by a plugin.
+ * 'pops conn plugin open' opens an existing plugin connection.
+ * 'pops conn plugin list' lists all plugin connections.
+ * 'pops conn plugin types' lists all plugins found on your PATH.`,
+	}
+
+	// `pops connection plugin create *` commands
+	cmd.AddCommand(newCreateCmd())
+
+	// `pops connection plugin open *` commands
+	cmd.AddCommand(newOpenCmd())
+
+	// `pops connection plugin list` command
+	cmd.AddCommand(newListCmd())
+
+	// `pops connection plugin types` command
+	cmd.AddCommand(newTypesCmd())
+
+	return cmd
+}
@@ -0,0 +1,81 @@
+package plugin
+
+import (
+	"os"
+
+	"github.com/prompt-ops/pops/pkg/conn"
+	"github.com/prompt-ops/pops/pkg/ui"
+
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+func newTypesCmd() *cobra.Command {
+	var output string
+
+	listCmd := &cobra.Command{
+		Use:   "types",
+		Short: "List all plugins found on PATH",
+		Long:  "List all plugins found on PATH",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runListAvaiblePluginTypes(output); err != nil {
+				color.Red("Error listing plugins: %v", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	listCmd.Flags().StringVarP(&output, "output", "o", "", "Print in an output format (table, json, yaml, csv or markdown) instead of the interactive table")
+
+	return listCmd
+}
+
+// runListAvaiblePluginTypes lists all plugins found on PATH
+func runListAvaiblePluginTypes(output string) error {
+	plugins := conn.DiscoverPlugins()
+
+	items := make([]table.Row, len(plugins))
+	for i, plugin := range plugins {
+		items[i] = table.Row{plugin.Subtype, plugin.Path}
+	}
+
+	columns := []table.Column{
+		{Title: "Available Types", Width: 25},
+		{Title: "Path", Width: 50},
+	}
+
+	if output != "" {
+		return ui.PrintTable(columns, items, output)
+	}
+
+	t := table.New(
+		table.WithColumns(columns),
+		table.WithRows(items),
+		table.WithFocused(true),
+		table.WithHeight(10),
+	)
+
+	s := table.DefaultStyles()
+	s.Header = s.Header.
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderForeground(lipgloss.Color("240")).
+		BorderBottom(true).
+		Bold(false)
+	s.Selected = s.Selected.
+		Foreground(lipgloss.Color("0")).
+		Background(lipgloss.Color("212")).
+		Bold(true)
+	t.SetStyles(s)
+
+	openTableModel := ui.NewTableModel(t, nil, true)
+
+	p := tea.NewProgram(openTableModel)
+	if _, err := p.Run(); err != nil {
+		panic(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package conn
+
+import (
+	"fmt"
+
+	"github.com/prompt-ops/pops/pkg/cache"
+	"github.com/prompt-ops/pops/pkg/config"
+	"github.com/prompt-ops/pops/pkg/conn"
+	"github.com/prompt-ops/pops/pkg/ui"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// newRefreshCmd creates the refresh command for the connection.
+func newRefreshCmd() *cobra.Command {
+	refreshCmd := &cobra.Command{
+		Use:   "refresh [conn-name]",
+		Short: "Refresh the cached context of a connection",
+		Long: `Refresh the cached context of a connection.
+
+The context (database schema, cluster inventory, cloud resources) is cached under ~/.pops/cache
+and refreshed in the background when a connection is opened after the cache expired.
+The cache expires after 24 hours by default; set POPS_CONTEXT_CACHE_TTL (e.g. "30m") to change it, or "0" to disable the cache.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			connectionName := args[0]
+			err := ui.RunWithSpinner(fmt.Sprintf("Refreshing context of connection '%s'...", connectionName), func() error {
+				return refreshConnection(connectionName)
+			})
+			if err != nil {
+				color.Red("Failed to refresh connection '%s': %v", connectionName, err)
+			}
+		},
+	}
+
+	return refreshCmd
+}
+
+// refreshConnection gathers the context of a connection and stores it in the cache.
+func refreshConnection(name string) error {
+	connection, err := config.GetConnectionByName(name)
+	if err != nil {
+		return err
+	}
+
+	popsConn, err := conn.GetConnection(connection)
+	if err != nil {
+		return err
+	}
+
+	if err := popsConn.CheckAuthentication(); err != nil {
+		return err
+	}
+
+	return cache.RefreshContext(popsConn)
+}
@@ -1,6 +1,7 @@
 package conn
 
 import (
+	"fmt"
 	"os"
 
 	"github.com/prompt-ops/pops/pkg/conn"
@@ -14,36 +15,51 @@ import (
 )
 
 func newTypesCmd() *cobra.Command {
+	var output string
+
 	listCmd := &cobra.Command{
 		Use:   "types",
 		Short: "List all available connection types",
 		Long:  "List all available connection types",
 		Run: func(cmd *cobra.Command, args []string) {
-			if err := runListAvaibleTypes(); err != nil {
+			if err := runListAvaibleTypes(output); err != nil {
 				color.Red("Error listing connections: %v", err)
 				os.Exit(1)
 			}
 		},
 	}
 
+	listCmd.Flags().StringVarP(&output, "output", "o", "", "Print in an output format (table, json, yaml, csv or markdown) instead of the interactive table")
+
 	return listCmd
 }
 
 // runListAvaibleTypes lists all available connection types
-func runListAvaibleTypes() error {
+func runListAvaibleTypes(output string) error {
 	connectionTypes := conn.AvailableConnectionTypes()
 
-	items := make([]table.Row, len(connectionTypes))
-	for i, connectionType := range connectionTypes {
-		items[i] = table.Row{
+	items := make([]table.Row, 0, len(connectionTypes))
+	for _, connectionType := range connectionTypes {
+		items = append(items, table.Row{
 			connectionType,
-		}
+		})
+	}
+
+	// Plugins found on PATH are listed as "Plugin: <type>".
+	for _, plugin := range conn.DiscoverPlugins() {
+		items = append(items, table.Row{
+			fmt.Sprintf("%s: %s", conn.ConnectionTypePlugin, plugin.Subtype),
+		})
 	}
 
 	columns := []table.Column{
 		{Title: "Available Types", Width: 25},
 	}
 
+	if output != "" {
+		return ui.PrintTable(columns, items, output)
+	}
+
 	t := table.New(
 		table.WithColumns(columns),
 		table.WithRows(items),
@@ -0,0 +1,42 @@
+package policy
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// NewPolicyCommand creates the 'policy' command to work with the policy file.
+func NewPolicyCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "policy",
+		Short: "Check generated commands against the policy file.",
+		Long: `
+The policy file (~/.pops/policy.yaml, or the file in POPS_POLICY_FILE) has rules that allow, warn about or deny
+generated commands before they are confirmed and run. The first rule matching a command decides; commands that
+match no rule are allowed.
+
+rules:
+  - name: no-unbounded-delete
+    connections: ["prod-db"]
+    match: ['(?i)^\s*delete\b']
+    unless: ['(?i)\bwhere\b']
+    action: deny
+    message: DELETE without WHERE is not allowed on prod-db.
+  - name: no-exec-in-payments
+    types: ["Kubernetes"]
+    match: ['\bexec\b', '(-n|--namespace)[= ]payments\b']
+    action: deny
+    message: Don't exec into pods in the payments namespace.
+  - name: destructive-on-prod
+    connections: ["prod-*"]
+    risk: destructive
+    action: warn
+    message: This is a production connection.
+
+A trusted project directory (.pops in the repository, see 'pops project') can have its own policy.yaml.
+Both policies apply, and the stricter decision wins.`,
+	}
+
+	cmd.AddCommand(newTestCmd())
+
+	return cmd
+}
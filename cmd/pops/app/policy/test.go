@@ -0,0 +1,167 @@
+package policy
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/prompt-ops/pops/pkg/config"
+	"github.com/prompt-ops/pops/pkg/conn"
+	"github.com/prompt-ops/pops/pkg/policy"
+	"github.com/prompt-ops/pops/pkg/ui"
+
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// sample is a command to check against the policy, with the expected action.
+type sample struct {
+	Connection string        `yaml:"connection"`
+	Command    string        `yaml:"command"`
+	Expect     policy.Action `yaml:"expect,omitempty"`
+}
+
+// newTestCmd creates the test command for the policy.
+func newTestCmd() *cobra.Command {
+	var (
+		connectionName string
+		samplesFile    string
+		policyFile     string
+		output         string
+	)
+
+	testCmd := &cobra.Command{
+		Use:   "test [command...]",
+		Short: "Check sample commands against the policy rules",
+		Long: `Check sample commands against the policy rules and show the decision for each of them.
+
+Commands are given as arguments together with --connection, or in a samples file:
+
+tests:
+  - connection: prod-db
+    command: DELETE FROM users
+    expect: deny
+
+If a sample has an expected action and the decision differs, the command exits with an error.`,
+		Example: `
+- **pops policy test --connection prod-db "DELETE FROM users"** - Show what the policy decides for a command.
+- **pops policy test --samples policy-tests.yaml** - Check the expected decisions of sample commands.
+- **pops policy test --file ./policy.yaml --samples policy-tests.yaml** - Check a policy file before installing it.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			samples, err := loadSamples(connectionName, samplesFile, args)
+			if err != nil {
+				color.Red("Error: %v", err)
+				os.Exit(1)
+			}
+
+			// Without --file, the policy of the project directory applies too.
+			var p policy.Policies
+			if policyFile == "" {
+				p, err = policy.LoadAll()
+			} else {
+				var filePolicy *policy.Policy
+				filePolicy, err = policy.LoadFile(policyFile)
+				p = policy.Policies{filePolicy}
+			}
+			if err != nil {
+				color.Red("Error loading policy: %v", err)
+				os.Exit(1)
+			}
+
+			failed, err := runPolicyTest(p, samples, output)
+			if err != nil {
+				color.Red("Error: %v", err)
+				os.Exit(1)
+			}
+			if failed > 0 {
+				color.Red("%d of %d samples didn't get the expected decision", failed, len(samples))
+				os.Exit(1)
+			}
+		},
+	}
+
+	testCmd.Flags().StringVarP(&connectionName, "connection", "c", "", "Connection the commands given as arguments are generated for")
+	testCmd.Flags().StringVar(&samplesFile, "samples", "", "YAML file with sample commands and expected decisions")
+	testCmd.Flags().StringVar(&policyFile, "file", "", "Policy file to check (defaults to the policy of the workspace or POPS_POLICY_FILE, and of the project directory)")
+	testCmd.Flags().StringVarP(&output, "output", "o", "table", "Output format (table, json, yaml, csv or markdown)")
+
+	return testCmd
+}
+
+// loadSamples returns the commands given as arguments, or the samples of the samples file.
+func loadSamples(connectionName, samplesFile string, commands []string) ([]sample, error) {
+	var samples []sample
+	for _, command := range commands {
+		if connectionName == "" {
+			return nil, fmt.Errorf("--connection is required for commands given as arguments")
+		}
+		samples = append(samples, sample{Connection: connectionName, Command: command})
+	}
+
+	if samplesFile != "" {
+		data, err := os.ReadFile(samplesFile)
+		if err != nil {
+			return nil, err
+		}
+
+		var file struct {
+			Tests []sample `yaml:"tests"`
+		}
+		if err := yaml.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("invalid samples file %s: %w", samplesFile, err)
+		}
+		samples = append(samples, file.Tests...)
+	}
+
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("no commands to check; give commands as arguments or use --samples")
+	}
+	return samples, nil
+}
+
+// runPolicyTest prints the decision for each sample and returns how many didn't get the expected decision.
+func runPolicyTest(p policy.Policies, samples []sample, output string) (int, error) {
+	columns := []table.Column{
+		{Title: "Connection"},
+		{Title: "Command"},
+		{Title: "Risk"},
+		{Title: "Decision"},
+		{Title: "Rule"},
+		{Title: "Message"},
+		{Title: "Result"},
+	}
+
+	failed := 0
+	rows := make([]table.Row, len(samples))
+	for i, s := range samples {
+		connection, err := config.GetConnectionByName(s.Connection)
+		if err != nil {
+			return 0, err
+		}
+		popsConn, err := conn.GetConnection(connection)
+		if err != nil {
+			return 0, err
+		}
+
+		risk := popsConn.ClassifyCommand(s.Command).Risk
+		decision := p.Evaluate(policy.Input{
+			Connection: connection,
+			Command:    s.Command,
+			Risk:       risk,
+		})
+
+		result := ""
+		if s.Expect != "" {
+			result = "ok"
+			if decision.Action != s.Expect {
+				result = fmt.Sprintf("FAIL (expected %s)", s.Expect)
+				failed++
+			}
+		}
+
+		rows[i] = table.Row{connection.Name, s.Command, risk.String(), string(decision.Action), decision.Rule, decision.Message, result}
+	}
+
+	return failed, ui.PrintTable(columns, rows, output)
+}
@@ -0,0 +1,112 @@
+package project
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/prompt-ops/pops/pkg/project"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// NewProjectCommand creates the 'project' command to work with project directories.
+func NewProjectCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "project",
+		Short: "Show and trust the project directory of the working directory.",
+		Long: `
+A repository can carry its own pops connections and policy in a .pops directory, for example a service that
+defines its dev database and its Kubernetes namespace:
+
+  .pops/connections.json   connections, in the format of ~/.pops/connections.json
+  .pops/policy.yaml        policy rules, in the format of ~/.pops/policy.yaml
+
+pops uses the nearest .pops directory above the working directory (below the home directory), or the one in
+POPS_PROJECT_DIR ("none" to use none). Its connections take precedence over connections of the workspace with
+the same name, and both policies apply, the stricter decision winning. Project connections are changed by
+editing the file, not with pops.
+
+As project files can run commands, for example with ${exec:...} references, a project directory is only used
+after 'pops project trust', and again after its files change.`,
+		Example: `
+- **pops project** - Show the project directory and whether it is trusted.
+- **pops project trust** - Use the connections and policy of the project directory.
+- **pops project untrust** - Stop using them.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			p := currentProject()
+
+			trusted, err := p.IsTrusted()
+			if err != nil {
+				color.Red("Error: %v", err)
+				os.Exit(1)
+			}
+
+			fmt.Printf("Project directory: %s\n", p.Dir)
+			for _, filename := range project.Files {
+				if _, err := os.Stat(p.Path(filename)); err == nil {
+					fmt.Printf("  %s\n", filename)
+				}
+			}
+			if trusted {
+				color.Green("Trusted.")
+			} else {
+				color.Yellow("Not trusted, or changed since it was trusted; run 'pops project trust' to use it.")
+			}
+		},
+	}
+
+	cmd.AddCommand(newTrustCmd())
+	cmd.AddCommand(newUntrustCmd())
+
+	return cmd
+}
+
+// newTrustCmd creates the trust command for project directories.
+func newTrustCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "trust",
+		Short: "Trust the project directory with its files as they are",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			p := currentProject()
+			if err := p.Trust(); err != nil {
+				color.Red("Error trusting %s: %v", p.Dir, err)
+				os.Exit(1)
+			}
+			fmt.Printf("✅ Project directory %s trusted.\n", p.Dir)
+		},
+	}
+}
+
+// newUntrustCmd creates the untrust command for project directories.
+func newUntrustCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "untrust",
+		Short: "Stop trusting the project directory",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			p := currentProject()
+			if err := p.Untrust(); err != nil {
+				color.Red("Error: %v", err)
+				os.Exit(1)
+			}
+			fmt.Printf("✅ Project directory %s is not trusted anymore.\n", p.Dir)
+		},
+	}
+}
+
+// currentProject returns the project directory of the working directory, exiting if there is none.
+func currentProject() *project.Project {
+	p, err := project.Current()
+	if err != nil {
+		color.Red("Error: %v", err)
+		os.Exit(1)
+	}
+	if p == nil {
+		color.Yellow("No %s directory found in the working directory or its parents.", project.DirName)
+		os.Exit(1)
+	}
+	return p
+}
@@ -4,22 +4,54 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/prompt-ops/pops/cmd/pops/app/audit"
+	configcmd "github.com/prompt-ops/pops/cmd/pops/app/config"
 	"github.com/prompt-ops/pops/cmd/pops/app/conn"
+	"github.com/prompt-ops/pops/cmd/pops/app/policy"
+	"github.com/prompt-ops/pops/cmd/pops/app/project"
+	workspacecmd "github.com/prompt-ops/pops/cmd/pops/app/workspace"
+	"github.com/prompt-ops/pops/pkg/workspace"
 	"github.com/spf13/cobra"
 )
 
 func NewRootCommand() *cobra.Command {
+	var workspaceName string
+
 	cmd := &cobra.Command{
 		Use:   "pops",
 		Short: "Prompt-Ops manages your infrastructure using natural language.",
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := workspace.Select(workspaceName); err != nil {
+				cmd.SilenceUsage = true
+				return err
+			}
+			return nil
+		},
 	}
 
+	cmd.PersistentFlags().StringVarP(&workspaceName, "workspace", "w", "", "Workspace to use (defaults to POPS_WORKSPACE or the one chosen with 'pops workspace use')")
+
 	// `pops version` command
 	cmd.AddCommand(NewVersionCmd)
 
 	// `pops connection (conn as alias)` commands
 	cmd.AddCommand(conn.NewConnectionCommand())
 
+	// `pops policy` commands
+	cmd.AddCommand(policy.NewPolicyCommand())
+
+	// `pops audit` commands
+	cmd.AddCommand(audit.NewAuditCommand())
+
+	// `pops project` commands
+	cmd.AddCommand(project.NewProjectCommand())
+
+	// `pops config` commands
+	cmd.AddCommand(configcmd.NewConfigCommand())
+
+	// `pops workspace (ws as alias)` commands
+	cmd.AddCommand(workspacecmd.NewWorkspaceCommand())
+
 	return cmd
 }
 
@@ -0,0 +1,42 @@
+package workspace
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/prompt-ops/pops/pkg/workspace"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// newCreateCmd creates the create command for workspaces.
+func newCreateCmd() *cobra.Command {
+	var use bool
+
+	createCmd := &cobra.Command{
+		Use:   "create [name]",
+		Short: "Create a workspace",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			name := args[0]
+			if err := workspace.Create(name); err != nil {
+				color.Red("Error creating workspace: %v", err)
+				os.Exit(1)
+			}
+			fmt.Printf("✅ Workspace '%s' created.\n", name)
+
+			if use {
+				if err := workspace.Use(name); err != nil {
+					color.Red("Error using workspace: %v", err)
+					os.Exit(1)
+				}
+				fmt.Printf("Using workspace '%s'.\n", name)
+			}
+		},
+	}
+
+	createCmd.Flags().BoolVar(&use, "use", false, "Use the new workspace for the next pops commands")
+
+	return createCmd
+}
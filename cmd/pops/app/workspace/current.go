@@ -0,0 +1,24 @@
+package workspace
+
+import (
+	"fmt"
+
+	"github.com/prompt-ops/pops/pkg/workspace"
+
+	"github.com/spf13/cobra"
+)
+
+// newCurrentCmd creates the current command for workspaces.
+func newCurrentCmd() *cobra.Command {
+	currentCmd := &cobra.Command{
+		Use:   "current",
+		Short: "Show the current workspace and how it was selected",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			name, source := workspace.CurrentWithSource()
+			fmt.Printf("%s (selected by %s, %s)\n", name, source, workspace.Dir(name))
+		},
+	}
+
+	return currentCmd
+}
@@ -0,0 +1,57 @@
+package workspace
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/prompt-ops/pops/pkg/config"
+	"github.com/prompt-ops/pops/pkg/workspace"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// newDeleteCmd creates the delete command for workspaces.
+func newDeleteCmd() *cobra.Command {
+	var force bool
+
+	deleteCmd := &cobra.Command{
+		Use:   "delete [name]",
+		Short: "Delete a workspace with its connections",
+		Long: `Delete a workspace with its connections, their stored secrets, its context cache and its audit log.
+
+Workspaces that have connections are only deleted with --force. The default workspace can't be deleted.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			name := args[0]
+			if name == workspace.Default {
+				color.Red("Error: the default workspace can't be deleted")
+				os.Exit(1)
+			}
+			if !workspace.Exists(name) {
+				color.Red("Error: workspace '%s' does not exist", name)
+				os.Exit(1)
+			}
+
+			connections, err := config.WorkspaceStore(name).Load()
+			if err != nil {
+				color.Red("Error reading the connections of workspace '%s': %v", name, err)
+				os.Exit(1)
+			}
+			if len(connections) > 0 && !force {
+				color.Red("Workspace '%s' has %d connection(s); use --force to delete it with them.", name, len(connections))
+				os.Exit(1)
+			}
+
+			if err := config.DeleteWorkspace(name); err != nil {
+				color.Red("Error deleting workspace: %v", err)
+				os.Exit(1)
+			}
+			fmt.Printf("✅ Workspace '%s' deleted.\n", name)
+		},
+	}
+
+	deleteCmd.Flags().BoolVar(&force, "force", false, "Delete the workspace even if it has connections")
+
+	return deleteCmd
+}
@@ -0,0 +1,66 @@
+package workspace
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/prompt-ops/pops/pkg/config"
+	"github.com/prompt-ops/pops/pkg/ui"
+	"github.com/prompt-ops/pops/pkg/workspace"
+
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// newListCmd creates the list command for workspaces.
+func newListCmd() *cobra.Command {
+	var output string
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the workspaces",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runList(output); err != nil {
+				color.Red("Error listing workspaces: %v", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	listCmd.Flags().StringVarP(&output, "output", "o", "table", "Output format (table, json, yaml, csv or markdown)")
+
+	return listCmd
+}
+
+// runList prints the workspaces with their number of connections, marking the current one.
+func runList(output string) error {
+	names, err := workspace.List()
+	if err != nil {
+		return err
+	}
+
+	current := workspace.Current()
+	rows := make([]table.Row, 0, len(names))
+	for _, name := range names {
+		marker := ""
+		if name == current {
+			marker = "*"
+		}
+
+		count := "?"
+		if connections, err := config.WorkspaceStore(name).Load(); err == nil {
+			count = fmt.Sprint(len(connections))
+		}
+		rows = append(rows, table.Row{marker, name, count, workspace.Dir(name)})
+	}
+
+	columns := []table.Column{
+		{Title: "Current"},
+		{Title: "Name"},
+		{Title: "Connections"},
+		{Title: "Directory"},
+	}
+	return ui.PrintTable(columns, rows, output)
+}
@@ -0,0 +1,40 @@
+package workspace
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/prompt-ops/pops/pkg/workspace"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// newUseCmd creates the use command for workspaces.
+func newUseCmd() *cobra.Command {
+	useCmd := &cobra.Command{
+		Use:   "use [name]",
+		Short: "Use a workspace for the next pops commands",
+		Long: `Use a workspace for the next pops commands.
+
+--workspace and the POPS_WORKSPACE environment variable take precedence over the workspace chosen here.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			name := args[0]
+			if err := workspace.Use(name); err != nil {
+				color.Red("Error using workspace: %v", err)
+				if !workspace.Exists(name) {
+					fmt.Printf("Create it with 'pops workspace create %s'.\n", name)
+				}
+				os.Exit(1)
+			}
+			fmt.Printf("✅ Using workspace '%s'.\n", name)
+
+			if value := os.Getenv(workspace.EnvVar); value != "" && value != name {
+				color.Yellow("%s is set to '%s', which takes precedence in this shell.", workspace.EnvVar, value)
+			}
+		},
+	}
+
+	return useCmd
+}
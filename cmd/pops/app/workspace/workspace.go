@@ -0,0 +1,35 @@
+package workspace
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// NewWorkspaceCommand creates the 'workspace' command to manage workspaces.
+func NewWorkspaceCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "workspace",
+		Aliases: []string{"ws"},
+		Short:   "Manage workspaces, separate sets of connections.",
+		Long: `
+A workspace has its own connections, context cache, audit log and settings, so the connections of different
+clients or environments are kept apart. The default workspace is ~/.pops, and named workspaces are kept in
+~/.pops/workspaces/<name>. A workspace that has no policy.yaml uses ~/.pops/policy.yaml.
+
+The workspace is selected with --workspace, otherwise with the POPS_WORKSPACE environment variable,
+otherwise with 'pops workspace use'.`,
+		Example: `
+- **pops workspace create acme** - Create a workspace.
+- **pops workspace use acme** - Use a workspace for the next pops commands.
+- **pops workspace list** - List the workspaces.
+- **pops --workspace acme connection list** - List the connections of a workspace without switching to it.
+- **pops connection list --all-workspaces** - List the connections of all workspaces.`,
+	}
+
+	cmd.AddCommand(newCreateCmd())
+	cmd.AddCommand(newUseCmd())
+	cmd.AddCommand(newListCmd())
+	cmd.AddCommand(newCurrentCmd())
+	cmd.AddCommand(newDeleteCmd())
+
+	return cmd
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/openai/openai-go"
 	"github.com/openai/openai-go/option"
@@ -49,6 +50,17 @@ No triple backticks. No code fences. Plain text only.
 `
 )
 
+// DefaultChatModel is the chat model used to generate commands and answers.
+const DefaultChatModel = openai.ChatModelGPT4o
+
+// DefaultTemperature is the sampling temperature for generating commands and answers.
+const DefaultTemperature = 0.2
+
+// ModelName returns the provider and the chat model, e.g. "OpenAI gpt-4o".
+func ModelName(chatModel string) string {
+	return "OpenAI " + chatModel
+}
+
 // OpenAIModel is the OpenAI implementation of the AIModel interface.
 type OpenAIModel struct {
 	apiKey      string
@@ -56,6 +68,8 @@ type OpenAIModel struct {
 	chatModel   openai.ChatModel
 	commandType string
 	context     string
+	temperature float64
+	timeout     time.Duration
 }
 
 func NewOpenAIModel(commandType, context string) (*OpenAIModel, error) {
@@ -71,9 +85,10 @@ func NewOpenAIModel(commandType, context string) (*OpenAIModel, error) {
 	return &OpenAIModel{
 		apiKey:      apiKey,
 		client:      client,
-		chatModel:   openai.ChatModelGPT4o, // example model
+		chatModel:   DefaultChatModel,
 		commandType: commandType,
 		context:     context,
+		temperature: DefaultTemperature,
 	}, nil
 }
 
@@ -93,6 +108,24 @@ func (o *OpenAIModel) GetChatModel() openai.ChatModel {
 	return o.chatModel
 }
 
+// SetTemperature sets the sampling temperature for generating commands and answers.
+func (o *OpenAIModel) SetTemperature(temperature float64) {
+	o.temperature = temperature
+}
+
+// SetTimeout limits how long a request to the API may take. 0 means no limit.
+func (o *OpenAIModel) SetTimeout(timeout time.Duration) {
+	o.timeout = timeout
+}
+
+// requestContext returns the context of a request to the API, with the timeout if there is one.
+func (o *OpenAIModel) requestContext() (context.Context, context.CancelFunc) {
+	if o.timeout > 0 {
+		return context.WithTimeout(context.Background(), o.timeout)
+	}
+	return context.WithCancel(context.Background())
+}
+
 func (o *OpenAIModel) SetCommandType(commandType string) {
 	o.commandType = commandType
 }
@@ -140,7 +173,10 @@ func (o *OpenAIModel) GetCommand(prompt string) (*AIResponse, error) {
 	}
 
 	// 2) Create the chat completion request with tool definitions. Depending on your client, you might use a different way to specify tool calling.
-	chatCompletion, err := o.client.Chat.Completions.New(context.TODO(), openai.ChatCompletionNewParams{
+	ctx, cancel := o.requestContext()
+	defer cancel()
+
+	chatCompletion, err := o.client.Chat.Completions.New(ctx, openai.ChatCompletionNewParams{
 		Messages: openai.F([]openai.ChatCompletionMessageParamUnion{
 			openai.SystemMessage(fmt.Sprintf(defaultSystemMessage, o.GetCommandType())),
 			openai.SystemMessage(o.GetContext()),
@@ -149,7 +185,7 @@ func (o *OpenAIModel) GetCommand(prompt string) (*AIResponse, error) {
 		Model:       openai.F(o.GetChatModel()),
 		ToolChoice:  openai.F[openai.ChatCompletionToolChoiceOptionUnionParam](openai.ChatCompletionToolChoiceOptionAutoRequired),
 		Tools:       openai.F(tools),
-		Temperature: openai.F(0.2),
+		Temperature: openai.F(o.temperature),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("error from OpenAI API: %v", err)
@@ -179,13 +215,16 @@ func (o *OpenAIModel) GetCommand(prompt string) (*AIResponse, error) {
 }
 
 func (o *OpenAIModel) GetAnswer(prompt string) (*AIResponse, error) {
-	chatCompletion, err := o.client.Chat.Completions.New(context.TODO(), openai.ChatCompletionNewParams{
+	ctx, cancel := o.requestContext()
+	defer cancel()
+
+	chatCompletion, err := o.client.Chat.Completions.New(ctx, openai.ChatCompletionNewParams{
 		Messages: openai.F([]openai.ChatCompletionMessageParamUnion{
 			openai.SystemMessage(o.GetContext()),
 			openai.UserMessage(prompt),
 		}),
 		Model:       openai.F(o.GetChatModel()),
-		Temperature: openai.F(0.2),
+		Temperature: openai.F(o.temperature),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("error from OpenAI API: %v", err)
@@ -206,6 +245,80 @@ func (o *OpenAIModel) GetAnswer(prompt string) (*AIResponse, error) {
 	}, nil
 }
 
+// riskSystemMessage asks the model to rate how risky it is to run a command.
+const riskSystemMessage = `
+You review %s before they are run.
+Rate each command as "read" if it only reads data, "write" if it changes data or resources in a way that can be undone,
+or "destructive" if it deletes data or resources, or could cause an outage.
+Give a one sentence reason.
+`
+
+// ClassifyCommand asks the model how risky it is to run the command.
+func (o *OpenAIModel) ClassifyCommand(command string) (*RiskResponse, error) {
+	tools := []openai.ChatCompletionToolParam{
+		{
+			Function: openai.F(shared.FunctionDefinitionParam{
+				Name:        openai.F("classifyRisk"),
+				Description: openai.F("Rate how risky it is to run a command."),
+				Parameters: openai.F(shared.FunctionParameters{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"risk": map[string]interface{}{
+							"type": "string",
+							"enum": []string{"read", "write", "destructive"},
+						},
+						"reason": map[string]interface{}{
+							"type":        "string",
+							"description": "A one sentence reason for the rating.",
+						},
+					},
+					"required":             []string{"risk", "reason"},
+					"additionalProperties": false,
+				}),
+				Strict: openai.F(true),
+			}),
+			Type: openai.F(openai.ChatCompletionToolTypeFunction),
+		},
+	}
+
+	ctx, cancel := o.requestContext()
+	defer cancel()
+
+	chatCompletion, err := o.client.Chat.Completions.New(ctx, openai.ChatCompletionNewParams{
+		Messages: openai.F([]openai.ChatCompletionMessageParamUnion{
+			openai.SystemMessage(fmt.Sprintf(riskSystemMessage, o.GetCommandType())),
+			openai.SystemMessage(o.GetContext()),
+			openai.UserMessage(command),
+		}),
+		Model:       openai.F(o.GetChatModel()),
+		ToolChoice:  openai.F[openai.ChatCompletionToolChoiceOptionUnionParam](openai.ChatCompletionToolChoiceOptionAutoRequired),
+		Tools:       openai.F(tools),
+		Temperature: openai.F(0.0),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error from OpenAI API: %v", err)
+	}
+
+	if len(chatCompletion.Choices) == 0 {
+		return nil, fmt.Errorf("no choices returned from OpenAI")
+	}
+
+	for _, toolCall := range chatCompletion.Choices[0].Message.ToolCalls {
+		if toolCall.Function.Name == "classifyRisk" {
+			var args struct {
+				Risk   string `json:"risk"`
+				Reason string `json:"reason"`
+			}
+			if err := json.Unmarshal([]byte(toolCall.Function.Arguments), &args); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal tool call args: %v", err)
+			}
+			return &RiskResponse{Risk: args.Risk, Reason: args.Reason}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("needed tool call could not be found in the tool calls")
+}
+
 // parseToolCall unmarshals the model's tool call arguments into AIResponse.
 func parseToolCalls(toolCalls []openai.ChatCompletionMessageToolCall) (*AIResponse, error) {
 	if len(toolCalls) == 0 {
@@ -11,6 +11,9 @@ type AIModel interface {
 	// GetCommand generates a command based on user input.
 	GetCommand(prompt string) (*AIResponse, error)
 
+	// ClassifyCommand rates how risky it is to run a command.
+	ClassifyCommand(command string) (*RiskResponse, error)
+
 	// SetContext sets the context for the AI model.
 	SetContext(context string)
 
@@ -38,3 +41,12 @@ type AIResponse struct {
 	// NextSteps are the suggested next steps that are provided by the AI.
 	NextSteps []string
 }
+
+// RiskResponse holds the risk of a command as rated by the AI.
+type RiskResponse struct {
+	// Risk is "read", "write" or "destructive".
+	Risk string
+
+	// Reason explains the rating.
+	Reason string
+}
@@ -0,0 +1,387 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/prompt-ops/pops/pkg/conn"
+	"github.com/prompt-ops/pops/pkg/secret"
+	"github.com/prompt-ops/pops/pkg/workspace"
+)
+
+// PathEnvVar is the environment variable to use a different audit log than the one of the workspace, like ~/.pops/audit.log.
+const PathEnvVar = "POPS_AUDIT_LOG"
+
+// ErrTampered is returned by Verify if the hash chain of the audit log is broken.
+var ErrTampered = errors.New("audit log has been tampered with")
+
+const (
+	// ActionRun is for commands that were confirmed and executed.
+	ActionRun = "run"
+
+	// ActionPreview is for commands that were executed as a dry run to preview their changes.
+	ActionPreview = "preview"
+
+	// StatusSucceeded is for commands that ran without an error.
+	StatusSucceeded = "succeeded"
+
+	// StatusFailed is for commands that failed or couldn't be started.
+	StatusFailed = "failed"
+
+	// StatusDenied is for confirmed commands that were blocked before they ran, like by the policy.
+	StatusDenied = "denied"
+)
+
+// Entry is an executed command in the audit log.
+type Entry struct {
+	Time time.Time `json:"time"`
+
+	// User is the OS user that ran the command.
+	User string `json:"user"`
+
+	Connection     string `json:"connection"`
+	ConnectionType string `json:"connectionType"`
+
+	// Prompt is what the user asked for.
+	Prompt string `json:"prompt,omitempty"`
+
+	// Model is the AI model that generated the command.
+	// Example: "OpenAI gpt-4o".
+	Model string `json:"model,omitempty"`
+
+	Command string `json:"command"`
+
+	// Edited was always false in entries of earlier versions, as commands can't be edited before running them.
+	// It is kept so their hashes can still be verified, and is not set in new entries.
+	Edited *bool `json:"edited,omitempty"`
+
+	// Action is ActionRun or ActionPreview.
+	Action string `json:"action"`
+
+	// Confirmation is what the user typed to confirm the command.
+	Confirmation string `json:"confirmation,omitempty"`
+
+	// Risk is the risk of the command: "read", "write" or "destructive".
+	Risk string `json:"risk,omitempty"`
+
+	// Status is StatusSucceeded, StatusFailed or StatusDenied.
+	Status string `json:"status"`
+
+	// ExitCode is the exit code of the command, if it exited with one.
+	ExitCode *int `json:"exitCode,omitempty"`
+
+	Error string `json:"error,omitempty"`
+
+	// DurationMs is how long the command ran, in milliseconds.
+	DurationMs int64 `json:"durationMs"`
+
+	// Rows is how many rows or resources the command returned before it was shown.
+	Rows int `json:"rows"`
+
+	// MoreRows is true if the command returned more rows than were read.
+	MoreRows bool `json:"moreRows,omitempty"`
+
+	// PrevHash is the hash of the previous entry, or empty for the first entry.
+	PrevHash string `json:"prevHash"`
+
+	// Hash is the SHA-256 of the entry without the hash, so it covers PrevHash as well.
+	Hash string `json:"hash"`
+}
+
+// NewEntry returns an entry for a command on the connection, for the current OS user.
+func NewEntry(connection conn.Connection, action, command string) Entry {
+	return Entry{
+		Time:           time.Now(),
+		User:           currentUser(),
+		Connection:     connection.Name,
+		ConnectionType: connection.Type.GetMainType() + "/" + connection.Type.GetSubtype(),
+		Action:         action,
+		Command:        command,
+	}
+}
+
+// SetResult sets the outcome of executing the command.
+func (e *Entry) SetResult(result *conn.ResultSet, err error, duration time.Duration) {
+	e.DurationMs = duration.Milliseconds()
+	if err != nil {
+		e.Status = StatusFailed
+		e.Error = secret.Redact(err.Error())
+
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			code := exitErr.ExitCode()
+			e.ExitCode = &code
+		}
+		return
+	}
+
+	e.Status = StatusSucceeded
+	if result != nil {
+		e.Rows = len(result.Rows)
+		e.MoreRows = result.HasMore()
+	}
+}
+
+// SetDenied records that the command was blocked before it ran, and why.
+func (e *Entry) SetDenied(err error) {
+	e.Status = StatusDenied
+	e.Error = secret.Redact(err.Error())
+}
+
+// currentUser returns the name of the OS user, or the user from the environment if it can't be looked up.
+func currentUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return os.Getenv("USERNAME")
+}
+
+// Path returns the path of the audit log.
+func Path() string {
+	if value := strings.TrimSpace(os.Getenv(PathEnvVar)); value != "" {
+		return value
+	}
+
+	return workspace.Path("audit.log")
+}
+
+// Record appends the entry to the audit log.
+func Record(entry Entry) error {
+	return AppendFile(Path(), entry)
+}
+
+// AppendFile appends the entry to an audit log, chaining it to the last entry in the log.
+// It takes an advisory lock on a lock file next to the log, so concurrent pops processes don't chain two entries to the same one.
+func AppendFile(filename string, entry Entry) error {
+	if err := os.MkdirAll(filepath.Dir(filename), 0o700); err != nil {
+		return err
+	}
+
+	unlock, err := lock(filename)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	file, err := os.OpenFile(filename, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	prevHash, err := lastHash(file)
+	if err != nil {
+		return fmt.Errorf("failed to read the last audit log entry: %w", err)
+	}
+
+	entry.Time = entry.Time.UTC()
+	entry.PrevHash = prevHash
+	entry.Hash, err = entry.hash()
+	if err != nil {
+		return err
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(append(line, '\n'))
+	return err
+}
+
+// lock takes the lock of the audit log and returns the function to release it.
+func lock(filename string) (func(), error) {
+	file, err := os.OpenFile(filename+".lock", os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(file); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to lock %s: %w", filename, err)
+	}
+
+	return func() {
+		_ = unlockFile(file)
+		file.Close()
+	}, nil
+}
+
+// hash returns the hash of the entry without its hash.
+func (e Entry) hash() (string, error) {
+	e.Hash = ""
+	data, err := json.Marshal(e)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// lastHash returns the hash of the last entry in the file, or an empty string if it is empty.
+// The file is read backwards from its end, so appending doesn't get slower as the log grows.
+func lastHash(file *os.File) (string, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	end := info.Size()
+	var tail []byte
+	for chunkSize := int64(4096); ; chunkSize *= 2 {
+		start := max(end-chunkSize, 0)
+		tail = make([]byte, end-start)
+		if _, err := file.ReadAt(tail, start); err != nil && err != io.EOF {
+			return "", err
+		}
+
+		trimmed := bytes.TrimRight(tail, "\n")
+		if i := bytes.LastIndexByte(trimmed, '\n'); i >= 0 || start == 0 {
+			line := trimmed[i+1:]
+			if len(bytes.TrimSpace(line)) == 0 {
+				return "", nil
+			}
+
+			var entry Entry
+			if err := json.Unmarshal(line, &entry); err != nil {
+				return "", err
+			}
+			return entry.Hash, nil
+		}
+	}
+}
+
+// Filter selects entries of the audit log. Empty fields select all entries.
+type Filter struct {
+	Connection string
+	User       string
+	Status     string
+
+	// Contains is text the prompt or the command must contain, ignoring case.
+	Contains string
+
+	Since time.Time
+	Until time.Time
+
+	// Limit is the maximum number of entries, keeping the most recent ones. Zero is no limit.
+	Limit int
+}
+
+// Matches reports whether the entry is selected by the filter.
+func (f Filter) Matches(entry Entry) bool {
+	if f.Connection != "" && !strings.EqualFold(f.Connection, entry.Connection) {
+		return false
+	}
+	if f.User != "" && f.User != entry.User {
+		return false
+	}
+	if f.Status != "" && !strings.EqualFold(f.Status, entry.Status) {
+		return false
+	}
+	if f.Contains != "" {
+		contains := strings.ToLower(f.Contains)
+		if !strings.Contains(strings.ToLower(entry.Command), contains) &&
+			!strings.Contains(strings.ToLower(entry.Prompt), contains) {
+			return false
+		}
+	}
+	if !f.Since.IsZero() && entry.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && entry.Time.After(f.Until) {
+		return false
+	}
+	return true
+}
+
+// Query returns the entries of the audit log selected by the filter, oldest first.
+// A missing audit log has no entries.
+func Query(filename string, filter Filter) ([]Entry, error) {
+	var entries []Entry
+	err := readEntries(filename, func(_ int, entry Entry) error {
+		if filter.Matches(entry) {
+			entries = append(entries, entry)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if filter.Limit > 0 && len(entries) > filter.Limit {
+		entries = entries[len(entries)-filter.Limit:]
+	}
+	return entries, nil
+}
+
+// Verify checks the hash chain of the audit log and returns the number of entries.
+// Edited, inserted, removed or reordered entries are reported with an error wrapping ErrTampered.
+// Removing entries from the end of the log leaves a valid chain and can't be detected from the log alone.
+func Verify(filename string) (int, error) {
+	count := 0
+	prevHash := ""
+	err := readEntries(filename, func(lineNumber int, entry Entry) error {
+		if entry.PrevHash != prevHash {
+			return fmt.Errorf("%w: line %d doesn't follow the previous entry", ErrTampered, lineNumber)
+		}
+
+		hash, err := entry.hash()
+		if err != nil {
+			return err
+		}
+		if entry.Hash != hash {
+			return fmt.Errorf("%w: line %d was changed", ErrTampered, lineNumber)
+		}
+
+		prevHash = entry.Hash
+		count++
+		return nil
+	})
+	return count, err
+}
+
+// readEntries calls fn for every entry of the audit log. Lines that are not entries are reported as tampering.
+func readEntries(filename string, fn func(lineNumber int, entry Entry) error) error {
+	file, err := os.Open(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := scanner.Bytes()
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		var entry Entry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return fmt.Errorf("%w: line %d is not an audit entry: %v", ErrTampered, lineNumber, err)
+		}
+		if err := fn(lineNumber, entry); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,224 @@
+package audit
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prompt-ops/pops/pkg/conn"
+)
+
+func writeTestLog(t *testing.T) string {
+	t.Helper()
+
+	filename := filepath.Join(t.TempDir(), "audit.log")
+	db := conn.NewDatabaseConnection("prod-db", conn.PostgreSQLDatabaseConnection, "postgres://localhost/db")
+	cluster := conn.NewKubernetesConnection("cluster", "dev")
+
+	commands := []struct {
+		connection conn.Connection
+		command    string
+		err        error
+	}{
+		{db, "SELECT * FROM users", nil},
+		{cluster, "kubectl get pods", nil},
+		{db, "DELETE FROM sessions WHERE expired", errors.New("permission denied")},
+	}
+	for i, c := range commands {
+		entry := NewEntry(c.connection, ActionRun, c.command)
+		entry.Time = time.Date(2024, 1, 1, i, 0, 0, 0, time.UTC)
+		entry.SetResult(nil, c.err, time.Second)
+		if err := AppendFile(filename, entry); err != nil {
+			t.Fatalf("AppendFile() error = %v", err)
+		}
+	}
+	return filename
+}
+
+func TestAppendFile_Concurrent(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "audit.log")
+	cluster := conn.NewKubernetesConnection("cluster", "dev")
+
+	const writers = 20
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := AppendFile(filename, NewEntry(cluster, ActionRun, fmt.Sprintf("kubectl get pods -n ns-%d", i))); err != nil {
+				t.Errorf("AppendFile() error = %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	count, err := Verify(filename)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if count != writers {
+		t.Errorf("Verify() = %d entries, want %d", count, writers)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	tests := []struct {
+		name    string
+		tamper  func(lines []string) []string
+		wantErr bool
+	}{
+		{
+			name:   "Test Verify accepts an untouched log",
+			tamper: func(lines []string) []string { return lines },
+		},
+		{
+			name: "Test Verify detects an edited entry",
+			tamper: func(lines []string) []string {
+				lines[1] = strings.Replace(lines[1], "kubectl get pods", "kubectl get nodes", 1)
+				return lines
+			},
+			wantErr: true,
+		},
+		{
+			name: "Test Verify detects a removed entry",
+			tamper: func(lines []string) []string {
+				return append(lines[:1], lines[2:]...)
+			},
+			wantErr: true,
+		},
+		{
+			name: "Test Verify detects reordered entries",
+			tamper: func(lines []string) []string {
+				lines[0], lines[1] = lines[1], lines[0]
+				return lines
+			},
+			wantErr: true,
+		},
+		{
+			name: "Test Verify detects a line that is not an entry",
+			tamper: func(lines []string) []string {
+				return append(lines, "not json")
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := writeTestLog(t)
+			data, err := os.ReadFile(filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			lines := tt.tamper(strings.Split(strings.TrimSuffix(string(data), "\n"), "\n"))
+			if err := os.WriteFile(filename, []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = Verify(filename)
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("Verify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrTampered) {
+				t.Errorf("Verify() error = %v, want ErrTampered", err)
+			}
+		})
+	}
+}
+
+func TestVerify_EarlierVersions(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "audit.log")
+
+	// Entries of earlier versions always have "edited": false.
+	entry := NewEntry(conn.NewKubernetesConnection("cluster", "dev"), ActionRun, "kubectl get pods")
+	edited := false
+	entry.Edited = &edited
+	if err := AppendFile(filename, entry); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"edited":false`) {
+		t.Fatalf("entry = %s, want the edited field of earlier versions", data)
+	}
+
+	if err := AppendFile(filename, NewEntry(conn.NewKubernetesConnection("cluster", "dev"), ActionRun, "kubectl get nodes")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Verify(filename); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+}
+
+func TestEntry_SetDenied(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "audit.log")
+
+	entry := NewEntry(conn.NewKubernetesConnection("cluster", "dev"), ActionRun, "kubectl delete ns prod")
+	entry.SetDenied(errors.New("denied by policy rule 'no-prod-deletes'"))
+	if err := AppendFile(filename, entry); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := Query(filename, Filter{Status: StatusDenied})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(entries) != 1 || entries[0].Error != "denied by policy rule 'no-prod-deletes'" {
+		t.Errorf("Query() = %+v, want the denied command", entries)
+	}
+}
+
+func TestQuery(t *testing.T) {
+	filename := writeTestLog(t)
+
+	tests := []struct {
+		name         string
+		filter       Filter
+		wantCommands []string
+	}{
+		{
+			name:         "Test Query returns all entries without a filter",
+			filter:       Filter{},
+			wantCommands: []string{"SELECT * FROM users", "kubectl get pods", "DELETE FROM sessions WHERE expired"},
+		},
+		{
+			name:         "Test Query filters by connection and status",
+			filter:       Filter{Connection: "prod-db", Status: StatusFailed},
+			wantCommands: []string{"DELETE FROM sessions WHERE expired"},
+		},
+		{
+			name:         "Test Query filters by text and time",
+			filter:       Filter{Contains: "from", Until: time.Date(2024, 1, 1, 1, 30, 0, 0, time.UTC)},
+			wantCommands: []string{"SELECT * FROM users"},
+		},
+		{
+			name:         "Test Query keeps the most recent entries",
+			filter:       Filter{Limit: 2},
+			wantCommands: []string{"kubectl get pods", "DELETE FROM sessions WHERE expired"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := Query(filename, tt.filter)
+			if err != nil {
+				t.Fatalf("Query() error = %v", err)
+			}
+
+			var commands []string
+			for _, entry := range entries {
+				commands = append(commands, entry.Command)
+			}
+			if strings.Join(commands, "\n") != strings.Join(tt.wantCommands, "\n") {
+				t.Errorf("Query() = %v, want %v", commands, tt.wantCommands)
+			}
+		})
+	}
+}
@@ -0,0 +1,6 @@
+package audit
+
+// This package keeps the audit log (~/.pops/audit.log): one JSON line per executed command with who ran it,
+// on which connection, what was asked and generated, how it was confirmed and how it ended.
+// Every entry carries the hash of the previous one, so editing or removing entries breaks the chain and is
+// detected by Verify.
@@ -0,0 +1,253 @@
+package bundle
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/prompt-ops/pops/pkg/conn"
+	"gopkg.in/yaml.v3"
+)
+
+// Kind identifies connection bundles.
+const Kind = "ConnectionBundle"
+
+// Version is the version of the bundle format written by this version of pops.
+const Version = 1
+
+const (
+	// FormatJSON writes bundles as JSON.
+	FormatJSON = "json"
+
+	// FormatYAML writes bundles as YAML.
+	FormatYAML = "yaml"
+)
+
+// Bundle is a set of connections to share.
+type Bundle struct {
+	// Kind is always "ConnectionBundle".
+	Kind string `json:"kind" yaml:"kind"`
+
+	// Version is the version of the bundle format.
+	Version int `json:"version" yaml:"version"`
+
+	// ExportedAt is when the bundle was written.
+	ExportedAt time.Time `json:"exportedAt,omitempty" yaml:"exportedAt,omitempty"`
+
+	// Recipient is the public key the secrets of the connections are encrypted for, if they are included.
+	Recipient string `json:"recipient,omitempty" yaml:"recipient,omitempty"`
+
+	Connections []Entry `json:"connections" yaml:"connections"`
+}
+
+// Entry is a connection in a bundle.
+type Entry struct {
+	// Connection is the connection as it is in connections.json, without secrets.
+	Connection conn.Connection `json:"connection"`
+
+	// EncryptedDetails are the details of the connection with its secrets, encrypted for the recipient.
+	EncryptedDetails string `json:"encryptedDetails,omitempty"`
+
+	// SecretsRemoved is true if the connection had secrets that are not in the bundle,
+	// so they have to be added after importing it.
+	SecretsRemoved bool `json:"secretsRemoved,omitempty"`
+}
+
+// New returns a bundle of the connections. Stored secrets are encrypted for the recipient,
+// a public key returned by Key.PublicKey, or removed if the recipient is empty.
+func New(connections []conn.Connection, recipient string) (*Bundle, error) {
+	var recipientKey *[32]byte
+	if recipient != "" {
+		var err error
+		if recipientKey, err = ParsePublicKey(recipient); err != nil {
+			return nil, err
+		}
+	}
+
+	bundle := &Bundle{
+		Kind:        Kind,
+		Version:     Version,
+		ExportedAt:  time.Now().UTC(),
+		Recipient:   recipient,
+		Connections: make([]Entry, 0, len(connections)),
+	}
+	for _, connection := range connections {
+		entry := Entry{Connection: connection}
+
+		if details, ok := connection.Details.(conn.SecretDetails); ok {
+			withoutSecrets := details.WithoutSecrets()
+			entry.Connection.Details = withoutSecrets
+
+			// Only details that lost something when removing the secrets need them encrypted.
+			if hasSecrets, err := differ(details, withoutSecrets); err != nil {
+				return nil, err
+			} else if hasSecrets {
+				if recipientKey == nil {
+					entry.SecretsRemoved = true
+				} else {
+					loaded, err := details.LoadSecrets()
+					if err != nil {
+						return nil, fmt.Errorf("failed to read the secrets of connection '%s': %w", connection.Name, err)
+					}
+					data, err := json.Marshal(loaded)
+					if err != nil {
+						return nil, err
+					}
+					if entry.EncryptedDetails, err = seal(data, recipientKey); err != nil {
+						return nil, err
+					}
+				}
+			}
+		}
+
+		bundle.Connections = append(bundle.Connections, entry)
+	}
+	return bundle, nil
+}
+
+// Decode reads a bundle in JSON or YAML.
+func Decode(data []byte) (*Bundle, error) {
+	var bundle Bundle
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		if err := json.Unmarshal(trimmed, &bundle); err != nil {
+			return nil, fmt.Errorf("invalid bundle: %w", err)
+		}
+	} else if err := yaml.Unmarshal(data, &bundle); err != nil {
+		return nil, fmt.Errorf("invalid bundle: %w", err)
+	}
+
+	if bundle.Kind != Kind {
+		return nil, fmt.Errorf("invalid bundle: kind is '%s', not '%s'", bundle.Kind, Kind)
+	}
+	if bundle.Version < 1 || bundle.Version > Version {
+		return nil, fmt.Errorf("bundle version %d is not supported; this version of pops reads version %d", bundle.Version, Version)
+	}
+	return &bundle, nil
+}
+
+// Encode writes the bundle in the format, FormatJSON or FormatYAML.
+func (b *Bundle) Encode(w io.Writer, format string) error {
+	switch format {
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(b)
+	case FormatYAML:
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(b); err != nil {
+			return err
+		}
+		return encoder.Close()
+	default:
+		return fmt.Errorf("unsupported bundle format '%s' (json or yaml)", format)
+	}
+}
+
+// Decrypt returns the connections of the bundle, with their secrets decrypted with the key.
+// The key is only needed if the bundle has encrypted secrets. Secrets only come from the encrypted details:
+// references to the secret store, which would read the secrets of other connections, are removed.
+func (b *Bundle) Decrypt(key *Key) ([]conn.Connection, error) {
+	if b.Recipient != "" && key != nil && key.PublicKey() != b.Recipient {
+		return nil, fmt.Errorf("the secrets in the bundle are encrypted for the public key %s, not for yours (%s)", b.Recipient, key.PublicKey())
+	}
+
+	connections := make([]conn.Connection, 0, len(b.Connections))
+	for _, entry := range b.Connections {
+		connection := entry.Connection
+		if entry.EncryptedDetails != "" {
+			if key == nil {
+				return nil, fmt.Errorf("connection '%s' has encrypted secrets, but there is no key to decrypt them", connection.Name)
+			}
+			details, err := key.open(entry.EncryptedDetails)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decrypt the secrets of connection '%s': %w", connection.Name, err)
+			}
+			if connection, err = withDetails(connection, details); err != nil {
+				return nil, fmt.Errorf("invalid secrets of connection '%s': %w", connection.Name, err)
+			}
+		}
+		if details, ok := connection.Details.(conn.SecretDetails); ok {
+			connection.Details = details.WithoutSecretRefs()
+		}
+		connections = append(connections, connection)
+	}
+	return connections, nil
+}
+
+// HasEncryptedSecrets reports whether any connection of the bundle has encrypted secrets.
+func (b *Bundle) HasEncryptedSecrets() bool {
+	for _, entry := range b.Connections {
+		if entry.EncryptedDetails != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// MarshalYAML writes the entry as YAML with the same fields as JSON.
+func (e Entry) MarshalYAML() (interface{}, error) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+
+	var value map[string]interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// UnmarshalYAML reads the entry from YAML with the same fields as JSON.
+func (e *Entry) UnmarshalYAML(node *yaml.Node) error {
+	var value interface{}
+	if err := node.Decode(&value); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, e)
+}
+
+// withDetails returns the connection with details decoded from JSON for the type of the connection.
+func withDetails(connection conn.Connection, details []byte) (conn.Connection, error) {
+	data, err := json.Marshal(connection)
+	if err != nil {
+		return connection, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return connection, err
+	}
+	fields["details"] = details
+	if data, err = json.Marshal(fields); err != nil {
+		return connection, err
+	}
+
+	var decoded conn.Connection
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return connection, err
+	}
+	return decoded, nil
+}
+
+// differ reports whether the details encode differently.
+func differ(a, b conn.ConnectionDetails) (bool, error) {
+	dataA, err := json.Marshal(a)
+	if err != nil {
+		return false, err
+	}
+	dataB, err := json.Marshal(b)
+	if err != nil {
+		return false, err
+	}
+	return !bytes.Equal(dataA, dataB), nil
+}
@@ -0,0 +1,190 @@
+package bundle
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/prompt-ops/pops/pkg/conn"
+)
+
+func TestBundle(t *testing.T) {
+	t.Setenv(KeyPathEnvVar, filepath.Join(t.TempDir(), "bundle.key"))
+	key, err := LoadOrCreateKey()
+	if err != nil {
+		t.Fatalf("LoadOrCreateKey() error = %v", err)
+	}
+
+	postgres := conn.AvailableDatabaseConnectionType{Subtype: "PostgreSQL", Driver: "postgres"}
+	connections := []conn.Connection{
+		conn.NewDatabaseConnection("prod-db", postgres, "postgres://user:secret@db/app"),
+		conn.NewDatabaseConnection("shared-db", postgres, "postgres://user:${env:PGPASSWORD}@db/app"),
+		conn.NewKubernetesConnection("cluster", "dev"),
+	}
+
+	tests := []struct {
+		name                 string
+		recipient            string
+		format               string
+		wantConnectionString string
+	}{
+		{
+			name:                 "Test bundle without secrets in YAML",
+			format:               FormatYAML,
+			wantConnectionString: "",
+		},
+		{
+			name:                 "Test bundle with secrets in JSON",
+			recipient:            key.PublicKey(),
+			format:               FormatJSON,
+			wantConnectionString: "postgres://user:secret@db/app",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := New(connections, tt.recipient)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+
+			var buffer bytes.Buffer
+			if err := b.Encode(&buffer, tt.format); err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+			if bytes.Contains(buffer.Bytes(), []byte("secret@")) {
+				t.Fatalf("Encode() wrote the secret:\n%s", buffer.String())
+			}
+
+			decoded, err := Decode(buffer.Bytes())
+			if err != nil {
+				t.Fatalf("Decode() error = %v\n%s", err, buffer.String())
+			}
+			if decoded.Connections[0].SecretsRemoved != (tt.recipient == "") {
+				t.Errorf("SecretsRemoved = %v, want %v", decoded.Connections[0].SecretsRemoved, tt.recipient == "")
+			}
+
+			imported, err := decoded.Decrypt(key)
+			if err != nil {
+				t.Fatalf("Decrypt() error = %v", err)
+			}
+			if len(imported) != len(connections) {
+				t.Fatalf("Decrypt() = %d connections, want %d", len(imported), len(connections))
+			}
+
+			details, err := conn.GetDatabaseConnectionDetails(imported[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if details.ConnectionString != tt.wantConnectionString {
+				t.Errorf("connection string = %q, want %q", details.ConnectionString, tt.wantConnectionString)
+			}
+
+			shared, err := conn.GetDatabaseConnectionDetails(imported[1])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if shared.ConnectionString != "postgres://user:${env:PGPASSWORD}@db/app" {
+				t.Errorf("connection string with references = %q, want it kept", shared.ConnectionString)
+			}
+		})
+	}
+}
+
+func TestBundle_Decrypt_OtherKey(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(KeyPathEnvVar, filepath.Join(dir, "recipient.key"))
+	recipient, err := LoadOrCreateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(KeyPathEnvVar, filepath.Join(dir, "other.key"))
+	other, err := LoadOrCreateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	postgres := conn.AvailableDatabaseConnectionType{Subtype: "PostgreSQL", Driver: "postgres"}
+	b, err := New([]conn.Connection{conn.NewDatabaseConnection("db", postgres, "postgres://user:secret@db/app")}, recipient.PublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := b.Decrypt(other); err == nil {
+		t.Error("Decrypt() with another key error = nil")
+	}
+	if _, err := b.Decrypt(nil); err == nil {
+		t.Error("Decrypt() without a key error = nil")
+	}
+}
+
+func TestBundle_Decrypt_SecretRefs(t *testing.T) {
+	t.Setenv(KeyPathEnvVar, filepath.Join(t.TempDir(), "bundle.key"))
+	key, err := LoadOrCreateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	postgres := conn.AvailableDatabaseConnectionType{Subtype: "PostgreSQL", Driver: "postgres"}
+	b, err := New([]conn.Connection{conn.NewDatabaseConnection("encrypted", postgres, "postgres://user:secret@db/app")}, key.PublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A crafted bundle can refer to the secrets of other connections, in its plain or its encrypted details.
+	details, err := seal([]byte(`{"connectionString": "postgres://db/app", "connectionStringRef": "keyring:connection/prod-db/connectionString", "driver": "postgres"}`), key.public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Connections[0].EncryptedDetails = details
+	b.Connections = append(b.Connections, Entry{Connection: conn.Connection{
+		Name:    "plain",
+		Type:    conn.DatabaseConnectionType{MainType: "Database", Subtype: "PostgreSQL"},
+		Details: conn.DatabaseConnectionDetails{ConnectionStringRef: "keyring:connection/prod-db/connectionString", Driver: "postgres"},
+	}})
+
+	connections, err := b.Decrypt(key)
+	if err != nil {
+		t.Fatalf("Decrypt() error = %v", err)
+	}
+	for _, connection := range connections {
+		if refs := connection.Details.(conn.SecretDetails).SecretRefs(); len(refs) > 0 {
+			t.Errorf("Decrypt() connection '%s' refers to %v", connection.Name, refs)
+		}
+	}
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{
+			name: "Test Decode of a YAML bundle",
+			data: "kind: ConnectionBundle\nversion: 1\nconnections:\n  - connection:\n      name: cluster\n      type: {mainType: Kubernetes}\n      details: {selectedContext: dev}\n",
+		},
+		{
+			name:    "Test Decode of another kind",
+			data:    `{"kind": "Other", "version": 1, "connections": []}`,
+			wantErr: true,
+		},
+		{
+			name:    "Test Decode of a newer version",
+			data:    `{"kind": "ConnectionBundle", "version": 2, "connections": []}`,
+			wantErr: true,
+		},
+		{
+			name:    "Test Decode of an unknown connection type",
+			data:    `{"kind": "ConnectionBundle", "version": 1, "connections": [{"connection": {"name": "x", "type": {"mainType": "Mainframe"}}}]}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Decode([]byte(tt.data)); (err != nil) != tt.wantErr {
+				t.Errorf("Decode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,6 @@
+package bundle
+
+// This package reads and writes connection bundles: portable JSON or YAML files with connections that can be
+// shared, for example in a repository, and imported with 'pops connection import'. Secrets are removed from
+// bundles, or encrypted for the public key of the person the bundle is for. The format is described in
+// docs/BUNDLE.md.
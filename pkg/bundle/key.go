@@ -0,0 +1,142 @@
+package bundle
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/prompt-ops/pops/pkg/workspace"
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/nacl/box"
+)
+
+// KeyPathEnvVar is the environment variable to use a different key file than ~/.pops/bundle.key.
+const KeyPathEnvVar = "POPS_BUNDLE_KEY"
+
+// Key is the key pair secrets in bundles are encrypted for.
+type Key struct {
+	public  *[32]byte
+	private *[32]byte
+}
+
+// KeyPath returns the path of the key file.
+func KeyPath() string {
+	if value := strings.TrimSpace(os.Getenv(KeyPathEnvVar)); value != "" {
+		return value
+	}
+	return filepath.Join(workspace.Root(), "bundle.key")
+}
+
+// LoadKey reads the key pair from the key file.
+func LoadKey() (*Key, error) {
+	data, err := os.ReadFile(KeyPath())
+	if err != nil {
+		return nil, err
+	}
+
+	private, err := decodeKey(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid key file %s: %w", KeyPath(), err)
+	}
+	return newKey(private)
+}
+
+// LoadOrCreateKey reads the key pair from the key file, creating it on first use.
+func LoadOrCreateKey() (*Key, error) {
+	key, err := LoadKey()
+	if !errors.Is(err, os.ErrNotExist) {
+		return key, err
+	}
+
+	_, private, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	path := KeyPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if errors.Is(err, os.ErrExist) {
+		// Another process created the key first.
+		return LoadKey()
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if _, err := file.WriteString(base64.StdEncoding.EncodeToString(private[:]) + "\n"); err != nil {
+		return nil, err
+	}
+	return newKey(private)
+}
+
+// PublicKey returns the public key to give to the people who export bundles for you.
+func (k *Key) PublicKey() string {
+	return base64.StdEncoding.EncodeToString(k.public[:])
+}
+
+// ParsePublicKey parses a public key returned by PublicKey.
+func ParsePublicKey(value string) (*[32]byte, error) {
+	key, err := decodeKey(strings.TrimSpace(value))
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %w", err)
+	}
+	return key, nil
+}
+
+// newKey returns the key pair of the private key.
+func newKey(private *[32]byte) (*Key, error) {
+	data, err := curve25519.X25519(private[:], curve25519.Basepoint)
+	if err != nil {
+		return nil, err
+	}
+
+	public := new([32]byte)
+	copy(public[:], data)
+	return &Key{public: public, private: private}, nil
+}
+
+// decodeKey decodes a base64 encoded 32 byte key.
+func decodeKey(value string) (*[32]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) != 32 {
+		return nil, fmt.Errorf("expected 32 bytes, got %d", len(data))
+	}
+
+	key := new([32]byte)
+	copy(key[:], data)
+	return key, nil
+}
+
+// seal encrypts the message for the public key.
+func seal(message []byte, recipient *[32]byte) (string, error) {
+	sealed, err := box.SealAnonymous(nil, message, recipient, rand.Reader)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// open decrypts a message encrypted by seal for the key.
+func (k *Key) open(sealed string) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil {
+		return nil, err
+	}
+
+	message, ok := box.OpenAnonymous(nil, data, k.public, k.private)
+	if !ok {
+		return nil, fmt.Errorf("decryption failed")
+	}
+	return message, nil
+}
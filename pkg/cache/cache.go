@@ -0,0 +1,206 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/prompt-ops/pops/pkg/conn"
+	"github.com/prompt-ops/pops/pkg/workspace"
+)
+
+// DefaultTTL is how long a cached context is used before it is refreshed.
+const DefaultTTL = 24 * time.Hour
+
+// TTLEnvVar is the environment variable to override DefaultTTL.
+// It accepts a Go duration like "30m" or "12h". "0" disables the cache.
+const TTLEnvVar = "POPS_CONTEXT_CACHE_TTL"
+
+// cacheDir is the directory cached contexts are stored in. If empty, the cache of the current workspace is used.
+var cacheDir string
+
+// getCacheDir returns the cache directory of the current workspace, like ~/.pops/cache.
+func getCacheDir() string {
+	if cacheDir != "" {
+		return cacheDir
+	}
+	return workspace.Path("cache")
+}
+
+// Entry is a cached context of a connection.
+type Entry struct {
+	// Connection is the name of the connection.
+	Connection string `json:"connection"`
+
+	// Fingerprint identifies the connection settings the context was gathered with.
+	// A context is not used if the connection was changed since.
+	Fingerprint string `json:"fingerprint"`
+
+	// UpdatedAt is when the context was gathered.
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	// Context is the output of ExportContext.
+	Context json.RawMessage `json:"context"`
+}
+
+// Age returns how long ago the context was gathered.
+func (e *Entry) Age() time.Duration {
+	return time.Since(e.UpdatedAt)
+}
+
+// Expired returns true if the context is older than the TTL.
+func (e *Entry) Expired(ttl time.Duration) bool {
+	return e.Age() > ttl
+}
+
+// TTL returns the configured time to live of cached contexts.
+func TTL() time.Duration {
+	value := strings.TrimSpace(os.Getenv(TTLEnvVar))
+	if value == "" {
+		return DefaultTTL
+	}
+
+	ttl, err := time.ParseDuration(value)
+	if err != nil || ttl < 0 {
+		return DefaultTTL
+	}
+	return ttl
+}
+
+// Enabled returns false if the cache was disabled by setting the TTL to 0.
+func Enabled() bool {
+	return TTL() > 0
+}
+
+// Load returns the cached context of the connection.
+// Returns an error wrapping os.ErrNotExist if there is no usable cached context.
+func Load(connection conn.Connection) (*Entry, error) {
+	data, err := os.ReadFile(entryPath(connection.Name))
+	if err != nil {
+		return nil, err
+	}
+
+	var entry Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, fmt.Errorf("error parsing cached context of '%s': %v", connection.Name, err)
+	}
+
+	fingerprint, err := fingerprint(connection)
+	if err != nil {
+		return nil, err
+	}
+	if entry.Fingerprint != fingerprint {
+		return nil, fmt.Errorf("cached context of '%s' is for different connection settings: %w", connection.Name, os.ErrNotExist)
+	}
+
+	return &entry, nil
+}
+
+// Save stores the context of the connection.
+func Save(connection conn.Connection, context []byte) error {
+	fingerprint, err := fingerprint(connection)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(Entry{
+		Connection:  connection.Name,
+		Fingerprint: fingerprint,
+		UpdatedAt:   time.Now().UTC(),
+		Context:     context,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(getCacheDir(), 0700); err != nil {
+		return fmt.Errorf("error creating cache directory: %v", err)
+	}
+
+	// Write to a temporary file first so a reader never sees a partial entry.
+	path := entryPath(connection.Name)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("error writing cached context: %v", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// Delete removes the cached context of the connection, if any.
+func Delete(name string) error {
+	if err := os.Remove(entryPath(name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// DeleteAll removes all cached contexts.
+func DeleteAll() error {
+	return os.RemoveAll(getCacheDir())
+}
+
+// LoadContext restores the cached context into the connection.
+// Returns the cache entry, or nil if there is no usable cached context.
+func LoadContext(c conn.ConnectionInterface) *Entry {
+	if !Enabled() {
+		return nil
+	}
+
+	entry, err := Load(c.GetConnection())
+	if err != nil {
+		return nil
+	}
+
+	if err := c.ImportContext(entry.Context); err != nil {
+		return nil
+	}
+	return entry
+}
+
+// RefreshContext gathers the context of the connection and stores it in the cache.
+func RefreshContext(c conn.ConnectionInterface) error {
+	if err := c.SetContext(); err != nil {
+		return err
+	}
+	return Store(c)
+}
+
+// Store stores the context already gathered by the connection in the cache.
+func Store(c conn.ConnectionInterface) error {
+	if !Enabled() {
+		return nil
+	}
+
+	context, err := c.ExportContext()
+	if err != nil {
+		return err
+	}
+	return Save(c.GetConnection(), context)
+}
+
+// entryPath returns the file the context of the connection is cached in.
+// Connection names are case insensitive.
+func entryPath(name string) string {
+	return filepath.Join(getCacheDir(), url.PathEscape(strings.ToLower(name))+".json")
+}
+
+// fingerprint returns a hash of the connection settings the context is gathered with: its type and details.
+// Metadata like labels and when the connection was last used doesn't change the context.
+func fingerprint(connection conn.Connection) (string, error) {
+	data, err := json.Marshal(struct {
+		Type    conn.ConnectionType    `json:"type"`
+		Details conn.ConnectionDetails `json:"details"`
+	}{connection.Type, connection.Details})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
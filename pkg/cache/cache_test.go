@@ -0,0 +1,73 @@
+package cache
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/prompt-ops/pops/pkg/conn"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveAndLoad(t *testing.T) {
+	cacheDir = t.TempDir()
+
+	connection := conn.NewKubernetesConnection("My-Cluster", "test-context")
+	require.NoError(t, Save(connection, []byte(`{"namespaces":[{"name":"default"}]}`)))
+
+	used := connection
+	now := time.Now()
+	used.LastUsedAt = &now
+	used.Labels = map[string]string{"team": "payments"}
+	_, err := Load(used)
+	require.NoError(t, err, "metadata doesn't change the context")
+
+	entry, err := Load(connection)
+	require.NoError(t, err)
+	require.Equal(t, "My-Cluster", entry.Connection)
+	require.JSONEq(t, `{"namespaces":[{"name":"default"}]}`, string(entry.Context))
+	require.False(t, entry.Expired(time.Hour))
+
+	_, err = Load(conn.NewKubernetesConnection("My-Cluster", "other-context"))
+	require.True(t, errors.Is(err, os.ErrNotExist))
+
+	require.NoError(t, Delete("my-cluster"))
+	_, err = Load(connection)
+	require.True(t, errors.Is(err, os.ErrNotExist))
+}
+
+func TestLoadContext(t *testing.T) {
+	cacheDir = t.TempDir()
+
+	connection := conn.NewKubernetesConnection("test", "test-context")
+	require.NoError(t, Save(connection, []byte(`{"namespaces":[{"name":"default"}],"pods":[{"name":"web","namespace":"default"}]}`)))
+
+	k8s := conn.NewKubernetesConnectionImpl(&connection)
+	entry := LoadContext(k8s)
+	require.NotNil(t, entry)
+	require.Equal(t, []conn.Pod{{Name: "web", Namespace: "default"}}, k8s.Pods)
+
+	t.Setenv(TTLEnvVar, "0")
+	require.Nil(t, LoadContext(conn.NewKubernetesConnectionImpl(&connection)))
+}
+
+func TestTTL(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  time.Duration
+	}{
+		{name: "Test TTL default", value: "", want: DefaultTTL},
+		{name: "Test TTL duration", value: "30m", want: 30 * time.Minute},
+		{name: "Test TTL disabled", value: "0", want: 0},
+		{name: "Test TTL invalid", value: "soon", want: DefaultTTL},
+		{name: "Test TTL negative", value: "-1h", want: DefaultTTL},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(TTLEnvVar, tt.value)
+			require.Equal(t, tt.want, TTL())
+		})
+	}
+}
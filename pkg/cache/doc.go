@@ -0,0 +1,4 @@
+package cache
+
+// This package stores the context gathered for connections under ~/.pops/cache,
+// so opening a connection doesn't have to wait for the whole schema or inventory to be fetched.
@@ -1,118 +1,196 @@
 package config
 
 import (
-	"encoding/json"
-	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/prompt-ops/pops/pkg/cache"
 	"github.com/prompt-ops/pops/pkg/conn"
+	"github.com/prompt-ops/pops/pkg/secret"
+	"github.com/prompt-ops/pops/pkg/workspace"
 )
 
-// connections stores all the connection configurations in memory.
-var connections []conn.Connection
+// connectionsFileName is the name of the connections configuration file in a workspace.
+const connectionsFileName = "connections.json"
 
-// connectionsConfigFilePath defines the path to the connections configuration file.
-var connectionsConfigFilePath = getConfigFilePath("connections.json")
+var (
+	storesMu sync.Mutex
 
-// getConfigFilePath constructs the absolute path for the configuration file.
+	// stores are the connections configuration files used so far, by path.
+	stores = map[string]*Store{}
+)
+
+// getConfigFilePath constructs the absolute path for a configuration file of the current workspace.
 func getConfigFilePath(filename string) string {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return filename
-	}
-	configDir := filepath.Join(homeDir, ".pops")
+	return filepath.Join(getConfigDir(workspace.Current()), filename)
+}
+
+// getConfigDir returns the directory of the workspace, creating it if needed.
+func getConfigDir(name string) string {
+	configDir := workspace.Dir(name)
 	if _, err := os.Stat(configDir); os.IsNotExist(err) {
 		if err := os.MkdirAll(configDir, 0755); err != nil {
-			return filename
+			return "."
 		}
 	}
-	return filepath.Join(configDir, filename)
+	return configDir
 }
 
-// init loads existing connections from the configuration file into memory.
-func init() {
-	if err := loadConnections(); err != nil && !errors.Is(err, os.ErrNotExist) {
-		fmt.Printf("Warning: Unable to load connections: %v\n", err)
-	}
+// DefaultStore returns the store of the connections configuration file of the current workspace.
+func DefaultStore() *Store {
+	return WorkspaceStore(workspace.Current())
 }
 
-// loadConnections reads the connections from the JSON configuration file.
-func loadConnections() error {
-	if _, statErr := os.Stat(connectionsConfigFilePath); os.IsNotExist(statErr) {
-		if createErr := writeConnections(); createErr != nil {
-			return createErr
-		}
+// WorkspaceStore returns the store of the connections configuration file of the workspace.
+func WorkspaceStore(name string) *Store {
+	path := filepath.Join(getConfigDir(name), connectionsFileName)
+
+	storesMu.Lock()
+	defer storesMu.Unlock()
+
+	if store, ok := stores[path]; ok {
+		return store
 	}
+	store := NewStore(path)
+	store.Workspace = name
+	upgrade(store)
+	stores[path] = store
+	return store
+}
 
-	file, err := os.Open(connectionsConfigFilePath)
+// upgrade upgrades a configuration file when it is first used: it migrates files of older schema versions,
+// quarantines invalid connections and moves secrets of connections saved by earlier versions out of the file.
+func upgrade(store *Store) {
+	file, err := store.Inspect()
 	if err != nil {
-		return err
+		fmt.Printf("Warning: Unable to load connections: %v\n", err)
+		return
 	}
-	defer file.Close()
 
-	var loadedConnections []conn.Connection
-	if err := json.NewDecoder(file).Decode(&loadedConnections); err != nil {
-		return err
+	if file.Version == SchemaVersion && len(file.Invalid) == 0 && !hasPlaintextSecrets(file.Connections) {
+		return
 	}
 
-	connections = loadedConnections
-	return nil
+	// Update migrates, quarantines and stores the secrets before writing the connections.
+	err = store.Update(func(connections []conn.Connection) ([]conn.Connection, error) {
+		return connections, nil
+	})
+	if err != nil {
+		fmt.Printf("Warning: Unable to upgrade %s: %v\n", store.Path, err)
+		return
+	}
+
+	if len(file.Invalid) > 0 {
+		fmt.Printf("Warning: %d invalid connection(s) were moved to %s; run 'pops connection doctor' for details.\n",
+			len(file.Invalid), store.QuarantinePath())
+	}
 }
 
-// SaveConnection saves a new connection or updates an existing one based on the connection name.
-func SaveConnection(conn conn.Connection) error {
-	if connections == nil {
-		if err := loadConnections(); err != nil && !errors.Is(err, os.ErrNotExist) {
-			return err
+// hasPlaintextSecrets reports whether any connection holds secrets that are not in the secret store.
+func hasPlaintextSecrets(connections []conn.Connection) bool {
+	for _, connection := range connections {
+		if details, ok := connection.Details.(conn.SecretDetails); ok && details.HasPlaintextSecrets() {
+			return true
 		}
 	}
+	return false
+}
+
+// storeSecrets moves the secrets held by connections of the workspace into the secret store, leaving references to them.
+func storeSecrets(connections []conn.Connection, workspaceName string) error {
+	for i, connection := range connections {
+		details, ok := connection.Details.(conn.SecretDetails)
+		if !ok || !details.HasPlaintextSecrets() {
+			continue
+		}
 
-	updated := false
-	for i, existingConnection := range connections {
-		if strings.EqualFold(existingConnection.Name, conn.Name) {
-			connections[i] = conn
-			updated = true
-			break
+		stored, err := details.StoreSecrets(secretName(workspaceName, connection.Name))
+		if err != nil {
+			return fmt.Errorf("failed to store the secrets of connection '%s': %w", connection.Name, err)
 		}
+		connections[i].Details = stored
 	}
+	return nil
+}
 
-	if !updated {
-		connections = append(connections, conn)
+// secretName returns the name the secrets of a connection are stored under. The secret store is shared
+// by all workspaces, so the names of connections outside the default workspace include the workspace.
+func secretName(workspaceName, connectionName string) string {
+	if workspaceName == "" || workspaceName == workspace.Default {
+		return connectionName
 	}
+	return workspaceName + "/" + connectionName
+}
 
-	if err := writeConnections(); err != nil {
-		return err
+// deleteSecrets removes the stored secrets of the connection.
+func deleteSecrets(connection conn.Connection) error {
+	details, ok := connection.Details.(conn.SecretDetails)
+	if !ok {
+		return nil
 	}
 
+	for _, ref := range details.SecretRefs() {
+		if err := secret.Delete(ref); err != nil {
+			return fmt.Errorf("failed to delete the secrets of connection '%s': %w", connection.Name, err)
+		}
+	}
 	return nil
 }
 
-// writeConnections writes the in-memory connections slice to the JSON configuration file.
-func writeConnections() error {
-	file, err := os.Create(connectionsConfigFilePath)
-	if err != nil {
+// SaveConnection saves a new connection or updates an existing one based on the connection name.
+// Connections defined in the project directory can't be saved.
+func SaveConnection(connection conn.Connection) error {
+	if err := checkNotInProject(connection.Name); err != nil {
 		return err
 	}
-	defer file.Close()
 
-	encoder := json.NewEncoder(file)
-	encoder.SetIndent("", "  ")
-	if err := encoder.Encode(connections); err != nil {
+	return DefaultStore().Update(func(connections []conn.Connection) ([]conn.Connection, error) {
+		for i, existingConnection := range connections {
+			if strings.EqualFold(existingConnection.Name, connection.Name) {
+				connections[i] = connection
+				return connections, nil
+			}
+		}
+		if connection.CreatedAt == nil {
+			now := time.Now().UTC()
+			connection.CreatedAt = &now
+		}
+		return append(connections, connection), nil
+	})
+}
+
+// RecordUse sets when the connection was last used to now.
+// Connections defined in the project directory aren't changed by pops, so their use isn't recorded.
+func RecordUse(connectionName string) error {
+	projectConns, _, err := projectConnections()
+	if err != nil {
 		return err
 	}
+	if hasConnection(projectConns, connectionName) {
+		return nil
+	}
 
-	return nil
+	return DefaultStore().Update(func(connections []conn.Connection) ([]conn.Connection, error) {
+		for i, connection := range connections {
+			if strings.EqualFold(connection.Name, connectionName) {
+				now := time.Now().UTC()
+				connections[i].LastUsedAt = &now
+				return connections, nil
+			}
+		}
+		return nil, fmt.Errorf("connection with name '%s' does not exist", connectionName)
+	})
 }
 
-// GetConnectionByName retrieves a connection by its name.
+// GetConnectionByName retrieves a connection by its name, from the project directory or the workspace.
 func GetConnectionByName(connectionName string) (conn.Connection, error) {
-	if connections == nil {
-		if err := loadConnections(); err != nil {
-			return conn.Connection{}, err
-		}
+	connections, err := GetAllConnections()
+	if err != nil {
+		return conn.Connection{}, err
 	}
 
 	for _, conn := range connections {
@@ -124,14 +202,17 @@ func GetConnectionByName(connectionName string) (conn.Connection, error) {
 	return conn.Connection{}, fmt.Errorf("connection with name '%s' does not exist", connectionName)
 }
 
-// GetAllConnections retrieves all stored connections.
+// GetAllConnections retrieves all stored connections of the workspace and the trusted project directory.
 func GetAllConnections() ([]conn.Connection, error) {
-	if connections == nil {
-		if err := loadConnections(); err != nil {
-			return nil, err
-		}
+	sourced, err := ListConnections()
+	if err != nil {
+		return nil, err
 	}
 
+	connections := make([]conn.Connection, len(sourced))
+	for i, connection := range sourced {
+		connections[i] = connection.Connection
+	}
 	return connections, nil
 }
 
@@ -153,65 +234,83 @@ func GetConnectionsByType(connectionType string) ([]conn.Connection, error) {
 }
 
 // DeleteConnectionByName removes a connection by its name.
+// Connections defined in the project directory can't be deleted.
 func DeleteConnectionByName(connectionName string) error {
-	if connections == nil {
-		if err := loadConnections(); err != nil {
-			return err
-		}
+	if err := checkNotInProject(connectionName); err != nil {
+		return err
 	}
 
-	var updatedConnections []conn.Connection
-	found := false
-	for _, conn := range connections {
-		if strings.EqualFold(conn.Name, connectionName) {
-			found = true
-			continue
+	var deleted *conn.Connection
+	err := DefaultStore().Update(func(connections []conn.Connection) ([]conn.Connection, error) {
+		var updatedConnections []conn.Connection
+		for _, conn := range connections {
+			if strings.EqualFold(conn.Name, connectionName) {
+				deleted = &conn
+				continue
+			}
+			updatedConnections = append(updatedConnections, conn)
 		}
-		updatedConnections = append(updatedConnections, conn)
-	}
 
-	if !found {
-		return fmt.Errorf("connection with name '%s' does not exist", connectionName)
+		if deleted == nil {
+			return nil, fmt.Errorf("connection with name '%s' does not exist", connectionName)
+		}
+		return updatedConnections, nil
+	})
+	if err != nil {
+		return err
 	}
 
-	connections = updatedConnections
-
-	if err := writeConnections(); err != nil {
+	if err := deleteSecrets(*deleted); err != nil {
 		return err
 	}
 
-	return nil
+	return cache.Delete(connectionName)
 }
 
-// DeleteAllConnections removes all stored connections.
+// DeleteAllConnections removes all stored connections of the workspace.
 func DeleteAllConnections() error {
-	connections = []conn.Connection{}
-
-	if err := writeConnections(); err != nil {
+	var deleted []conn.Connection
+	err := DefaultStore().Update(func(connections []conn.Connection) ([]conn.Connection, error) {
+		deleted = connections
+		return []conn.Connection{}, nil
+	})
+	if err != nil {
 		return err
 	}
 
-	return nil
-}
-
-func DeleteAllConnectionsByType(connectionType string) error {
-	if connections == nil {
-		if err := loadConnections(); err != nil {
+	for _, connection := range deleted {
+		if err := deleteSecrets(connection); err != nil {
 			return err
 		}
 	}
 
-	var updatedConnections []conn.Connection
-	for _, conn := range connections {
-		if !strings.EqualFold(conn.Type.GetMainType(), connectionType) {
-			updatedConnections = append(updatedConnections, conn)
+	return cache.DeleteAll()
+}
+
+func DeleteAllConnectionsByType(connectionType string) error {
+	var deleted []conn.Connection
+	err := DefaultStore().Update(func(connections []conn.Connection) ([]conn.Connection, error) {
+		var updatedConnections []conn.Connection
+		for _, conn := range connections {
+			if !strings.EqualFold(conn.Type.GetMainType(), connectionType) {
+				updatedConnections = append(updatedConnections, conn)
+			} else {
+				deleted = append(deleted, conn)
+			}
 		}
+		return updatedConnections, nil
+	})
+	if err != nil {
+		return err
 	}
 
-	connections = updatedConnections
-
-	if err := writeConnections(); err != nil {
-		return err
+	for _, connection := range deleted {
+		if err := deleteSecrets(connection); err != nil {
+			return err
+		}
+		if err := cache.Delete(connection.Name); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -219,17 +318,30 @@ func DeleteAllConnectionsByType(connectionType string) error {
 
 // CheckIfNameExists checks if a connection with the given name already exists.
 func CheckIfNameExists(name string) bool {
-	if connections == nil {
-		if err := loadConnections(); err != nil && !errors.Is(err, os.ErrNotExist) {
-			return false
-		}
+	_, err := GetConnectionByName(name)
+	return err == nil
+}
+
+// DeleteWorkspace deletes the workspace with its connections, their stored secrets and everything else in it.
+func DeleteWorkspace(name string) error {
+	if !workspace.Exists(name) {
+		return fmt.Errorf("%w: %s", workspace.ErrNotFound, name)
 	}
 
-	for _, conn := range connections {
-		if strings.EqualFold(conn.Name, name) {
-			return true
+	store := WorkspaceStore(name)
+	connections, err := store.Load()
+	if err != nil {
+		return err
+	}
+	for _, connection := range connections {
+		if err := deleteSecrets(connection); err != nil {
+			return err
 		}
 	}
 
-	return false
+	storesMu.Lock()
+	delete(stores, store.Path)
+	storesMu.Unlock()
+
+	return workspace.Remove(name)
 }
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prompt-ops/pops/pkg/conn"
+	"github.com/prompt-ops/pops/pkg/project"
+	"github.com/prompt-ops/pops/pkg/workspace"
+)
+
+func TestRecordUse(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv(workspace.EnvVar, "")
+	t.Setenv(project.EnvVar, "none")
+
+	before := time.Now().Add(-time.Second)
+	if err := SaveConnection(conn.NewKubernetesConnection("cluster", "dev")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		connection string
+		wantErr    bool
+	}{
+		{
+			name:       "Test RecordUse of a connection",
+			connection: "Cluster",
+		},
+		{
+			name:       "Test RecordUse of a missing connection",
+			connection: "missing",
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := RecordUse(tt.connection)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RecordUse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	connection, err := GetConnectionByName("cluster")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if connection.CreatedAt == nil || connection.LastUsedAt == nil || connection.CreatedAt.Before(before) || connection.LastUsedAt.Before(*connection.CreatedAt) {
+		t.Errorf("CreatedAt = %v, LastUsedAt = %v, want both set", connection.CreatedAt, connection.LastUsedAt)
+	}
+
+	// Saving the connection again keeps when it was created.
+	createdAt := connection.CreatedAt
+	connection.Description = "Development cluster"
+	if err := SaveConnection(connection); err != nil {
+		t.Fatal(err)
+	}
+	if connection, err = GetConnectionByName("cluster"); err != nil {
+		t.Fatal(err)
+	}
+	if connection.CreatedAt == nil || !connection.CreatedAt.Equal(*createdAt) || connection.Description != "Development cluster" {
+		t.Errorf("GetConnectionByName() = %+v, want CreatedAt %v kept", connection, createdAt)
+	}
+}
@@ -0,0 +1,198 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/prompt-ops/pops/pkg/conn"
+	"github.com/prompt-ops/pops/pkg/secret"
+)
+
+// OnConflict is what ImportConnections does with a connection whose name is taken.
+type OnConflict string
+
+const (
+	// OnConflictFail imports nothing if any name is taken.
+	OnConflictFail OnConflict = "fail"
+
+	// OnConflictSkip keeps the existing connection.
+	OnConflictSkip OnConflict = "skip"
+
+	// OnConflictOverwrite replaces the existing connection.
+	OnConflictOverwrite OnConflict = "overwrite"
+
+	// OnConflictRename imports the connection with a free name, like "prod-db-2".
+	OnConflictRename OnConflict = "rename"
+)
+
+// ParseOnConflict parses the value of --on-conflict.
+func ParseOnConflict(value string) (OnConflict, error) {
+	switch onConflict := OnConflict(value); onConflict {
+	case OnConflictFail, OnConflictSkip, OnConflictOverwrite, OnConflictRename:
+		return onConflict, nil
+	default:
+		return "", fmt.Errorf("invalid value '%s' (fail, skip, overwrite or rename)", value)
+	}
+}
+
+// ImportResult is what happened to an imported connection.
+type ImportResult struct {
+	// Name is the name of the connection in the bundle.
+	Name string
+
+	// ImportedAs is the name the connection was saved with; empty if it was skipped.
+	ImportedAs string
+
+	// Action is "created", "overwritten", "renamed" or "skipped".
+	Action string
+}
+
+// ImportConnections adds connections to the current workspace in a single update,
+// resolving names that are taken as onConflict says. References to the secret store are removed from them.
+// Connections with ${exec:...} or ${file:...} references, which run commands or read files when the connection
+// is opened, are only imported if allowReferences is set.
+func ImportConnections(imported []conn.Connection, onConflict OnConflict, allowReferences bool) ([]ImportResult, error) {
+	if !allowReferences {
+		for _, connection := range imported {
+			references, err := LocalReferences(connection)
+			if err != nil {
+				return nil, err
+			}
+			if len(references) > 0 {
+				return nil, fmt.Errorf("connection '%s' runs commands or reads files when it is opened (%s); check them and use --allow-references to import it", connection.Name, strings.Join(references, ", "))
+			}
+		}
+	}
+
+	var results []ImportResult
+	var replaced []conn.Connection
+	err := DefaultStore().Update(func(connections []conn.Connection) ([]conn.Connection, error) {
+		results, replaced = nil, nil
+
+		for _, connection := range imported {
+			if connection.Name == "" {
+				return nil, fmt.Errorf("a connection in the bundle has no name")
+			}
+
+			// Imported connections can't refer to secrets in the secret store, which may belong to other connections.
+			if details, ok := connection.Details.(conn.SecretDetails); ok {
+				connection.Details = details.WithoutSecretRefs()
+			}
+
+			// When the connection was used in the workspace it was exported from doesn't apply here.
+			connection.LastUsedAt = nil
+			if connection.CreatedAt == nil {
+				now := time.Now().UTC()
+				connection.CreatedAt = &now
+			}
+
+			index := slices.IndexFunc(connections, func(existing conn.Connection) bool {
+				return strings.EqualFold(existing.Name, connection.Name)
+			})
+			if index < 0 {
+				connections = append(connections, connection)
+				results = append(results, ImportResult{Name: connection.Name, ImportedAs: connection.Name, Action: "created"})
+				continue
+			}
+
+			switch onConflict {
+			case OnConflictSkip:
+				results = append(results, ImportResult{Name: connection.Name, Action: "skipped"})
+			case OnConflictOverwrite:
+				replaced = append(replaced, connections[index])
+				connections[index] = connection
+				results = append(results, ImportResult{Name: connection.Name, ImportedAs: connection.Name, Action: "overwritten"})
+			case OnConflictRename:
+				name := freeName(connections, connection.Name)
+				results = append(results, ImportResult{Name: connection.Name, ImportedAs: name, Action: "renamed"})
+				connection.Name = name
+				connections = append(connections, connection)
+			default:
+				return nil, fmt.Errorf("connection '%s' already exists; use --on-conflict to skip, overwrite or rename it", connection.Name)
+			}
+		}
+		return connections, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Secrets of replaced connections are saved under the same names as the new ones,
+	// so only the ones the new connections don't use any more are deleted.
+	if len(replaced) > 0 {
+		connections, err := DefaultStore().Load()
+		if err != nil {
+			return results, err
+		}
+		used := map[secret.Ref]bool{}
+		for _, connection := range connections {
+			if details, ok := connection.Details.(conn.SecretDetails); ok {
+				for _, ref := range details.SecretRefs() {
+					used[ref] = true
+				}
+			}
+		}
+		for _, connection := range replaced {
+			if details, ok := connection.Details.(conn.SecretDetails); ok {
+				for _, ref := range details.SecretRefs() {
+					if !used[ref] {
+						if err := secret.Delete(ref); err != nil {
+							return results, err
+						}
+					}
+				}
+			}
+		}
+	}
+	return results, nil
+}
+
+// LocalReferences returns the ${exec:...} and ${file:...} references in the details of the connection,
+// like the connection string of a database or the settings of a plugin.
+func LocalReferences(connection conn.Connection) ([]string, error) {
+	data, err := json.Marshal(connection.Details)
+	if err != nil {
+		return nil, err
+	}
+	var details interface{}
+	if err := json.Unmarshal(data, &details); err != nil {
+		return nil, err
+	}
+
+	var references []string
+	var walk func(value interface{})
+	walk = func(value interface{}) {
+		switch value := value.(type) {
+		case string:
+			references = append(references, secret.LocalReferences(value)...)
+		case []interface{}:
+			for _, item := range value {
+				walk(item)
+			}
+		case map[string]interface{}:
+			keys := make([]string, 0, len(value))
+			for key := range value {
+				keys = append(keys, key)
+			}
+			slices.Sort(keys)
+			for _, key := range keys {
+				walk(value[key])
+			}
+		}
+	}
+	walk(details)
+	return references, nil
+}
+
+// freeName returns the name with the lowest suffix like "-2" that no connection has.
+func freeName(connections []conn.Connection, name string) string {
+	for i := 2; ; i++ {
+		candidate := fmt.Sprintf("%s-%d", name, i)
+		if !hasConnection(connections, candidate) {
+			return candidate
+		}
+	}
+}
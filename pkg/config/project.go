@@ -0,0 +1,134 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/prompt-ops/pops/pkg/conn"
+	"github.com/prompt-ops/pops/pkg/project"
+	"github.com/prompt-ops/pops/pkg/workspace"
+)
+
+const (
+	// SourceWorkspace is the source of connections defined in a workspace, like ~/.pops/connections.json.
+	SourceWorkspace = "workspace"
+
+	// SourceProject is the source of connections defined in the project directory, like ./.pops/connections.json.
+	SourceProject = "project"
+)
+
+// SourcedConnection is a connection with where it is defined.
+type SourcedConnection struct {
+	conn.Connection
+
+	// Source is SourceWorkspace or SourceProject.
+	Source string
+
+	// Workspace is the workspace of connections defined in a workspace.
+	Workspace string
+
+	// Path is the file the connection is defined in.
+	Path string
+
+	// Overrides is true for project connections that hide a connection of the workspace with the same name.
+	Overrides bool
+}
+
+// warnUntrusted prints the warning about an untrusted project directory once.
+var warnUntrusted sync.Once
+
+// projectConnections returns the connections of the project directory if there is one and it is trusted.
+// Project connections are never written by pops, so the file is neither migrated nor modified.
+func projectConnections() ([]conn.Connection, string, error) {
+	p, err := project.Trusted()
+	var untrusted *project.UntrustedError
+	if errors.As(err, &untrusted) {
+		warnUntrusted.Do(func() {
+			fmt.Fprintf(os.Stderr, "Warning: %v.\n", err)
+		})
+		return nil, "", nil
+	}
+	if err != nil || p == nil {
+		return nil, "", err
+	}
+
+	path := p.Path(connectionsFileName)
+	file, err := NewStore(path).Inspect()
+	if err != nil {
+		return nil, path, err
+	}
+	for _, entry := range file.Invalid {
+		fmt.Fprintf(os.Stderr, "Warning: ignoring connection '%s' of %s: %s\n", entry.Name, path, entry.Error)
+	}
+	return file.Connections, path, nil
+}
+
+// ListConnections returns the connections of the current workspace merged with the connections of the
+// trusted project directory, with where each is defined. A project connection takes precedence over a
+// workspace connection with the same name. Connections of other workspaces are added if they are named.
+func ListConnections(otherWorkspaces ...string) ([]SourcedConnection, error) {
+	current := workspace.Current()
+	projectConns, projectPath, err := projectConnections()
+	if err != nil {
+		return nil, err
+	}
+
+	workspaces := []string{current}
+	for _, name := range otherWorkspaces {
+		if name != current {
+			workspaces = append(workspaces, name)
+		}
+	}
+
+	var sourced []SourcedConnection
+	for _, name := range workspaces {
+		store := WorkspaceStore(name)
+		connections, err := store.Load()
+		if err != nil {
+			return nil, err
+		}
+		for _, connection := range connections {
+			if name == current && hasConnection(projectConns, connection.Name) {
+				continue
+			}
+			sourced = append(sourced, SourcedConnection{
+				Connection: connection,
+				Source:     SourceWorkspace,
+				Workspace:  name,
+				Path:       store.Path,
+			})
+		}
+	}
+
+	currentConns, err := DefaultStore().Load()
+	if err != nil {
+		return nil, err
+	}
+	for _, connection := range projectConns {
+		sourced = append(sourced, SourcedConnection{
+			Connection: connection,
+			Source:     SourceProject,
+			Path:       projectPath,
+			Overrides:  hasConnection(currentConns, connection.Name),
+		})
+	}
+	return sourced, nil
+}
+
+// checkNotInProject returns an error if the trusted project directory defines the connection,
+// as pops doesn't change project connections.
+func checkNotInProject(connectionName string) error {
+	projectConns, projectPath, err := projectConnections()
+	if err != nil {
+		return err
+	}
+	for _, connection := range projectConns {
+		if strings.EqualFold(connection.Name, connectionName) {
+			return fmt.Errorf("connection '%s' is defined in %s; change it there", connection.Name, projectPath)
+		}
+	}
+	return nil
+}
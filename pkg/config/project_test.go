@@ -0,0 +1,89 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/prompt-ops/pops/pkg/conn"
+	"github.com/prompt-ops/pops/pkg/project"
+	"github.com/prompt-ops/pops/pkg/workspace"
+)
+
+func TestListConnections(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv(workspace.EnvVar, "")
+
+	projectDir := filepath.Join(t.TempDir(), project.DirName)
+	if err := os.MkdirAll(projectDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	projectFile := `{"version": 2, "connections": [
+		{"name": "Shared", "type": {"mainType": "Kubernetes"}, "details": {"selectedContext": "project"}},
+		{"name": "service", "type": {"mainType": "Kubernetes"}, "details": {"selectedContext": "project"}}
+	]}`
+	if err := os.WriteFile(filepath.Join(projectDir, connectionsFileName), []byte(projectFile), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(project.EnvVar, projectDir)
+
+	for _, name := range []string{"shared", "mine"} {
+		if err := SaveConnection(conn.NewKubernetesConnection(name, "workspace")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		trusted bool
+		want    map[string]string
+	}{
+		{
+			name: "Test ListConnections ignores untrusted projects",
+			want: map[string]string{"shared": SourceWorkspace, "mine": SourceWorkspace},
+		},
+		{
+			name:    "Test ListConnections merges trusted projects over the workspace",
+			trusted: true,
+			want:    map[string]string{"Shared": SourceProject, "mine": SourceWorkspace, "service": SourceProject},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.trusted {
+				if err := (&project.Project{Dir: projectDir}).Trust(); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			connections, err := ListConnections()
+			if err != nil {
+				t.Fatalf("ListConnections() error = %v", err)
+			}
+			if len(connections) != len(tt.want) {
+				t.Fatalf("ListConnections() = %d connections, want %d", len(connections), len(tt.want))
+			}
+			for _, connection := range connections {
+				if tt.want[connection.Name] != connection.Source {
+					t.Errorf("source of '%s' = %s, want %s", connection.Name, connection.Source, tt.want[connection.Name])
+				}
+				if connection.Name == "Shared" && !connection.Overrides {
+					t.Errorf("'Shared' doesn't override the workspace connection")
+				}
+			}
+
+			if _, err := GetConnectionByName("service"); (err == nil) != tt.trusted {
+				t.Errorf("GetConnectionByName(service) error = %v", err)
+			}
+			if err := SaveConnection(conn.NewKubernetesConnection("service", "workspace")); (err != nil) != tt.trusted {
+				t.Errorf("SaveConnection() of a project connection error = %v, want an error %v", err, tt.trusted)
+			}
+			if err := DeleteConnectionByName("service"); (err != nil) != tt.trusted {
+				t.Errorf("DeleteConnectionByName() of a project connection error = %v, want an error %v", err, tt.trusted)
+			}
+		})
+	}
+}
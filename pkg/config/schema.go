@@ -0,0 +1,172 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/prompt-ops/pops/pkg/conn"
+)
+
+// SchemaVersion is the version of the connections file written by this version of pops.
+//
+// Versions:
+//  1. A JSON array of connections, written before the file had a version.
+//  2. An object with the version and the connections.
+const SchemaVersion = 2
+
+// migrations upgrade the connections file one version at a time; migrations[i] upgrades version i+1 to i+2.
+// To change the schema, increase SchemaVersion and append the migration from the previous version.
+var migrations = []func(data []byte) ([]byte, error){
+	migrateV1ToV2,
+}
+
+// connectionsFile is the connections file as it is written.
+type connectionsFile struct {
+	Version     int               `json:"version"`
+	Connections []json.RawMessage `json:"connections"`
+}
+
+// InvalidEntry is an entry of the connections file that couldn't be decoded,
+// like a connection of a type this version of pops doesn't know.
+type InvalidEntry struct {
+	// Name is the name of the connection, if the entry has one.
+	Name string `json:"name,omitempty"`
+
+	// Entry is the entry as it was in the connections file.
+	Entry json.RawMessage `json:"entry"`
+
+	// Error is why the entry couldn't be decoded.
+	Error string `json:"error"`
+
+	// QuarantinedAt is when the entry was moved out of the connections file.
+	QuarantinedAt time.Time `json:"quarantinedAt,omitempty"`
+}
+
+// FileStatus is the connections file after migrating and decoding it.
+type FileStatus struct {
+	// Version is the version the file had on disk.
+	Version int
+
+	Connections []conn.Connection
+
+	// Invalid are the entries that couldn't be decoded.
+	Invalid []InvalidEntry
+}
+
+// fileVersion returns the schema version of the connections file.
+func fileVersion(data []byte) (int, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		return 1, nil
+	}
+
+	var file struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(trimmed, &file); err != nil {
+		return 0, err
+	}
+	if file.Version < 1 {
+		return 0, fmt.Errorf("missing schema version")
+	}
+	return file.Version, nil
+}
+
+// migrate upgrades the connections file to SchemaVersion.
+func migrate(data []byte) (connectionsFile, int, error) {
+	version, err := fileVersion(data)
+	if err != nil {
+		return connectionsFile{}, 0, err
+	}
+	if version > SchemaVersion {
+		return connectionsFile{}, version, fmt.Errorf("schema version %d is newer than the supported version %d; upgrade pops", version, SchemaVersion)
+	}
+
+	for v := version; v < SchemaVersion; v++ {
+		if data, err = migrations[v-1](data); err != nil {
+			return connectionsFile{}, version, fmt.Errorf("failed to migrate from schema version %d to %d: %w", v, v+1, err)
+		}
+	}
+
+	var file connectionsFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return connectionsFile{}, version, err
+	}
+	return file, version, nil
+}
+
+// decodeFile migrates the connections file and decodes its entries one by one,
+// so an invalid entry doesn't prevent using the other connections.
+func decodeFile(data []byte) (FileStatus, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return FileStatus{Version: SchemaVersion, Connections: []conn.Connection{}}, nil
+	}
+
+	file, version, err := migrate(data)
+	if err != nil {
+		return FileStatus{}, err
+	}
+
+	decoded := FileStatus{
+		Version:     version,
+		Connections: []conn.Connection{},
+	}
+	for _, entry := range file.Connections {
+		var connection conn.Connection
+		if err := json.Unmarshal(entry, &connection); err != nil {
+			var named struct {
+				Name string `json:"name"`
+			}
+			_ = json.Unmarshal(entry, &named)
+
+			decoded.Invalid = append(decoded.Invalid, InvalidEntry{
+				Name:  named.Name,
+				Entry: entry,
+				Error: err.Error(),
+			})
+			continue
+		}
+		decoded.Connections = append(decoded.Connections, connection)
+	}
+	return decoded, nil
+}
+
+// encodeFile encodes the connections in the current schema version.
+func encodeFile(connections []conn.Connection) ([]byte, error) {
+	file := connectionsFile{
+		Version:     SchemaVersion,
+		Connections: make([]json.RawMessage, len(connections)),
+	}
+	for i, connection := range connections {
+		entry, err := json.Marshal(connection)
+		if err != nil {
+			return nil, err
+		}
+		file.Connections[i] = entry
+	}
+
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(file); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// migrateV1ToV2 wraps the array of connections into an object with the version.
+func migrateV1ToV2(data []byte) ([]byte, error) {
+	var connections []json.RawMessage
+	if err := json.Unmarshal(data, &connections); err != nil {
+		return nil, err
+	}
+	if connections == nil {
+		connections = []json.RawMessage{}
+	}
+	return json.Marshal(connectionsFile{
+		Version:     2,
+		Connections: connections,
+	})
+}
@@ -0,0 +1,130 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/prompt-ops/pops/pkg/conn"
+)
+
+const v1File = `[
+  {"name": "cluster", "type": {"mainType": "Kubernetes"}, "details": {"selectedContext": "dev"}},
+  {"name": "mainframe", "type": {"mainType": "Mainframe"}, "details": {}}
+]`
+
+func TestDecodeFile(t *testing.T) {
+	tests := []struct {
+		name            string
+		data            string
+		wantVersion     int
+		wantConnections []string
+		wantInvalid     []string
+		wantErr         bool
+	}{
+		{
+			name:            "Test decodeFile migrates files without a version",
+			data:            v1File,
+			wantVersion:     1,
+			wantConnections: []string{"cluster"},
+			wantInvalid:     []string{"mainframe"},
+		},
+		{
+			name:            "Test decodeFile reads the current version",
+			data:            `{"version": 2, "connections": [{"name": "cluster", "type": {"mainType": "Kubernetes"}, "details": {}}]}`,
+			wantVersion:     2,
+			wantConnections: []string{"cluster"},
+		},
+		{
+			name:        "Test decodeFile reads an empty file",
+			data:        "",
+			wantVersion: SchemaVersion,
+		},
+		{
+			name:    "Test decodeFile rejects newer versions",
+			data:    `{"version": 99, "connections": []}`,
+			wantErr: true,
+		},
+		{
+			name:    "Test decodeFile rejects files without a version",
+			data:    `{"connections": []}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := decodeFile([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decodeFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if file.Version != tt.wantVersion {
+				t.Errorf("decodeFile() version = %d, want %d", file.Version, tt.wantVersion)
+			}
+			if len(file.Connections) != len(tt.wantConnections) {
+				t.Fatalf("decodeFile() connections = %v, want %v", file.Connections, tt.wantConnections)
+			}
+			for i, name := range tt.wantConnections {
+				if file.Connections[i].Name != name {
+					t.Errorf("decodeFile() connection %d = %s, want %s", i, file.Connections[i].Name, name)
+				}
+			}
+			if len(file.Invalid) != len(tt.wantInvalid) {
+				t.Fatalf("decodeFile() invalid = %v, want %v", file.Invalid, tt.wantInvalid)
+			}
+			for i, name := range tt.wantInvalid {
+				if file.Invalid[i].Name != name || file.Invalid[i].Error == "" {
+					t.Errorf("decodeFile() invalid %d = %+v, want %s with an error", i, file.Invalid[i], name)
+				}
+			}
+		})
+	}
+}
+
+func TestStore_MigratesAndQuarantines(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(filepath.Join(dir, "connections.json"))
+	if err := os.WriteFile(store.Path, []byte(v1File), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Update(func(connections []conn.Connection) ([]conn.Connection, error) {
+		return connections, nil
+	}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	file, err := store.Inspect()
+	if err != nil {
+		t.Fatalf("Inspect() error = %v", err)
+	}
+	if file.Version != SchemaVersion || len(file.Connections) != 1 || len(file.Invalid) != 0 {
+		t.Errorf("Inspect() = %+v, want the migrated file with only the valid connection", file)
+	}
+
+	if backup, err := os.ReadFile(store.Path + ".v1.bak"); err != nil || string(backup) != v1File {
+		t.Errorf("backup of version 1 = %q, %v", backup, err)
+	}
+
+	quarantined, err := store.Quarantined()
+	if err != nil || len(quarantined) != 1 || quarantined[0].Name != "mainframe" {
+		t.Fatalf("Quarantined() = %+v, %v, want the invalid connection", quarantined, err)
+	}
+
+	// The connection still can't be read, so it stays quarantined.
+	restored, err := store.RestoreQuarantined()
+	if err != nil || len(restored) != 0 {
+		t.Errorf("RestoreQuarantined() = %v, %v, want nothing restored", restored, err)
+	}
+
+	if err := store.PurgeQuarantined(); err != nil {
+		t.Fatalf("PurgeQuarantined() error = %v", err)
+	}
+	if quarantined, err := store.Quarantined(); err != nil || len(quarantined) != 0 {
+		t.Errorf("Quarantined() after PurgeQuarantined() = %+v, %v", quarantined, err)
+	}
+}
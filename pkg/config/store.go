@@ -0,0 +1,330 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/prompt-ops/pops/pkg/conn"
+)
+
+// Store is a connections file that several pops processes can use at the same time.
+// The file has a schema version; older files are migrated to SchemaVersion when they are modified.
+//
+// Modifications take an advisory lock on a lock file next to the connections file,
+// re-read the connections so changes of other processes are kept, and replace the file
+// by renaming a completely written temporary file, so a crash never leaves it half written.
+// The previous version of the file is kept as a backup with the ".bak" extension.
+type Store struct {
+	// Path is the path of the connections file.
+	Path string
+
+	// Workspace is the name of the workspace the connections file belongs to.
+	// It keeps the secrets of connections with the same name in different workspaces apart.
+	Workspace string
+}
+
+// NewStore returns the store for the connections file at the path.
+func NewStore(path string) *Store {
+	return &Store{Path: path}
+}
+
+// BackupPath returns the path of the backup of the previous version of the connections file.
+func (s *Store) BackupPath() string {
+	return s.Path + ".bak"
+}
+
+// lockPath returns the path of the lock file.
+func (s *Store) lockPath() string {
+	return s.Path + ".lock"
+}
+
+// Load reads the connections. A missing file has no connections.
+// Entries that can't be decoded are left out; Inspect reports them.
+// Reading doesn't need the lock, as the file is only ever replaced as a whole.
+func (s *Store) Load() ([]conn.Connection, error) {
+	file, err := s.Inspect()
+	if err != nil {
+		return nil, err
+	}
+	return file.Connections, nil
+}
+
+// Inspect reads the connections file, with its schema version and the entries that can't be decoded.
+func (s *Store) Inspect() (FileStatus, error) {
+	data, err := os.ReadFile(s.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return FileStatus{Version: SchemaVersion, Connections: []conn.Connection{}}, nil
+	}
+	if err != nil {
+		return FileStatus{}, err
+	}
+
+	file, err := decodeFile(data)
+	if err != nil {
+		return FileStatus{}, fmt.Errorf("invalid connections file %s: %w", s.Path, err)
+	}
+	return file, nil
+}
+
+// Update modifies the connections while holding the lock.
+// fn gets the connections as they are on disk and returns the connections to write.
+//
+// Files of an older schema version are migrated, keeping a backup of the old version.
+// Entries that can't be decoded are moved to the quarantine file, so they don't get lost.
+// Secrets held by the connections are moved to the secret store before they are written.
+func (s *Store) Update(fn func(connections []conn.Connection) ([]conn.Connection, error)) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return s.update(fn)
+}
+
+// update is Update for callers that hold the lock.
+func (s *Store) update(fn func(connections []conn.Connection) ([]conn.Connection, error)) error {
+	previous, err := os.ReadFile(s.Path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	file, err := decodeFile(previous)
+	if err != nil {
+		return fmt.Errorf("invalid connections file %s: %w", s.Path, err)
+	}
+
+	// fn may change the connections in place, so they are encoded without usage times first.
+	previousWithoutUsage, err := encodeFile(withoutUsage(file.Connections))
+	if err != nil {
+		return err
+	}
+
+	connections, err := fn(file.Connections)
+	if err != nil {
+		return err
+	}
+	if connections == nil {
+		connections = []conn.Connection{}
+	}
+
+	if err := storeSecrets(connections, s.Workspace); err != nil {
+		return err
+	}
+
+	data, err := encodeFile(connections)
+	if err != nil {
+		return err
+	}
+
+	if bytes.Equal(previous, data) {
+		return nil
+	}
+
+	if len(file.Invalid) > 0 {
+		if err := s.quarantine(file.Invalid); err != nil {
+			return fmt.Errorf("failed to quarantine invalid connections: %w", err)
+		}
+	}
+
+	// A change that only records when connections were used keeps the backup of the last real change.
+	usageOnly := false
+	if len(file.Invalid) == 0 && file.Version == SchemaVersion {
+		withoutUsageData, err := encodeFile(withoutUsage(connections))
+		if err != nil {
+			return err
+		}
+		usageOnly = bytes.Equal(previousWithoutUsage, withoutUsageData)
+	}
+
+	if len(previous) > 0 && !usageOnly {
+		backupPath := s.BackupPath()
+		if file.Version < SchemaVersion {
+			// Keep the last file of each old version, so downgrading pops can use it again.
+			backupPath = fmt.Sprintf("%s.v%d.bak", s.Path, file.Version)
+		}
+		if err := writeFileAtomic(backupPath, previous); err != nil {
+			return fmt.Errorf("failed to back up %s: %w", s.Path, err)
+		}
+	}
+	return writeFileAtomic(s.Path, data)
+}
+
+// withoutUsage returns a copy of the connections without the time they were last used.
+func withoutUsage(connections []conn.Connection) []conn.Connection {
+	copied := slices.Clone(connections)
+	for i := range copied {
+		copied[i].LastUsedAt = nil
+	}
+	return copied
+}
+
+// QuarantinePath returns the path of the file invalid connections are moved to.
+func (s *Store) QuarantinePath() string {
+	return strings.TrimSuffix(s.Path, filepath.Ext(s.Path)) + ".quarantine.json"
+}
+
+// Quarantined returns the connections that were moved out of the connections file because they were invalid.
+func (s *Store) Quarantined() ([]InvalidEntry, error) {
+	data, err := os.ReadFile(s.QuarantinePath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []InvalidEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("invalid quarantine file %s: %w", s.QuarantinePath(), err)
+	}
+	return entries, nil
+}
+
+// quarantine appends entries to the quarantine file. The caller must hold the lock.
+func (s *Store) quarantine(entries []InvalidEntry) error {
+	quarantined, err := s.Quarantined()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, entry := range entries {
+		entry.QuarantinedAt = now
+		quarantined = append(quarantined, entry)
+	}
+	return s.writeQuarantine(quarantined)
+}
+
+// writeQuarantine replaces the quarantine file, removing it if there are no entries. The caller must hold the lock.
+func (s *Store) writeQuarantine(entries []InvalidEntry) error {
+	if len(entries) == 0 {
+		err := os.Remove(s.QuarantinePath())
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(s.QuarantinePath(), append(data, '\n'))
+}
+
+// RestoreQuarantined moves quarantined connections that can be decoded now, for example after installing
+// the plugin they need, back into the connections file. Connections whose names are taken stay quarantined.
+// Returns the names of the restored connections.
+func (s *Store) RestoreQuarantined() ([]string, error) {
+	unlock, err := s.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	quarantined, err := s.Quarantined()
+	if err != nil || len(quarantined) == 0 {
+		return nil, err
+	}
+
+	var restored []string
+	var remaining []InvalidEntry
+	err = s.update(func(connections []conn.Connection) ([]conn.Connection, error) {
+		for _, entry := range quarantined {
+			var connection conn.Connection
+			if err := json.Unmarshal(entry.Entry, &connection); err != nil {
+				entry.Error = err.Error()
+				remaining = append(remaining, entry)
+				continue
+			}
+			if hasConnection(connections, connection.Name) {
+				entry.Error = fmt.Sprintf("a connection named '%s' already exists", connection.Name)
+				remaining = append(remaining, entry)
+				continue
+			}
+
+			connections = append(connections, connection)
+			restored = append(restored, connection.Name)
+		}
+		return connections, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The quarantine is only reduced once the restored connections are written.
+	return restored, s.writeQuarantine(remaining)
+}
+
+// PurgeQuarantined deletes the quarantined connections.
+func (s *Store) PurgeQuarantined() error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return s.writeQuarantine(nil)
+}
+
+// hasConnection reports whether a connection with the name is in connections. Names are case insensitive.
+func hasConnection(connections []conn.Connection, name string) bool {
+	for _, connection := range connections {
+		if strings.EqualFold(connection.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// lock takes the lock of the store and returns the function to release it.
+func (s *Store) lock() (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0755); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(s.lockPath(), os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(file); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to lock %s: %w", s.Path, err)
+	}
+
+	return func() {
+		_ = unlockFile(file)
+		file.Close()
+	}, nil
+}
+
+// writeFileAtomic replaces the file with the data, readable only by the user.
+// The data is written to a temporary file in the same directory first and renamed over the file.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
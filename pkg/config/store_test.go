@@ -0,0 +1,106 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prompt-ops/pops/pkg/conn"
+)
+
+func TestStore_ConcurrentUpdates(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "connections.json"))
+
+	const writers = 20
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := store.Update(func(connections []conn.Connection) ([]conn.Connection, error) {
+				return append(connections, conn.NewKubernetesConnection(fmt.Sprintf("cluster-%d", i), "dev")), nil
+			})
+			if err != nil {
+				t.Errorf("Update() error = %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	connections, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(connections) != writers {
+		t.Errorf("Load() = %d connections, want %d", len(connections), writers)
+	}
+}
+
+func TestStore_Update(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "connections.json"))
+
+	connections, err := store.Load()
+	if err != nil || len(connections) != 0 {
+		t.Fatalf("Load() of a missing file = %v, %v, want no connections", connections, err)
+	}
+
+	add := func(name string) {
+		t.Helper()
+		err := store.Update(func(connections []conn.Connection) ([]conn.Connection, error) {
+			return append(connections, conn.NewKubernetesConnection(name, "dev")), nil
+		})
+		if err != nil {
+			t.Fatalf("Update() error = %v", err)
+		}
+	}
+	add("first")
+	add("second")
+
+	info, err := os.Stat(store.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 && os.PathSeparator == '/' {
+		t.Errorf("connections file permissions = %o, want 600", perm)
+	}
+
+	backup, err := NewStore(store.BackupPath()).Load()
+	if err != nil {
+		t.Fatalf("Load() of the backup error = %v", err)
+	}
+	if len(backup) != 1 || backup[0].Name != "first" {
+		t.Errorf("backup = %v, want the previous version with only 'first'", backup)
+	}
+
+	// Recording when a connection was used keeps the backup of the last real change.
+	err = store.Update(func(connections []conn.Connection) ([]conn.Connection, error) {
+		now := time.Now().UTC()
+		connections[1].LastUsedAt = &now
+		return connections, nil
+	})
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	backup, err = NewStore(store.BackupPath()).Load()
+	if err != nil {
+		t.Fatalf("Load() of the backup error = %v", err)
+	}
+	if len(backup) != 1 {
+		t.Errorf("backup after recording a use = %v, want the version with only 'first'", backup)
+	}
+
+	// A failed update leaves the file as it was.
+	err = store.Update(func(connections []conn.Connection) ([]conn.Connection, error) {
+		return nil, fmt.Errorf("failed")
+	})
+	if err == nil {
+		t.Fatal("Update() error = nil, want the error of the update")
+	}
+	connections, err = store.Load()
+	if err != nil || len(connections) != 2 {
+		t.Errorf("Load() after a failed update = %v, %v, want 2 connections", connections, err)
+	}
+}
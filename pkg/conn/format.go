@@ -0,0 +1,301 @@
+package conn
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// FormatTable renders results as a table, styled by the connection type.
+	FormatTable = "table"
+
+	// FormatJSON renders tabular results as an array of objects.
+	FormatJSON = "json"
+
+	// FormatYAML renders tabular results as a list of mappings.
+	FormatYAML = "yaml"
+
+	// FormatCSV renders tabular results as comma separated values with a header.
+	FormatCSV = "csv"
+
+	// FormatMarkdown renders tabular results as a Markdown table.
+	FormatMarkdown = "markdown"
+)
+
+// ResultFormatter renders a result set in an output format.
+type ResultFormatter func(result *ResultSet) (string, error)
+
+var (
+	resultFormattersMu sync.RWMutex
+	resultFormatters   = map[string]ResultFormatter{}
+)
+
+func init() {
+	RegisterResultFormatter(FormatTable, formatTableResult)
+	RegisterResultFormatter(FormatJSON, formatJSONResult)
+	RegisterResultFormatter(FormatYAML, formatYAMLResult)
+	RegisterResultFormatter(FormatCSV, formatCSVResult)
+	RegisterResultFormatter(FormatMarkdown, formatMarkdownResult)
+}
+
+// RegisterResultFormatter makes an output format available to all connection types.
+// It panics if a formatter is already registered with the same name.
+func RegisterResultFormatter(name string, formatter ResultFormatter) {
+	resultFormattersMu.Lock()
+	defer resultFormattersMu.Unlock()
+
+	key := strings.ToLower(name)
+	if _, exists := resultFormatters[key]; exists {
+		panic(fmt.Sprintf("result formatter %q is already registered", name))
+	}
+	resultFormatters[key] = formatter
+}
+
+// GetResultFormatter returns the formatter registered for the output format.
+func GetResultFormatter(name string) (ResultFormatter, error) {
+	resultFormattersMu.RLock()
+	defer resultFormattersMu.RUnlock()
+
+	formatter, ok := resultFormatters[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported output format: %s (available: %s)", name, strings.Join(availableResultFormatsLocked(), ", "))
+	}
+	return formatter, nil
+}
+
+// AvailableResultFormats returns the names of the registered output formats, sorted, with the table format first.
+func AvailableResultFormats() []string {
+	resultFormattersMu.RLock()
+	defer resultFormattersMu.RUnlock()
+
+	return availableResultFormatsLocked()
+}
+
+func availableResultFormatsLocked() []string {
+	formats := make([]string, 0, len(resultFormatters))
+	for name := range resultFormatters {
+		if name != FormatTable {
+			formats = append(formats, name)
+		}
+	}
+	sort.Strings(formats)
+	return append([]string{FormatTable}, formats...)
+}
+
+// FormatResult renders the result of a command run on the connection in the output format.
+// The table format is rendered by the connection type, the other formats are shared by all connection types.
+func FormatResult(c ConnectionInterface, result *ResultSet, format string) (string, error) {
+	if format == "" || strings.EqualFold(format, FormatTable) {
+		return c.FormatResultAsTable(result)
+	}
+
+	formatter, err := GetResultFormatter(format)
+	if err != nil {
+		return "", err
+	}
+	return formatter(result)
+}
+
+// formatTableResult renders results without a connection, like the list of connections, as a table.
+func formatTableResult(result *ResultSet) (string, error) {
+	if !result.IsTabular() {
+		return result.Text, nil
+	}
+	return formatTable(result), nil
+}
+
+// structuredValue converts a value for JSON and YAML output.
+// Timestamps become RFC 3339 strings; exact numerics and JSON documents are kept as they are.
+func structuredValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case []byte:
+		return formatBytes(v)
+	default:
+		return v
+	}
+}
+
+func formatJSONResult(result *ResultSet) (string, error) {
+	if !result.IsTabular() {
+		data, err := json.MarshalIndent(map[string]string{"output": result.Text}, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(data) + "\n", nil
+	}
+
+	// Objects are written key by key, as maps would lose the column order.
+	var buffer bytes.Buffer
+	buffer.WriteString("[")
+	for i, row := range result.Rows {
+		if i > 0 {
+			buffer.WriteString(",")
+		}
+		buffer.WriteString("\n  {")
+		for j, column := range result.Columns {
+			if j > 0 {
+				buffer.WriteString(",")
+			}
+			key, err := json.Marshal(column.Name)
+			if err != nil {
+				return "", err
+			}
+			value, err := json.Marshal(structuredValue(row[j]))
+			if err != nil {
+				return "", err
+			}
+			fmt.Fprintf(&buffer, "\n    %s: %s", key, value)
+		}
+		buffer.WriteString("\n  }")
+	}
+	if len(result.Rows) > 0 {
+		buffer.WriteString("\n")
+	}
+	buffer.WriteString("]\n")
+	return buffer.String(), nil
+}
+
+func formatYAMLResult(result *ResultSet) (string, error) {
+	var document yaml.Node
+	if !result.IsTabular() {
+		document = yaml.Node{Kind: yaml.MappingNode}
+		document.Content = []*yaml.Node{
+			{Kind: yaml.ScalarNode, Value: "output"},
+			{Kind: yaml.ScalarNode, Value: result.Text, Style: yaml.LiteralStyle},
+		}
+	} else {
+		document = yaml.Node{Kind: yaml.SequenceNode, Content: []*yaml.Node{}}
+		for _, row := range result.Rows {
+			mapping := &yaml.Node{Kind: yaml.MappingNode}
+			for j, column := range result.Columns {
+				value, err := yamlValue(row[j])
+				if err != nil {
+					return "", err
+				}
+				mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: column.Name}, value)
+			}
+			document.Content = append(document.Content, mapping)
+		}
+	}
+
+	var buffer bytes.Buffer
+	encoder := yaml.NewEncoder(&buffer)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&document); err != nil {
+		return "", err
+	}
+	if err := encoder.Close(); err != nil {
+		return "", err
+	}
+	return buffer.String(), nil
+}
+
+// yamlValue converts a value into a YAML node. JSON documents become nested YAML.
+func yamlValue(value interface{}) (*yaml.Node, error) {
+	switch v := structuredValue(value).(type) {
+	case json.RawMessage:
+		// JSON is valid YAML; the parsed nodes are switched to block style.
+		var document yaml.Node
+		if err := yaml.Unmarshal(v, &document); err != nil {
+			return nil, err
+		}
+		node := document.Content[0]
+		clearYAMLStyle(node)
+		return node, nil
+	case json.Number:
+		return &yaml.Node{Kind: yaml.ScalarNode, Value: v.String()}, nil
+	default:
+		node := &yaml.Node{}
+		if err := node.Encode(v); err != nil {
+			return nil, err
+		}
+		return node, nil
+	}
+}
+
+// clearYAMLStyle switches a node and its children from flow style, as parsed from JSON, to the default style.
+func clearYAMLStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		clearYAMLStyle(child)
+	}
+}
+
+func formatCSVResult(result *ResultSet) (string, error) {
+	var buffer bytes.Buffer
+	writer := csv.NewWriter(&buffer)
+
+	if !result.IsTabular() {
+		if err := writer.Write([]string{"output"}); err != nil {
+			return "", err
+		}
+		for _, line := range strings.Split(strings.TrimSuffix(result.Text, "\n"), "\n") {
+			if err := writer.Write([]string{line}); err != nil {
+				return "", err
+			}
+		}
+	} else {
+		if err := writer.Write(result.ColumnNames()); err != nil {
+			return "", err
+		}
+		for _, row := range result.Rows {
+			record := make([]string, len(row))
+			for i, value := range row {
+				// NULL is an empty field in CSV.
+				if value != nil {
+					record[i] = FormatValue(value)
+				}
+			}
+			if err := writer.Write(record); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return "", err
+	}
+	return buffer.String(), nil
+}
+
+func formatMarkdownResult(result *ResultSet) (string, error) {
+	if !result.IsTabular() {
+		return "```\n" + strings.TrimSuffix(result.Text, "\n") + "\n```\n", nil
+	}
+
+	var sb strings.Builder
+	header := result.ColumnNames()
+	separators := make([]string, len(header))
+	for i := range header {
+		header[i] = markdownCell(header[i])
+		separators[i] = "---"
+	}
+	sb.WriteString("| " + strings.Join(header, " | ") + " |\n")
+	sb.WriteString("| " + strings.Join(separators, " | ") + " |\n")
+
+	for _, row := range result.StringRows(0) {
+		for i := range row {
+			row[i] = markdownCell(row[i])
+		}
+		sb.WriteString("| " + strings.Join(row, " | ") + " |\n")
+	}
+	return sb.String(), nil
+}
+
+// markdownCell escapes pipes and line breaks, which would end a Markdown table cell.
+func markdownCell(value string) string {
+	value = strings.ReplaceAll(value, `|`, `\|`)
+	value = strings.ReplaceAll(value, "\r\n", "<br>")
+	return strings.ReplaceAll(value, "\n", "<br>")
+}
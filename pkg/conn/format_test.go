@@ -0,0 +1,115 @@
+package conn
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestFormatResult(t *testing.T) {
+	result := &ResultSet{
+		Columns: []Column{{Name: "name"}, {Name: "count"}, {Name: "price"}, {Name: "flag"}, {Name: "meta"}, {Name: "created"}, {Name: "note"}},
+		Rows: [][]interface{}{
+			{"a|b", int64(2), json.Number("1.50"), true, json.RawMessage(`{"env": "dev", "on": "true"}`), time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), nil},
+		},
+	}
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{
+			format: FormatJSON,
+			want: `[
+  {
+    "name": "a|b",
+    "count": 2,
+    "price": 1.50,
+    "flag": true,
+    "meta": {"env":"dev","on":"true"},
+    "created": "2024-05-01T00:00:00Z",
+    "note": null
+  }
+]
+`,
+		},
+		{
+			format: FormatYAML,
+			want: `- name: a|b
+  count: 2
+  price: 1.50
+  flag: true
+  meta:
+    env: dev
+    on: "true"
+  created: "2024-05-01T00:00:00Z"
+  note: null
+`,
+		},
+		{
+			format: FormatCSV,
+			want: `name,count,price,flag,meta,created,note
+a|b,2,1.50,true,"{""env"":""dev"",""on"":""true""}",2024-05-01T00:00:00Z,
+`,
+		},
+		{
+			format: FormatMarkdown,
+			want: `| name | count | price | flag | meta | created | note |
+| --- | --- | --- | --- | --- | --- | --- |
+| a\|b | 2 | 1.50 | true | {"env":"dev","on":"true"} | 2024-05-01T00:00:00Z | NULL |
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run("Test FormatResult with "+tt.format, func(t *testing.T) {
+			formatter, err := GetResultFormatter(tt.format)
+			if err != nil {
+				t.Fatalf("GetResultFormatter() error = %v", err)
+			}
+			got, err := formatter(result)
+			if err != nil {
+				t.Fatalf("formatter() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("formatter() = \n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatResult_Text(t *testing.T) {
+	result := NewTextResultSet("Name: web\nStatus: Running\n")
+
+	got, err := formatMarkdownResult(result)
+	if err != nil {
+		t.Fatalf("formatMarkdownResult() error = %v", err)
+	}
+	if want := "```\nName: web\nStatus: Running\n```\n"; got != want {
+		t.Errorf("formatMarkdownResult() = %q, want %q", got, want)
+	}
+
+	got, err = formatJSONResult(result)
+	if err != nil {
+		t.Fatalf("formatJSONResult() error = %v", err)
+	}
+	if want := "{\n  \"output\": \"Name: web\\nStatus: Running\\n\"\n}\n"; got != want {
+		t.Errorf("formatJSONResult() = %q, want %q", got, want)
+	}
+}
+
+func TestGetResultFormatter_Unknown(t *testing.T) {
+	if _, err := GetResultFormatter("xml"); err == nil {
+		t.Errorf("GetResultFormatter() expected error for unknown format")
+	}
+
+	want := []string{FormatTable, FormatCSV, FormatJSON, FormatMarkdown, FormatYAML}
+	got := AvailableResultFormats()
+	if len(got) != len(want) {
+		t.Fatalf("AvailableResultFormats() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("AvailableResultFormats() = %v, want %v", got, want)
+		}
+	}
+}
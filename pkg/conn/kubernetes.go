@@ -3,12 +3,13 @@ package conn
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os/exec"
 	"strings"
 
 	"github.com/olekukonko/tablewriter"
-	"github.com/prompt-ops/pops/pkg/ai"
+	"gopkg.in/yaml.v3"
 )
 
 var (
@@ -16,6 +17,17 @@ var (
 	AvailableKubernetesConnectionTypes = []AvailableKubernetesConnectionType{}
 )
 
+func init() {
+	RegisterConnectionType(ConnectionTypeRegistration{
+		MainType:      ConnectionTypeKubernetes,
+		DecodeType:    DecodeTypeAs[KubernetesConnectionType](),
+		DecodeDetails: DecodeDetailsAs[KubernetesConnectionDetails](),
+		New: func(connection Connection) (ConnectionInterface, error) {
+			return NewKubernetesConnectionImpl(&connection), nil
+		},
+	})
+}
+
 // AvailableKubernetesConnection is a helper struct to UI to list available kubernetes connection types.
 // Subtype will be shown in the UI.
 type AvailableKubernetesConnectionType struct {
@@ -39,6 +51,10 @@ func (k KubernetesConnectionType) GetSubtype() string {
 type KubernetesConnectionDetails struct {
 	// SelectedContext is the selected context for the kubernetes connection.
 	SelectedContext string `json:"selectedContext"`
+
+	// Namespaces is the optional list of namespaces the connection is allowed to use.
+	// If empty, the connection works across all namespaces.
+	Namespaces []string `json:"namespaces,omitempty"`
 }
 
 func (k KubernetesConnectionDetails) GetDriver() string {
@@ -49,8 +65,13 @@ func (k KubernetesConnectionDetails) GetSelectedContext() string {
 	return k.SelectedContext
 }
 
+func (k KubernetesConnectionDetails) GetNamespaces() []string {
+	return k.Namespaces
+}
+
 // NewKubernetesConnection creates a new Kubernetes connection.
-func NewKubernetesConnection(name, context string) Connection {
+// Namespaces are optional and restrict the connection to the given namespaces.
+func NewKubernetesConnection(name, context string, namespaces ...string) Connection {
 	return Connection{
 		Name: name,
 		Type: KubernetesConnectionType{
@@ -58,6 +79,7 @@ func NewKubernetesConnection(name, context string) Connection {
 		},
 		Details: KubernetesConnectionDetails{
 			SelectedContext: context,
+			Namespaces:      namespaces,
 		},
 	}
 }
@@ -142,11 +164,46 @@ func (k *KubernetesConnectionImpl) SetContext() error {
 	return nil
 }
 
+// kubernetesContext is the cached form of the Kubernetes context.
+type kubernetesContext struct {
+	Namespaces  []Namespace  `json:"namespaces"`
+	Pods        []Pod        `json:"pods"`
+	Deployments []Deployment `json:"deployments"`
+	Services    []Service    `json:"services"`
+}
+
+func (k *KubernetesConnectionImpl) ExportContext() ([]byte, error) {
+	return json.Marshal(kubernetesContext{
+		Namespaces:  k.Namespaces,
+		Pods:        k.Pods,
+		Deployments: k.Deployments,
+		Services:    k.Services,
+	})
+}
+
+func (k *KubernetesConnectionImpl) ImportContext(data []byte) error {
+	var context kubernetesContext
+	if err := json.Unmarshal(data, &context); err != nil {
+		return fmt.Errorf("error parsing cached context: %v", err)
+	}
+
+	k.Namespaces = context.Namespaces
+	k.Pods = context.Pods
+	k.Deployments = context.Deployments
+	k.Services = context.Services
+	return nil
+}
+
 func (k *KubernetesConnectionImpl) GetContext() string {
 	var sb strings.Builder
 
 	sb.WriteString("Kubernetes Connection Context:\n\n")
 
+	if allowed := k.allowedNamespaces(); len(allowed) > 0 {
+		sb.WriteString(fmt.Sprintf("Note to the AI: This connection is restricted to the following namespaces: %s.\n", strings.Join(allowed, ", ")))
+		sb.WriteString("Note to the AI: Always pass --namespace (-n) with one of these namespaces and never use --all-namespaces (-A).\n\n")
+	}
+
 	sb.WriteString("Namespaces:\n")
 	for _, ns := range k.Namespaces {
 		sb.WriteString(fmt.Sprintf("- %s\n", ns.Name))
@@ -209,7 +266,7 @@ func (k *KubernetesConnectionImpl) GetFormattedContext() (string, error) {
 }
 
 func (k *KubernetesConnectionImpl) GetCommand(prompt string) (string, error) {
-	aiModel, err := ai.NewOpenAIModel(k.CommandType(), k.GetContext())
+	aiModel, err := newAIModel(k.Connection, k.CommandType(), k.GetContext())
 	if err != nil {
 		return "", fmt.Errorf("failed to create AI model: %v", err)
 	}
@@ -223,7 +280,7 @@ func (k *KubernetesConnectionImpl) GetCommand(prompt string) (string, error) {
 }
 
 func (k *KubernetesConnectionImpl) GetAnswer(prompt string) (string, error) {
-	aiModel, err := ai.NewOpenAIModel(k.CommandType(), k.GetContext())
+	aiModel, err := newAIModel(k.Connection, k.CommandType(), k.GetContext())
 	if err != nil {
 		return "", fmt.Errorf("failed to create AI model: %v", err)
 	}
@@ -236,44 +293,33 @@ func (k *KubernetesConnectionImpl) GetAnswer(prompt string) (string, error) {
 	return answer.Answer, nil
 }
 
-func (k *KubernetesConnectionImpl) ExecuteCommand(command string) ([]byte, error) {
-	// Split the command into parts
-	parts := strings.Fields(command)
-	if len(parts) == 0 {
-		return nil, fmt.Errorf("no command provided")
+// ExecuteCommand executes the given kubectl command.
+// Column aligned output, like the default output of `kubectl get`, is returned as rows; anything else as text.
+// The output is streamed; only the first rows are read until more are fetched.
+func (k *KubernetesConnectionImpl) ExecuteCommand(command string) (*ResultSet, error) {
+	if err := k.CheckReadOnly(command); err != nil {
+		return nil, err
 	}
 
-	// The first part is the command, the rest are the arguments
-	cmd := exec.Command(parts[0], parts[1:]...)
-	output, err := cmd.CombinedOutput()
+	output, err := executeCommandLine(k.Connection, k.AllowedExecutables(), command, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute command: %v", err)
+		return nil, err
 	}
 
-	return output, nil
-}
-
-func (k *KubernetesConnectionImpl) FormatResultAsTable(result []byte) (string, error) {
-	resultStr := string(result)
-
-	lines := strings.Split(resultStr, "\n")
-	if len(lines) == 0 {
-		return "", fmt.Errorf("no data to format")
+	result, err := NewColumnarTextResultSet(output, k.Connection.GetRowLimit())
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute command: %w", err)
 	}
+	return result, nil
+}
 
-	header := strings.Fields(lines[0])
-	if len(header) == 0 {
-		return "", fmt.Errorf("no headers found in result")
+func (k *KubernetesConnectionImpl) FormatResultAsTable(result *ResultSet) (string, error) {
+	if !result.IsTabular() {
+		return result.Text, nil
 	}
 
-	var rows [][]string
-	for _, line := range lines[1:] {
-		if strings.TrimSpace(line) == "" {
-			continue
-		}
-		row := strings.Fields(line)
-		rows = append(rows, row)
-	}
+	header := result.ColumnNames()
+	rows := result.StringRows(0)
 
 	var buffer bytes.Buffer
 
@@ -298,6 +344,24 @@ func (k *KubernetesConnectionImpl) CommandType() string {
 	return "kubectl command"
 }
 
+func (k *KubernetesConnectionImpl) AllowedExecutables() []string {
+	return []string{"kubectl"}
+}
+
+// CheckReadOnly only allows the get, describe, logs and top kubectl commands on read-only connections.
+func (k *KubernetesConnectionImpl) CheckReadOnly(command string) error {
+	return checkReadOnlyStages(k.Connection, command, "kubectl", func(args []string) error {
+		verb, err := kubectlStrictVerb(args)
+		if err != nil {
+			return readOnlyViolation(k.Connection, fmt.Sprintf("can't tell what `%s` runs: %v", strings.Join(args, " "), err))
+		}
+		if !containsFold(readOnlyKubectlVerbs, verb) {
+			return readOnlyViolation(k.Connection, fmt.Sprintf("`kubectl %s` is not allowed, only %s", verb, strings.Join(readOnlyKubectlVerbs, ", ")))
+		}
+		return nil
+	})
+}
+
 type Namespace struct {
 	Name string `json:"name"`
 }
@@ -320,11 +384,45 @@ type Service struct {
 	Namespace string `json:"namespace"`
 }
 
-// getNamespaces retrieves all namespaces in the cluster
+// objectMeta is the subset of object metadata collected for the context.
+type objectMeta struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+}
+
+// allowedNamespaces returns the namespaces the connection is restricted to.
+// It returns nil if the connection is not restricted.
+func (k *KubernetesConnectionImpl) allowedNamespaces() []string {
+	details, err := GetKubernetesConnectionDetails(k.Connection)
+	if err != nil {
+		return nil
+	}
+	return details.GetNamespaces()
+}
+
+// isForbidden checks whether kubectl output is an RBAC Forbidden error.
+func isForbidden(output []byte) bool {
+	return strings.Contains(string(output), "Forbidden") || strings.Contains(string(output), "forbidden")
+}
+
+// getNamespaces retrieves all namespaces in the cluster.
+// If the connection is restricted, the allowed namespaces are returned without querying the cluster.
 func (k *KubernetesConnectionImpl) getNamespaces() ([]Namespace, error) {
+	if allowed := k.allowedNamespaces(); len(allowed) > 0 {
+		var namespaces []Namespace
+		for _, ns := range allowed {
+			namespaces = append(namespaces, Namespace{Name: ns})
+		}
+		return namespaces, nil
+	}
+
 	cmd := exec.Command("kubectl", "get", "namespaces", "-o", "json")
 	output, err := cmd.CombinedOutput()
 	if err != nil {
+		// Listing namespaces is cluster-scoped, so users with namespaced RBAC can't do it.
+		if isForbidden(output) {
+			return nil, nil
+		}
 		return nil, fmt.Errorf("failed to list namespaces: %v. Output: %s", err, string(output))
 	}
 
@@ -348,98 +446,517 @@ func (k *KubernetesConnectionImpl) getNamespaces() ([]Namespace, error) {
 	return namespaces, nil
 }
 
-// getPods retrieves all pods across all namespaces
-func (k *KubernetesConnectionImpl) getPods() ([]Pod, error) {
-	cmd := exec.Command("kubectl", "get", "pods", "--all-namespaces", "-o", "json")
+// listObjects retrieves the given resource across the allowed namespaces,
+// or across all namespaces if the connection is not restricted.
+// Namespaces the user is forbidden to read are skipped.
+func (k *KubernetesConnectionImpl) listObjects(resource string) ([]objectMeta, error) {
+	allowed := k.allowedNamespaces()
+	if len(allowed) == 0 {
+		objects, err := k.listObjectsIn(resource, "--all-namespaces")
+		if errors.Is(err, errForbidden) {
+			return nil, nil
+		}
+		return objects, err
+	}
+
+	var objects []objectMeta
+	for _, ns := range allowed {
+		nsObjects, err := k.listObjectsIn(resource, "--namespace", ns)
+		if err != nil {
+			if errors.Is(err, errForbidden) {
+				continue
+			}
+			return nil, err
+		}
+		objects = append(objects, nsObjects...)
+	}
+
+	return objects, nil
+}
+
+// errForbidden is returned by listObjectsIn when the user is not allowed to list the resource.
+var errForbidden = errors.New("forbidden")
+
+// listObjectsIn runs `kubectl get <resource>` with the given scope arguments.
+func (k *KubernetesConnectionImpl) listObjectsIn(resource string, scope ...string) ([]objectMeta, error) {
+	args := append([]string{"get", resource}, scope...)
+	args = append(args, "-o", "json")
+
+	cmd := exec.Command("kubectl", args...)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
-		return nil, fmt.Errorf("failed to list pods: %v. Output: %s", err, string(output))
+		if isForbidden(output) {
+			return nil, fmt.Errorf("failed to list %s: %w", resource, errForbidden)
+		}
+		return nil, fmt.Errorf("failed to list %s: %v. Output: %s", resource, err, string(output))
 	}
 
-	var podList struct {
+	var list struct {
 		Items []struct {
-			Metadata struct {
-				Name      string `json:"name"`
-				Namespace string `json:"namespace"`
-			} `json:"metadata"`
+			Metadata objectMeta `json:"metadata"`
 		} `json:"items"`
 	}
 
-	if err := json.Unmarshal(output, &podList); err != nil {
-		return nil, fmt.Errorf("failed to parse pods: %v. Output: %s", err, string(output))
+	if err := json.Unmarshal(output, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v. Output: %s", resource, err, string(output))
 	}
 
-	var pods []Pod
-	for _, item := range podList.Items {
-		pods = append(pods, Pod{
-			Name:      item.Metadata.Name,
-			Namespace: item.Metadata.Namespace,
-		})
+	var objects []objectMeta
+	for _, item := range list.Items {
+		objects = append(objects, item.Metadata)
 	}
 
-	return pods, nil
+	return objects, nil
 }
 
-// getDeployments retrieves all deployments across all namespaces
-func (k *KubernetesConnectionImpl) getDeployments() ([]Deployment, error) {
-	cmd := exec.Command("kubectl", "get", "deployments", "--all-namespaces", "-o", "json")
-	output, err := cmd.CombinedOutput()
+// getPods retrieves all pods across the allowed namespaces
+func (k *KubernetesConnectionImpl) getPods() ([]Pod, error) {
+	objects, err := k.listObjects("pods")
 	if err != nil {
-		return nil, fmt.Errorf("failed to list deployments: %v. Output: %s", err, string(output))
+		return nil, err
 	}
 
-	var depList struct {
-		Items []struct {
-			Metadata struct {
-				Name      string `json:"name"`
-				Namespace string `json:"namespace"`
-			} `json:"metadata"`
-		} `json:"items"`
+	var pods []Pod
+	for _, object := range objects {
+		pods = append(pods, Pod(object))
 	}
 
-	if err := json.Unmarshal(output, &depList); err != nil {
-		return nil, fmt.Errorf("failed to parse deployments: %v. Output: %s", err, string(output))
+	return pods, nil
+}
+
+// getDeployments retrieves all deployments across the allowed namespaces
+func (k *KubernetesConnectionImpl) getDeployments() ([]Deployment, error) {
+	objects, err := k.listObjects("deployments")
+	if err != nil {
+		return nil, err
 	}
 
 	var deployments []Deployment
-	for _, item := range depList.Items {
-		deployments = append(deployments, Deployment{
-			Name:      item.Metadata.Name,
-			Namespace: item.Metadata.Namespace,
-		})
+	for _, object := range objects {
+		deployments = append(deployments, Deployment(object))
 	}
 
 	return deployments, nil
 }
 
-// getServices retrieves all services across all namespaces
+// getServices retrieves all services across the allowed namespaces
 func (k *KubernetesConnectionImpl) getServices() ([]Service, error) {
-	cmd := exec.Command("kubectl", "get", "services", "--all-namespaces", "-o", "json")
-	output, err := cmd.CombinedOutput()
+	objects, err := k.listObjects("services")
 	if err != nil {
-		return nil, fmt.Errorf("failed to list services: %v. Output: %s", err, string(output))
+		return nil, err
 	}
 
-	var svcList struct {
-		Items []struct {
-			Metadata struct {
-				Name      string `json:"name"`
-				Namespace string `json:"namespace"`
-			} `json:"metadata"`
-		} `json:"items"`
+	var services []Service
+	for _, object := range objects {
+		services = append(services, Service(object))
+	}
+
+	return services, nil
+}
+
+// kubectlPreviewVerbs are the kubectl commands that accept --dry-run=server.
+// The ones mapped to true print the changed objects; the others only print a message.
+var kubectlPreviewVerbs = map[string]bool{
+	"annotate":  true,
+	"apply":     true,
+	"autoscale": true,
+	"create":    true,
+	"expose":    true,
+	"label":     true,
+	"patch":     true,
+	"replace":   true,
+	"run":       true,
+	"scale":     true,
+	"set":       true,
+	"taint":     true,
+	"cordon":    false,
+	"delete":    false,
+	"drain":     false,
+	"uncordon":  false,
+}
+
+// kubectlGlobalFlags are the kubectl flags that select the cluster and take a value.
+var kubectlGlobalFlags = map[string]bool{
+	"--context":    true,
+	"--kubeconfig": true,
+	"--cluster":    true,
+	"--user":       true,
+	"--server":     true,
+	"-s":           true,
+}
+
+// kubectlGlobalValueFlags are the other kubectl flags that can come before the command and take a value.
+var kubectlGlobalValueFlags = map[string]bool{
+	"-n":                      true,
+	"--namespace":             true,
+	"--request-timeout":       true,
+	"--token":                 true,
+	"--as":                    true,
+	"--as-group":              true,
+	"--as-uid":                true,
+	"--certificate-authority": true,
+	"--client-certificate":    true,
+	"--client-key":            true,
+	"--tls-server-name":       true,
+	"--cache-dir":             true,
+	"-v":                      true,
+	"--v":                     true,
+}
+
+// kubectlGlobalBoolFlags are the kubectl flags that can come before the command and take no value.
+var kubectlGlobalBoolFlags = map[string]bool{
+	"--insecure-skip-tls-verify": true,
+	"--match-server-version":     true,
+	"--warnings-as-errors":       true,
+	"--disable-compression":      true,
+}
+
+// kubectlStrictVerb returns the kubectl command like kubectlVerb, but fails on flags before it
+// that it doesn't know, as it can't tell whether they take a value that would be mistaken for the command.
+func kubectlStrictVerb(args []string) (string, error) {
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") {
+			return arg, nil
+		}
+
+		flag, _, hasValue := strings.Cut(arg, "=")
+		switch {
+		case kubectlGlobalFlags[flag] || kubectlGlobalValueFlags[flag]:
+			if !hasValue {
+				i++
+			}
+		case kubectlGlobalBoolFlags[flag]:
+		default:
+			return "", fmt.Errorf("unknown flag %s before the command", arg)
+		}
 	}
+	return "", fmt.Errorf("no command")
+}
 
-	if err := json.Unmarshal(output, &svcList); err != nil {
-		return nil, fmt.Errorf("failed to parse services: %v. Output: %s", err, string(output))
+// PreviewCommand runs the kubectl command with --dry-run=server and diffs the resulting objects against the live ones.
+func (k *KubernetesConnectionImpl) PreviewCommand(command string) (string, error) {
+	if err := k.CheckReadOnly(command); err != nil {
+		return "", err
 	}
 
-	var services []Service
-	for _, item := range svcList.Items {
-		services = append(services, Service{
-			Name:      item.Metadata.Name,
-			Namespace: item.Metadata.Namespace,
-		})
+	args, err := previewArgs(k.Connection, k.AllowedExecutables(), command)
+	if err != nil {
+		return "", err
 	}
 
-	return services, nil
+	verb := kubectlVerb(args)
+	printsObjects, ok := kubectlPreviewVerbs[verb]
+	if !ok {
+		return "", previewNotSupported(fmt.Sprintf("`kubectl %s` can't be run as a dry run", verb))
+	}
+
+	output, err := runPreview(kubectlDryRunArgs(args, printsObjects))
+	if err != nil {
+		return "", fmt.Errorf("dry run failed: %v", err)
+	}
+	if !printsObjects {
+		return string(output), nil
+	}
+
+	objects, err := kubernetesObjects(output)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse dry run output: %v", err)
+	}
+
+	var buffer bytes.Buffer
+	for i, object := range objects {
+		if i == PreviewSampleSize {
+			fmt.Fprintf(&buffer, "... and %d more objects\n", len(objects)-i)
+			break
+		}
+
+		diff, err := k.diffLiveObject(args, object)
+		if err != nil {
+			return "", err
+		}
+		buffer.WriteString(diff)
+	}
+	return buffer.String(), nil
+}
+
+// diffLiveObject diffs an object returned by a dry run against the live object, if there is one.
+func (k *KubernetesConnectionImpl) diffLiveObject(args []string, object map[string]interface{}) (string, error) {
+	kind, _ := object["kind"].(string)
+	apiVersion, _ := object["apiVersion"].(string)
+	metadata, _ := object["metadata"].(map[string]interface{})
+	name, _ := metadata["name"].(string)
+	namespace, _ := metadata["namespace"].(string)
+
+	resource := strings.ToLower(kind)
+	if group, _, found := strings.Cut(apiVersion, "/"); found {
+		resource += "." + group
+	}
+
+	getArgs := append([]string{"kubectl", "get", resource, name, "-o", "yaml"}, kubectlGlobalArgs(args)...)
+	if namespace != "" {
+		getArgs = append(getArgs, "--namespace", namespace)
+	}
+
+	title := fmt.Sprintf("%s/%s", kind, name)
+	if namespace != "" {
+		title = fmt.Sprintf("%s/%s (namespace %s)", kind, name, namespace)
+	}
+
+	var live string
+	output, err := runPreview(getArgs)
+	if err != nil {
+		if !strings.Contains(err.Error(), "NotFound") && !strings.Contains(err.Error(), "not found") {
+			return "", fmt.Errorf("failed to get %s: %v", title, err)
+		}
+		title += " would be created"
+	} else {
+		liveObjects, err := kubernetesObjects(output)
+		if err != nil || len(liveObjects) != 1 {
+			return "", fmt.Errorf("failed to parse %s: %v", title, err)
+		}
+		if live, err = kubernetesObjectYAML(liveObjects[0]); err != nil {
+			return "", err
+		}
+	}
+
+	changed, err := kubernetesObjectYAML(object)
+	if err != nil {
+		return "", err
+	}
+
+	diff := DiffLines(live, changed)
+	if diff == "" {
+		return title + ": no changes\n\n", nil
+	}
+	return title + "\n" + diff + "\n", nil
+}
+
+// kubectlValueFlags are common kubectl flags that take a value, besides kubectlGlobalFlags.
+var kubectlValueFlags = map[string]bool{
+	"-n":               true,
+	"--namespace":      true,
+	"-l":               true,
+	"--selector":       true,
+	"-f":               true,
+	"--filename":       true,
+	"-k":               true,
+	"--kustomize":      true,
+	"-o":               true,
+	"--output":         true,
+	"--field-selector": true,
+	"--grace-period":   true,
+	"--timeout":        true,
+	"-c":               true,
+	"--container":      true,
+}
+
+// kubectlVerb returns the kubectl command, like "apply", skipping flags before it.
+func kubectlVerb(args []string) string {
+	if positional := kubectlPositionalArgs(args); len(positional) > 0 {
+		return positional[0]
+	}
+	return ""
+}
+
+// kubectlPositionalArgs returns the arguments of a kubectl command that are not flags or flag values,
+// e.g. "delete", "ns" and "payments" for `kubectl delete ns payments --wait=false`.
+// Arguments after "--" are left out, as they are the command run by `kubectl exec` or `kubectl run`.
+func kubectlPositionalArgs(args []string) []string {
+	var positional []string
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			break
+		}
+		if !strings.HasPrefix(arg, "-") {
+			positional = append(positional, arg)
+			continue
+		}
+		if !strings.Contains(arg, "=") && (kubectlGlobalFlags[arg] || kubectlGlobalValueFlags[arg] || kubectlValueFlags[arg]) {
+			i++
+		}
+	}
+	return positional
+}
+
+// readKubectlVerbs are the kubectl commands that only read.
+var readKubectlVerbs = []string{
+	"get", "describe", "logs", "top", "explain", "api-resources", "api-versions",
+	"version", "cluster-info", "diff", "events", "wait",
+}
+
+// readKubectlAuthCommands are the `kubectl auth` commands that only read; `kubectl auth reconcile` changes RBAC objects.
+var readKubectlAuthCommands = []string{"can-i", "whoami"}
+
+// ClassifyCommand rates every kubectl command of a pipeline and returns the riskiest one.
+// Deleting objects and draining nodes is destructive; for a single deleted object its name has to be typed to confirm.
+func (k *KubernetesConnectionImpl) ClassifyCommand(command string) RiskAssessment {
+	stages, err := ParsePipeline(command)
+	if err != nil {
+		return riskOf(k.Connection, RiskWrite, "the command can't be parsed", "")
+	}
+
+	assessment := riskOf(k.Connection, RiskRead, "the command only reads", "")
+	for _, stage := range stages {
+		if stage[0] != "kubectl" {
+			assessment = maxRisk(assessment, classifyOtherTool(k.Connection, stage))
+			continue
+		}
+
+		positional := kubectlPositionalArgs(stage)
+		verb := ""
+		if len(positional) > 0 {
+			verb = positional[0]
+		}
+
+		var stageAssessment RiskAssessment
+		switch {
+		case containsFold(readKubectlVerbs, verb):
+			stageAssessment = riskOf(k.Connection, RiskRead, fmt.Sprintf("`kubectl %s` only reads", verb), "")
+		case verb == "auth" && len(positional) > 1 && containsFold(readKubectlAuthCommands, positional[1]):
+			stageAssessment = riskOf(k.Connection, RiskRead, fmt.Sprintf("`kubectl auth %s` only reads", positional[1]), "")
+		case verb == "delete" || verb == "drain":
+			target := ""
+			if names := kubectlObjectNames(positional[1:]); len(names) == 1 {
+				target = names[0]
+			}
+			reason := "`kubectl delete` deletes the objects"
+			if verb == "drain" {
+				reason = "`kubectl drain` evicts every pod from the node"
+			} else if len(positional) > 1 && (positional[1] == "ns" || strings.HasPrefix(positional[1], "namespace")) {
+				reason = "deleting a namespace deletes every object in it"
+			}
+			stageAssessment = riskOf(k.Connection, RiskDestructive, reason, target)
+		case verb == "replace" && (containsFold(stage, "--force") || containsFold(stage, "--force=true")):
+			stageAssessment = riskOf(k.Connection, RiskDestructive, "`kubectl replace --force` deletes and recreates the objects", "")
+		case verb == "exec":
+			stageAssessment = riskOf(k.Connection, RiskWrite, "`kubectl exec` runs a command in a container", "")
+		default:
+			stageAssessment = riskOf(k.Connection, RiskWrite, fmt.Sprintf("`kubectl %s` changes the cluster", verb), "")
+		}
+		assessment = maxRisk(assessment, stageAssessment)
+	}
+	return assessment
+}
+
+// kubectlObjectNames returns the object names given to a command, like ["api"] for "deployment api" or "deployment/api".
+func kubectlObjectNames(args []string) []string {
+	var names []string
+	for i, arg := range args {
+		if _, name, found := strings.Cut(arg, "/"); found {
+			names = append(names, name)
+		} else if i > 0 {
+			names = append(names, arg)
+		}
+	}
+	return names
+}
+
+// kubectlGlobalArgs returns the flags that select the cluster, so related commands run against the same one.
+func kubectlGlobalArgs(args []string) []string {
+	var global []string
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+		if flag, _, found := strings.Cut(arg, "="); found && kubectlGlobalFlags[flag] {
+			global = append(global, arg)
+		} else if kubectlGlobalFlags[arg] && i+1 < len(args) {
+			global = append(global, arg, args[i+1])
+			i++
+		}
+	}
+	return global
+}
+
+// kubectlDryRunArgs replaces the dry run and, if the command prints objects, the output flags of a kubectl command,
+// so it runs as a server side dry run and prints the objects as YAML.
+func kubectlDryRunArgs(args []string, printsObjects bool) []string {
+	dryRun := []string{args[0]}
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--dry-run" || strings.HasPrefix(arg, "--dry-run="):
+			continue
+		case printsObjects && (arg == "-o" || arg == "--output"):
+			i++
+			continue
+		case printsObjects && (strings.HasPrefix(arg, "-o=") || strings.HasPrefix(arg, "--output=") || (strings.HasPrefix(arg, "-o") && len(arg) > 2)):
+			continue
+		}
+		dryRun = append(dryRun, arg)
+	}
+
+	dryRun = append(dryRun, "--dry-run=server")
+	if printsObjects {
+		dryRun = append(dryRun, "-o", "yaml")
+	}
+	return dryRun
+}
+
+// kubernetesObjects parses a Kubernetes object, or the items of a list, from YAML.
+func kubernetesObjects(data []byte) ([]map[string]interface{}, error) {
+	var object map[string]interface{}
+	if err := yaml.Unmarshal(data, &object); err != nil {
+		return nil, err
+	}
+	if object == nil {
+		return nil, nil
+	}
+
+	items, isList := object["items"].([]interface{})
+	if kind, _ := object["kind"].(string); !isList || !strings.HasSuffix(kind, "List") {
+		return []map[string]interface{}{object}, nil
+	}
+
+	objects := make([]map[string]interface{}, 0, len(items))
+	for _, item := range items {
+		if itemObject, ok := item.(map[string]interface{}); ok {
+			objects = append(objects, itemObject)
+		}
+	}
+	return objects, nil
+}
+
+// kubernetesServerFields are the metadata fields set by the API server, which are left out of diffs.
+var kubernetesServerFields = []string{"managedFields", "resourceVersion", "generation", "uid", "creationTimestamp"}
+
+// kubernetesObjectYAML returns the object as YAML without the status and the fields set by the API server.
+func kubernetesObjectYAML(object map[string]interface{}) (string, error) {
+	cleaned := make(map[string]interface{}, len(object))
+	for key, value := range object {
+		if key != "status" {
+			cleaned[key] = value
+		}
+	}
+
+	if metadata, ok := object["metadata"].(map[string]interface{}); ok {
+		cleanedMetadata := make(map[string]interface{}, len(metadata))
+		for key, value := range metadata {
+			cleanedMetadata[key] = value
+		}
+		for _, field := range kubernetesServerFields {
+			delete(cleanedMetadata, field)
+		}
+
+		if annotations, ok := metadata["annotations"].(map[string]interface{}); ok {
+			cleanedAnnotations := make(map[string]interface{}, len(annotations))
+			for key, value := range annotations {
+				if key != "kubectl.kubernetes.io/last-applied-configuration" {
+					cleanedAnnotations[key] = value
+				}
+			}
+			if len(cleanedAnnotations) == 0 {
+				delete(cleanedMetadata, "annotations")
+			} else {
+				cleanedMetadata["annotations"] = cleanedAnnotations
+			}
+		}
+		cleaned["metadata"] = cleanedMetadata
+	}
+
+	data, err := yaml.Marshal(cleaned)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
 }
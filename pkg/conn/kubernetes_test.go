@@ -7,8 +7,9 @@ import (
 
 func TestNewKubernetesConnection(t *testing.T) {
 	type args struct {
-		name    string
-		context string
+		name       string
+		context    string
+		namespaces []string
 	}
 	tests := []struct {
 		name string
@@ -31,10 +32,28 @@ func TestNewKubernetesConnection(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "Test NewKubernetesConnection with namespaces",
+			args: args{
+				name:       "test",
+				context:    "test-context",
+				namespaces: []string{"dev", "staging"},
+			},
+			want: Connection{
+				Name: "test",
+				Type: KubernetesConnectionType{
+					MainType: ConnectionTypeKubernetes,
+				},
+				Details: KubernetesConnectionDetails{
+					SelectedContext: "test-context",
+					Namespaces:      []string{"dev", "staging"},
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := NewKubernetesConnection(tt.args.name, tt.args.context); !reflect.DeepEqual(got, tt.want) {
+			if got := NewKubernetesConnection(tt.args.name, tt.args.context, tt.args.namespaces...); !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("NewKubernetesConnection() = %v, want %v", got, tt.want)
 			}
 		})
@@ -113,3 +132,84 @@ func TestGetKubernetesConnectionDetails(t *testing.T) {
 		})
 	}
 }
+
+func TestKubectlDryRunArgs(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          []string
+		printsObjects bool
+		wantVerb      string
+		want          []string
+	}{
+		{
+			name:          "Test kubectlDryRunArgs prints objects as YAML",
+			args:          []string{"kubectl", "-n", "prod", "scale", "deployment/api", "--replicas=3", "-o", "name"},
+			printsObjects: true,
+			wantVerb:      "scale",
+			want:          []string{"kubectl", "-n", "prod", "scale", "deployment/api", "--replicas=3", "--dry-run=server", "-o", "yaml"},
+		},
+		{
+			name:          "Test kubectlDryRunArgs replaces the dry run",
+			args:          []string{"kubectl", "apply", "-f", "app.yaml", "--dry-run=client", "-ojson"},
+			printsObjects: true,
+			wantVerb:      "apply",
+			want:          []string{"kubectl", "apply", "-f", "app.yaml", "--dry-run=server", "-o", "yaml"},
+		},
+		{
+			name:     "Test kubectlDryRunArgs keeps the output of deletes",
+			args:     []string{"kubectl", "--context", "dev", "delete", "pod", "api", "-o", "name"},
+			wantVerb: "delete",
+			want:     []string{"kubectl", "--context", "dev", "delete", "pod", "api", "-o", "name", "--dry-run=server"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := kubectlVerb(tt.args); got != tt.wantVerb {
+				t.Errorf("kubectlVerb() = %v, want %v", got, tt.wantVerb)
+			}
+			if got := kubectlDryRunArgs(tt.args, tt.printsObjects); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("kubectlDryRunArgs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKubernetesObjectYAML(t *testing.T) {
+	objects, err := kubernetesObjects([]byte(`apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: settings
+    namespace: prod
+    uid: 1234
+    resourceVersion: "42"
+    annotations:
+      kubectl.kubernetes.io/last-applied-configuration: "{}"
+  data:
+    mode: fast
+`))
+	if err != nil {
+		t.Fatalf("kubernetesObjects() error = %v", err)
+	}
+	if len(objects) != 1 {
+		t.Fatalf("kubernetesObjects() returned %d objects, want 1", len(objects))
+	}
+
+	got, err := kubernetesObjectYAML(objects[0])
+	if err != nil {
+		t.Fatalf("kubernetesObjectYAML() error = %v", err)
+	}
+	want := `apiVersion: v1
+data:
+    mode: fast
+kind: ConfigMap
+metadata:
+    name: settings
+    namespace: prod
+`
+	if got != want {
+		t.Errorf("kubernetesObjectYAML() = %q, want %q", got, want)
+	}
+}
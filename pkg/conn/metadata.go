@@ -0,0 +1,158 @@
+package conn
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Environments of connections.
+const (
+	EnvironmentDev     = "dev"
+	EnvironmentStaging = "staging"
+	EnvironmentProd    = "prod"
+)
+
+// Environments are the environments a connection can be in.
+var Environments = []string{EnvironmentDev, EnvironmentStaging, EnvironmentProd}
+
+// ParseEnvironment returns the environment, in lower case. An empty environment is allowed and means none.
+func ParseEnvironment(environment string) (string, error) {
+	environment = strings.ToLower(strings.TrimSpace(environment))
+	if environment == "" || containsFold(Environments, environment) {
+		return environment, nil
+	}
+	return "", fmt.Errorf("unknown environment '%s'; use one of %s", environment, strings.Join(Environments, ", "))
+}
+
+// IsProduction reports whether the connection is in the prod environment.
+func (c Connection) IsProduction() bool {
+	return c.Environment == EnvironmentProd
+}
+
+// labelPattern matches label keys and values: letters, digits, '.', '_', '-' and, in keys, '/',
+// starting and ending with a letter or a digit.
+var labelPattern = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9._/-]{0,61}[A-Za-z0-9])?$`)
+
+// ValidateLabel checks the key and value of a label. Values can be empty.
+func ValidateLabel(key, value string) error {
+	if !labelPattern.MatchString(key) {
+		return fmt.Errorf("invalid label key '%s': use up to 63 letters, digits, '.', '_', '-' or '/', starting and ending with a letter or digit", key)
+	}
+	if value != "" && (strings.Contains(value, "/") || !labelPattern.MatchString(value)) {
+		return fmt.Errorf("invalid value '%s' of label '%s': use up to 63 letters, digits, '.', '_' or '-', starting and ending with a letter or digit", value, key)
+	}
+	return nil
+}
+
+// ParseLabels parses labels given as key=value.
+func ParseLabels(labels []string) (map[string]string, error) {
+	parsed := map[string]string{}
+	for _, label := range labels {
+		key, value, ok := strings.Cut(label, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid label '%s': use key=value", label)
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if err := ValidateLabel(key, value); err != nil {
+			return nil, err
+		}
+		parsed[key] = value
+	}
+	return parsed, nil
+}
+
+// FormatLabels returns the labels as key=value, sorted by key and separated by commas.
+func FormatLabels(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, key := range keys {
+		pairs[i] = key + "=" + labels[key]
+	}
+	return strings.Join(pairs, ",")
+}
+
+// requirement is a condition on one label of a selector.
+type requirement struct {
+	key      string
+	value    string
+	operator string
+}
+
+// Selector selects connections by their labels, like "team=payments,tier!=db,critical,!deprecated".
+// All requirements must match.
+type Selector []requirement
+
+// Selector operators.
+const (
+	opEquals    = "="
+	opNotEquals = "!="
+	opExists    = "exists"
+	opNotExists = "!exists"
+)
+
+// ParseSelector parses a selector: comma separated requirements that are key=value (or key==value),
+// key!=value, key for labels that are set, or !key for labels that aren't set. An empty selector selects everything.
+func ParseSelector(selector string) (Selector, error) {
+	var parsed Selector
+	if strings.TrimSpace(selector) == "" {
+		return parsed, nil
+	}
+
+	for _, part := range strings.Split(selector, ",") {
+		part = strings.TrimSpace(part)
+
+		var r requirement
+		switch {
+		case strings.Contains(part, "!="):
+			key, value, _ := strings.Cut(part, "!=")
+			r = requirement{key: strings.TrimSpace(key), value: strings.TrimSpace(value), operator: opNotEquals}
+		case strings.Contains(part, "="):
+			key, value, _ := strings.Cut(part, "=")
+			value = strings.TrimPrefix(value, "=")
+			r = requirement{key: strings.TrimSpace(key), value: strings.TrimSpace(value), operator: opEquals}
+		case strings.HasPrefix(part, "!"):
+			r = requirement{key: strings.TrimSpace(part[1:]), operator: opNotExists}
+		default:
+			r = requirement{key: part, operator: opExists}
+		}
+
+		if err := ValidateLabel(r.key, r.value); err != nil {
+			return nil, fmt.Errorf("invalid selector '%s': %w", selector, err)
+		}
+		parsed = append(parsed, r)
+	}
+	return parsed, nil
+}
+
+// Matches reports whether the labels match all requirements of the selector.
+func (s Selector) Matches(labels map[string]string) bool {
+	for _, r := range s {
+		value, ok := labels[r.key]
+		switch r.operator {
+		case opEquals:
+			if !ok || value != r.value {
+				return false
+			}
+		case opNotEquals:
+			if ok && value == r.value {
+				return false
+			}
+		case opExists:
+			if !ok {
+				return false
+			}
+		case opNotExists:
+			if ok {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,123 @@
+package conn
+
+import "testing"
+
+func TestSelector_Matches(t *testing.T) {
+	labels := map[string]string{"team": "payments", "tier": "db", "critical": ""}
+
+	tests := []struct {
+		name     string
+		selector string
+		want     bool
+		wantErr  bool
+	}{
+		{
+			name:     "Test Matches with an empty selector",
+			selector: "",
+			want:     true,
+		},
+		{
+			name:     "Test Matches with equality",
+			selector: "team=payments",
+			want:     true,
+		},
+		{
+			name:     "Test Matches with == and spaces",
+			selector: " team == payments , tier=db",
+			want:     true,
+		},
+		{
+			name:     "Test Matches fails with a different value",
+			selector: "team=payments,tier=api",
+			want:     false,
+		},
+		{
+			name:     "Test Matches with inequality",
+			selector: "tier!=api",
+			want:     true,
+		},
+		{
+			name:     "Test Matches with inequality of a missing label",
+			selector: "region!=eu",
+			want:     true,
+		},
+		{
+			name:     "Test Matches with existence",
+			selector: "critical,team",
+			want:     true,
+		},
+		{
+			name:     "Test Matches with non-existence",
+			selector: "!deprecated,!critical",
+			want:     false,
+		},
+		{
+			name:     "Test Matches rejects invalid keys",
+			selector: "team name=payments",
+			wantErr:  true,
+		},
+		{
+			name:     "Test Matches rejects empty requirements",
+			selector: "team=payments,",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selector, err := ParseSelector(tt.selector)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSelector() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if got := selector.Matches(labels); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLabels(t *testing.T) {
+	tests := []struct {
+		name    string
+		labels  []string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "Test ParseLabels",
+			labels: []string{"tier=db", "team=payments", "critical="},
+			want:   "critical=,team=payments,tier=db",
+		},
+		{
+			name:   "Test ParseLabels with a prefixed key",
+			labels: []string{"example.com/owner=sre"},
+			want:   "example.com/owner=sre",
+		},
+		{
+			name:    "Test ParseLabels without a value",
+			labels:  []string{"team"},
+			wantErr: true,
+		},
+		{
+			name:    "Test ParseLabels with an invalid value",
+			labels:  []string{"team=pay ments"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLabels(tt.labels)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseLabels() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if FormatLabels(got) != tt.want {
+				t.Errorf("ParseLabels() = %s, want %s", FormatLabels(got), tt.want)
+			}
+		})
+	}
+}
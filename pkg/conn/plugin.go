@@ -0,0 +1,721 @@
+package conn
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prompt-ops/pops/pkg/secret"
+)
+
+const (
+	// ConnectionTypePlugin is the main type of connections served by external plugins.
+	ConnectionTypePlugin = "Plugin"
+
+	// PluginExecutablePrefix is the prefix of plugin executables on PATH.
+	// A plugin for the "deploy" subtype is an executable named "pops-plugin-deploy".
+	PluginExecutablePrefix = "pops-plugin-"
+
+	// PluginProtocolVersion is the version of the plugin protocol sent with the Initialize call.
+	PluginProtocolVersion = "1"
+)
+
+func init() {
+	RegisterConnectionType(ConnectionTypeRegistration{
+		MainType:      ConnectionTypePlugin,
+		DecodeType:    DecodeTypeAs[PluginConnectionType](),
+		DecodeDetails: DecodeDetailsAs[PluginConnectionDetails](),
+		New: func(connection Connection) (ConnectionInterface, error) {
+			return NewPluginConnectionImpl(&connection)
+		},
+	})
+}
+
+// AvailablePluginConnectionType is a helper struct to UI to list the plugins found on PATH.
+// Subtype will be shown in the UI.
+type AvailablePluginConnectionType struct {
+	Subtype string
+	Path    string
+}
+
+// DiscoverPlugins finds the plugin executables on PATH.
+// If the same plugin is found in several directories, the first one on PATH wins.
+func DiscoverPlugins() []AvailablePluginConnectionType {
+	found := map[string]AvailablePluginConnectionType{}
+
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+
+		for _, entry := range entries {
+			name := entry.Name()
+			if entry.IsDir() || !strings.HasPrefix(name, PluginExecutablePrefix) {
+				continue
+			}
+			if runtime.GOOS == "windows" {
+				name = strings.TrimSuffix(name, filepath.Ext(name))
+			}
+
+			subtype := strings.TrimPrefix(name, PluginExecutablePrefix)
+			if subtype == "" {
+				continue
+			}
+			if _, exists := found[subtype]; exists {
+				continue
+			}
+
+			path := filepath.Join(dir, entry.Name())
+			if !isExecutable(path) {
+				continue
+			}
+			found[subtype] = AvailablePluginConnectionType{
+				Subtype: subtype,
+				Path:    path,
+			}
+		}
+	}
+
+	plugins := make([]AvailablePluginConnectionType, 0, len(found))
+	for _, plugin := range found {
+		plugins = append(plugins, plugin)
+	}
+	sort.Slice(plugins, func(i, j int) bool {
+		return plugins[i].Subtype < plugins[j].Subtype
+	})
+
+	return plugins
+}
+
+// isExecutable checks whether the file at path can be executed.
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		return true
+	}
+	return info.Mode()&0111 != 0
+}
+
+type PluginConnectionType struct {
+	// MainType of the connection type.
+	// Example: "Plugin".
+	MainType string `json:"mainType"`
+
+	// Subtype is the name of the plugin.
+	// Example: "deploy" for the "pops-plugin-deploy" executable.
+	Subtype string `json:"subtype"`
+}
+
+func (p PluginConnectionType) GetMainType() string {
+	return ConnectionTypePlugin
+}
+
+func (p PluginConnectionType) GetSubtype() string {
+	return p.Subtype
+}
+
+type PluginConnectionDetails struct {
+	// Settings are free-form settings passed to the plugin on Initialize.
+	Settings map[string]string `json:"settings,omitempty"`
+}
+
+func (p PluginConnectionDetails) GetDriver() string {
+	return ""
+}
+
+// NewPluginConnection creates a new plugin connection.
+func NewPluginConnection(name string, plugin AvailablePluginConnectionType, settings map[string]string) Connection {
+	return Connection{
+		Name: name,
+		Type: PluginConnectionType{
+			MainType: ConnectionTypePlugin,
+			Subtype:  plugin.Subtype,
+		},
+		Details: PluginConnectionDetails{
+			Settings: settings,
+		},
+	}
+}
+
+// GetPluginConnectionDetails retrieves the PluginConnectionDetails from a Connection.
+func GetPluginConnectionDetails(conn Connection) (PluginConnectionDetails, error) {
+	if conn.Type.GetMainType() != ConnectionTypePlugin {
+		return PluginConnectionDetails{}, fmt.Errorf("connection is not of type 'plugin'")
+	}
+	details, ok := conn.Details.(PluginConnectionDetails)
+	if !ok {
+		return PluginConnectionDetails{}, fmt.Errorf("invalid connection details for 'plugin'")
+	}
+	return details, nil
+}
+
+// PluginConnection is the implementation of the ConnectionInterface for external plugins.
+// The plugin process is started on the first call and serves JSON-RPC 2.0 requests,
+// one JSON object per line, on its stdin and stdout.
+type PluginConnection struct {
+	Connection Connection
+
+	// path is the plugin executable.
+	path string
+
+	mu          sync.Mutex
+	cmd         *exec.Cmd
+	stdin       io.WriteCloser
+	stdout      *bufio.Reader
+	stderr      *tailBuffer
+	nextID      int
+	commandType string
+	context     *string
+}
+
+var _ ConnectionInterface = &PluginConnection{}
+
+// NewPluginConnectionImpl creates the plugin implementation for the connection.
+// It fails if the plugin executable can't be found on PATH.
+func NewPluginConnectionImpl(connection *Connection) (*PluginConnection, error) {
+	path, err := exec.LookPath(PluginExecutablePrefix + connection.Type.GetSubtype())
+	if err != nil {
+		return nil, fmt.Errorf("plugin '%s' is not installed: %v", connection.Type.GetSubtype(), err)
+	}
+
+	return &PluginConnection{
+		Connection: *connection,
+		path:       path,
+	}, nil
+}
+
+// pluginRequest is a JSON-RPC 2.0 request.
+type pluginRequest struct {
+	JSONRPC string      `json:"jsonrpc"`
+	ID      int         `json:"id"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
+// pluginResponse is a JSON-RPC 2.0 response.
+type pluginResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      int             `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *PluginError    `json:"error,omitempty"`
+}
+
+// PluginError is a JSON-RPC 2.0 error returned by a plugin.
+type PluginError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *PluginError) Error() string {
+	return fmt.Sprintf("plugin error %d: %s", e.Code, e.Message)
+}
+
+// PluginInitializeParams are the parameters of the Initialize call.
+type PluginInitializeParams struct {
+	ProtocolVersion string            `json:"protocolVersion"`
+	Name            string            `json:"name"`
+	Settings        map[string]string `json:"settings,omitempty"`
+
+	// ReadOnly tells the plugin to reject commands that could change anything.
+	ReadOnly bool `json:"readOnly,omitempty"`
+}
+
+// PluginInitializeResult is the result of the Initialize call.
+type PluginInitializeResult struct {
+	// CommandType is the kind of command the AI should generate.
+	// Example: "deployctl command".
+	CommandType string `json:"commandType"`
+
+	// ReadOnly acknowledges that the plugin rejects commands that could change anything.
+	// Plugins asked to be read-only that don't acknowledge it are not used.
+	ReadOnly bool `json:"readOnly,omitempty"`
+}
+
+// pluginCallTimeout is how long a plugin may take to read a request and answer it.
+var pluginCallTimeout = 5 * time.Minute
+
+// pluginStopTimeout is how long a plugin may take to exit once its stdin is closed before it is killed.
+var pluginStopTimeout = 5 * time.Second
+
+// pluginStderrLimit is how many of the last bytes the plugin wrote to stderr are kept for error messages.
+const pluginStderrLimit = 4096
+
+// tailBuffer keeps the last bytes written to it, up to its limit.
+type tailBuffer struct {
+	mu    sync.Mutex
+	data  []byte
+	limit int
+}
+
+func (b *tailBuffer) Write(data []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.data = append(b.data, data...)
+	if len(b.data) > b.limit {
+		b.data = b.data[len(b.data)-b.limit:]
+	}
+	return len(data), nil
+}
+
+func (b *tailBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return string(b.data)
+}
+
+// start starts the plugin process and initializes it. The caller must hold p.mu.
+func (p *PluginConnection) start() error {
+	settings, err := p.resolveSettings()
+	if err != nil {
+		return err
+	}
+
+	// Stderr is kept for error messages, as writing it to the terminal would garble the shell.
+	stderr := &tailBuffer{limit: pluginStderrLimit}
+	cmd := exec.Command(p.path)
+	cmd.Stderr = stderr
+	cmd.WaitDelay = time.Second
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("failed to open plugin stdin: %v", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to open plugin stdout: %v", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start plugin '%s': %v", p.path, err)
+	}
+
+	p.cmd = cmd
+	p.stdin = stdin
+	p.stdout = bufio.NewReader(stdout)
+	p.stderr = stderr
+
+	var result PluginInitializeResult
+	err = p.roundTrip("Initialize", PluginInitializeParams{
+		ProtocolVersion: PluginProtocolVersion,
+		Name:            p.Connection.Name,
+		Settings:        settings,
+		ReadOnly:        p.Connection.ReadOnly,
+	}, &result)
+	if err != nil {
+		p.stopLocked()
+		return fmt.Errorf("failed to initialize plugin: %v", err)
+	}
+	if p.Connection.ReadOnly && !result.ReadOnly {
+		p.stopLocked()
+		return fmt.Errorf("plugin '%s' doesn't support read-only connections", p.path)
+	}
+	p.commandType = result.CommandType
+
+	return nil
+}
+
+// Close stops the plugin process.
+func (p *PluginConnection) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.stopLocked()
+}
+
+// stopLocked closes stdin, which tells the plugin to exit, and waits for it.
+// A plugin that doesn't exit within pluginStopTimeout is killed. The caller must hold p.mu.
+func (p *PluginConnection) stopLocked() error {
+	if p.cmd == nil {
+		return nil
+	}
+	_ = p.stdin.Close()
+
+	cmd := p.cmd
+	p.cmd = nil
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(pluginStopTimeout):
+		_ = cmd.Process.Kill()
+		<-done
+		return fmt.Errorf("plugin didn't exit within %s and was killed", pluginStopTimeout)
+	}
+}
+
+// killLocked kills the plugin process, so the next call starts it again. The caller must hold p.mu.
+func (p *PluginConnection) killLocked() {
+	if p.cmd == nil {
+		return
+	}
+	_ = p.cmd.Process.Kill()
+	_ = p.cmd.Wait()
+	p.cmd = nil
+}
+
+// protocolError kills the plugin, as its responses can't be matched to requests anymore,
+// and returns the error with what the plugin wrote to stderr. The caller must hold p.mu.
+func (p *PluginConnection) protocolError(format string, args ...interface{}) error {
+	// Killing the plugin first waits for its stderr to be read completely.
+	p.killLocked()
+
+	err := fmt.Errorf(format, args...)
+	if stderr := strings.TrimSpace(p.stderr.String()); stderr != "" {
+		err = fmt.Errorf("%w (plugin stderr: %s)", err, stderr)
+	}
+	return err
+}
+
+// writeLine writes a line to the plugin, waiting until the deadline at most. The caller must hold p.mu.
+func (p *PluginConnection) writeLine(line []byte, deadline time.Time) error {
+	// The writer returns once the plugin reads the line or is killed after the timeout.
+	stdin := p.stdin
+	result := make(chan error, 1)
+	go func() {
+		_, err := stdin.Write(line)
+		result <- err
+	}()
+
+	select {
+	case err := <-result:
+		return err
+	case <-time.After(time.Until(deadline)):
+		return fmt.Errorf("request not read within %s", pluginCallTimeout)
+	}
+}
+
+// readLine reads a line from the plugin, waiting until the deadline at most. The caller must hold p.mu.
+func (p *PluginConnection) readLine(deadline time.Time) ([]byte, error) {
+	type readResult struct {
+		line []byte
+		err  error
+	}
+
+	// The reader returns once the plugin writes a line or is killed after the timeout.
+	stdout := p.stdout
+	result := make(chan readResult, 1)
+	go func() {
+		line, err := stdout.ReadBytes('\n')
+		result <- readResult{line: line, err: err}
+	}()
+
+	select {
+	case r := <-result:
+		return r.line, r.err
+	case <-time.After(time.Until(deadline)):
+		return nil, fmt.Errorf("no response within %s", pluginCallTimeout)
+	}
+}
+
+// call sends a request to the plugin, starting it if needed, and decodes the result.
+func (p *PluginConnection) call(method string, params, result interface{}) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.cmd == nil {
+		if err := p.start(); err != nil {
+			return err
+		}
+	}
+
+	return p.roundTrip(method, params, result)
+}
+
+// roundTrip writes a single request and reads its response, within pluginCallTimeout. The caller must hold p.mu.
+func (p *PluginConnection) roundTrip(method string, params, result interface{}) error {
+	deadline := time.Now().Add(pluginCallTimeout)
+	p.nextID++
+	request := pluginRequest{
+		JSONRPC: "2.0",
+		ID:      p.nextID,
+		Method:  method,
+		Params:  params,
+	}
+
+	data, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	if err := p.writeLine(append(data, '\n'), deadline); err != nil {
+		return p.protocolError("failed to write to plugin: %v", err)
+	}
+
+	line, err := p.readLine(deadline)
+	if err != nil {
+		return p.protocolError("failed to read from plugin: %v", err)
+	}
+
+	var response pluginResponse
+	if err := json.Unmarshal(line, &response); err != nil {
+		return p.protocolError("invalid response from plugin: %v", err)
+	}
+	if response.ID != request.ID {
+		return p.protocolError("unexpected response id from plugin: got %d, want %d", response.ID, request.ID)
+	}
+	if response.Error != nil {
+		return response.Error
+	}
+
+	if result == nil || len(response.Result) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(response.Result, result); err != nil {
+		return fmt.Errorf("invalid result from plugin: %v", err)
+	}
+
+	return nil
+}
+
+func (p *PluginConnection) GetConnection() Connection {
+	return p.Connection
+}
+
+func (p *PluginConnection) CheckAuthentication() error {
+	return p.call("CheckAuthentication", nil, nil)
+}
+
+// SetContext asks the plugin to gather its context and caches it.
+func (p *PluginConnection) SetContext() error {
+	if err := p.call("SetContext", nil, nil); err != nil {
+		return err
+	}
+
+	var result struct {
+		Context string `json:"context"`
+	}
+	if err := p.call("GetContext", nil, &result); err != nil {
+		return err
+	}
+	p.context = &result.Context
+
+	return nil
+}
+
+func (p *PluginConnection) GetContext() string {
+	if p.context == nil {
+		// Call SetContext to populate the context.
+		// This is a fallback in case SetContext is not called.
+		if err := p.SetContext(); err != nil {
+			return fmt.Sprintf("Error getting context: %v", err)
+		}
+	}
+	return *p.context
+}
+
+func (p *PluginConnection) GetFormattedContext() (string, error) {
+	if p.context == nil {
+		if err := p.SetContext(); err != nil {
+			return "", fmt.Errorf("error getting context: %v", err)
+		}
+	}
+	return *p.context, nil
+}
+
+func (p *PluginConnection) ExportContext() ([]byte, error) {
+	if p.context == nil {
+		return nil, fmt.Errorf("context is not set")
+	}
+	return json.Marshal(map[string]string{"context": *p.context})
+}
+
+func (p *PluginConnection) ImportContext(data []byte) error {
+	var cached struct {
+		Context string `json:"context"`
+	}
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return fmt.Errorf("error parsing cached context: %v", err)
+	}
+
+	p.context = &cached.Context
+	return nil
+}
+
+// ExecuteCommand executes the command via the plugin.
+// Plugins return either tabular results as columns and rows, or plain output.
+func (p *PluginConnection) ExecuteCommand(command string) (*ResultSet, error) {
+	var result struct {
+		Output  string              `json:"output"`
+		Columns []Column            `json:"columns"`
+		Rows    [][]json.RawMessage `json:"rows"`
+	}
+	if err := p.call("ExecuteCommand", map[string]string{"command": command}, &result); err != nil {
+		return nil, fmt.Errorf("failed to execute command: %v", err)
+	}
+
+	if len(result.Columns) == 0 {
+		return NewTextResultSet(result.Output), nil
+	}
+
+	resultSet := &ResultSet{
+		Columns: result.Columns,
+		Rows:    make([][]interface{}, len(result.Rows)),
+	}
+	for i, row := range result.Rows {
+		if len(row) != len(result.Columns) {
+			return nil, fmt.Errorf("plugin returned %d values in row %d, want %d", len(row), i, len(result.Columns))
+		}
+
+		resultSet.Rows[i] = make([]interface{}, len(row))
+		for j, value := range row {
+			resultSet.Rows[i][j] = ConvertJSONValue(value)
+		}
+	}
+	return resultSet, nil
+}
+
+// pluginMethodNotFound is the JSON-RPC error code for methods a plugin doesn't implement.
+const pluginMethodNotFound = -32601
+
+// PreviewCommand asks the plugin what the command would change without applying it.
+// Plugins that don't implement PreviewCommand don't support previews.
+func (p *PluginConnection) PreviewCommand(command string) (string, error) {
+	var result struct {
+		Preview string `json:"preview"`
+	}
+	if err := p.call("PreviewCommand", map[string]string{"command": command}, &result); err != nil {
+		var pluginErr *PluginError
+		if errors.As(err, &pluginErr) && pluginErr.Code == pluginMethodNotFound {
+			return "", previewNotSupported("the plugin doesn't implement PreviewCommand")
+		}
+		return "", fmt.Errorf("failed to preview command: %v", err)
+	}
+	return result.Preview, nil
+}
+
+// FormatResultAsTable formats plain output via the plugin.
+// Tabular results are formatted as a table without calling the plugin.
+func (p *PluginConnection) FormatResultAsTable(result *ResultSet) (string, error) {
+	if result.IsTabular() {
+		return formatTable(result), nil
+	}
+
+	var formatted struct {
+		Table string `json:"table"`
+	}
+	if err := p.call("FormatResultAsTable", map[string]string{"result": result.Text}, &formatted); err != nil {
+		return "", err
+	}
+	return formatted.Table, nil
+}
+
+func (p *PluginConnection) GetCommand(prompt string) (string, error) {
+	// Get the context first; it starts the plugin, which reports the command type.
+	context := p.GetContext()
+
+	aiModel, err := newAIModel(p.Connection, p.CommandType(), context)
+	if err != nil {
+		return "", fmt.Errorf("failed to create AI model: %v", err)
+	}
+
+	cmd, err := aiModel.GetCommand(prompt)
+	if err != nil {
+		return "", fmt.Errorf("failed to get command from AI: %v", err)
+	}
+
+	return cmd.Command, nil
+}
+
+func (p *PluginConnection) GetAnswer(prompt string) (string, error) {
+	// Get the context first; it starts the plugin, which reports the command type.
+	context := p.GetContext()
+
+	aiModel, err := newAIModel(p.Connection, p.CommandType(), context)
+	if err != nil {
+		return "", fmt.Errorf("failed to create AI model: %v", err)
+	}
+
+	answer, err := aiModel.GetAnswer(prompt)
+	if err != nil {
+		return "", fmt.Errorf("failed to get answer from AI: %v", err)
+	}
+
+	return answer.Answer, nil
+}
+
+// ClassifyCommand asks the plugin how risky the command is.
+// Commands of plugins that don't implement ClassifyCommand, or return an unknown risk, are rated as writes.
+func (p *PluginConnection) ClassifyCommand(command string) RiskAssessment {
+	var result struct {
+		Risk   string `json:"risk"`
+		Reason string `json:"reason"`
+		Target string `json:"target"`
+	}
+	if err := p.call("ClassifyCommand", map[string]string{"command": command}, &result); err != nil {
+		return riskOf(p.Connection, RiskWrite, "the plugin doesn't rate its commands", "")
+	}
+
+	risk, err := ParseRisk(result.Risk)
+	if err != nil {
+		return riskOf(p.Connection, RiskWrite, fmt.Sprintf("the plugin returned an unknown risk: %s", result.Risk), "")
+	}
+	return riskOf(p.Connection, risk, result.Reason, result.Target)
+}
+
+// CheckReadOnly rejects commands the plugin doesn't rate as reads on read-only connections.
+// The plugin also has to acknowledge read-only mode on Initialize and reject such commands itself.
+func (p *PluginConnection) CheckReadOnly(command string) error {
+	if !p.Connection.ReadOnly {
+		return nil
+	}
+
+	assessment := p.ClassifyCommand(command)
+	if assessment.Risk > RiskRead {
+		return readOnlyViolation(p.Connection, fmt.Sprintf("the command is rated %s: %s", assessment.Risk, assessment.Reason))
+	}
+	return nil
+}
+
+// AllowedExecutables returns nil as commands are passed to the plugin, which validates them itself.
+func (p *PluginConnection) AllowedExecutables() []string {
+	return nil
+}
+
+// CommandType returns the command type reported by the plugin on Initialize.
+func (p *PluginConnection) CommandType() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.commandType == "" {
+		return fmt.Sprintf("%s command", p.Connection.Type.GetSubtype())
+	}
+	return p.commandType
+}
+
+// resolveSettings returns the settings of the connection with references like ${env:API_TOKEN} resolved.
+// They are resolved every time the plugin starts and are never written back to the connection.
+func (p *PluginConnection) resolveSettings() (map[string]string, error) {
+	details, err := GetPluginConnectionDetails(p.Connection)
+	if err != nil || len(details.Settings) == 0 {
+		return nil, nil
+	}
+
+	settings := make(map[string]string, len(details.Settings))
+	for key, value := range details.Settings {
+		resolved, err := secret.Expand(value)
+		if err != nil {
+			return nil, fmt.Errorf("setting '%s': %w", key, err)
+		}
+		settings[key] = resolved.Value
+	}
+	return settings, nil
+}
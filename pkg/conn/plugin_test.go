@@ -0,0 +1,285 @@
+package conn
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestHelperPlugin is not a real test. It acts as a plugin when the test binary
+// is started by the pops-plugin-fake script created in installFakePlugin.
+func TestHelperPlugin(t *testing.T) {
+	if os.Getenv("POPS_WANT_HELPER_PLUGIN") != "1" {
+		return
+	}
+
+	ignoreEOF := false
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Buffer(nil, 4<<20)
+	for scanner.Scan() {
+		var request pluginRequest
+		if err := json.Unmarshal(scanner.Bytes(), &request); err != nil {
+			os.Exit(2)
+		}
+
+		response := map[string]interface{}{"jsonrpc": "2.0", "id": request.ID}
+		switch request.Method {
+		case "Hang":
+			continue
+		case "StopReading":
+			fmt.Println(`{"jsonrpc": "2.0", "id": ` + fmt.Sprint(request.ID) + `, "result": {}}`)
+			select {}
+		case "IgnoreEOF":
+			ignoreEOF = true
+			response["result"] = map[string]string{}
+		case "Garbage":
+			fmt.Fprintln(os.Stderr, "fake plugin: lost track of requests")
+			fmt.Println("not json")
+			continue
+		case "WrongID":
+			response["id"] = request.ID + 1
+			response["result"] = map[string]string{}
+		case "Initialize":
+			params := request.Params.(map[string]interface{})
+			settings, _ := params["settings"].(map[string]interface{})
+			readOnly, _ := params["readOnly"].(bool)
+			response["result"] = PluginInitializeResult{CommandType: "fakectl command", ReadOnly: readOnly && settings["readOnly"] != "unsupported"}
+		case "ClassifyCommand":
+			params := request.Params.(map[string]interface{})
+			if params["command"] == "fakectl list" {
+				response["result"] = map[string]string{"risk": "read", "reason": "lists services"}
+			} else {
+				response["result"] = map[string]string{"risk": "write", "reason": "changes services"}
+			}
+		case "CheckAuthentication", "SetContext":
+			response["result"] = map[string]string{}
+		case "GetContext":
+			response["result"] = map[string]string{"context": "Services: api, web"}
+		case "ExecuteCommand":
+			params := request.Params.(map[string]interface{})
+			if params["command"] == "fakectl list" {
+				response["result"] = map[string]interface{}{
+					"columns": []Column{{Name: "name", Type: "string"}, {Name: "replicas", Type: "number"}},
+					"rows":    [][]interface{}{{"api", 3}, {"web", nil}},
+				}
+			} else {
+				response["result"] = map[string]string{"output": fmt.Sprintf("ran %s", params["command"])}
+			}
+		default:
+			response["error"] = PluginError{Code: -32601, Message: "method not found"}
+		}
+
+		data, _ := json.Marshal(response)
+		fmt.Println(string(data))
+	}
+	if ignoreEOF {
+		select {}
+	}
+	os.Exit(0)
+}
+
+// installFakePlugin puts a pops-plugin-fake executable on PATH that runs TestHelperPlugin.
+func installFakePlugin(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake plugin script requires a POSIX shell")
+	}
+
+	dir := t.TempDir()
+	script := fmt.Sprintf("#!/bin/sh\nPOPS_WANT_HELPER_PLUGIN=1 exec %q -test.run=TestHelperPlugin\n", os.Args[0])
+	if err := os.WriteFile(filepath.Join(dir, PluginExecutablePrefix+"fake"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+}
+
+func TestDiscoverPlugins(t *testing.T) {
+	installFakePlugin(t)
+
+	plugins := DiscoverPlugins()
+	if len(plugins) != 1 || plugins[0].Subtype != "fake" {
+		t.Errorf("DiscoverPlugins() = %v, want the fake plugin", plugins)
+	}
+}
+
+func TestPluginConnection(t *testing.T) {
+	installFakePlugin(t)
+
+	connection := NewPluginConnection("test", AvailablePluginConnectionType{Subtype: "fake"}, nil)
+	plugin, err := NewPluginConnectionImpl(&connection)
+	if err != nil {
+		t.Fatalf("NewPluginConnectionImpl() error = %v", err)
+	}
+	defer plugin.Close()
+
+	if err := plugin.CheckAuthentication(); err != nil {
+		t.Fatalf("CheckAuthentication() error = %v", err)
+	}
+	if got := plugin.CommandType(); got != "fakectl command" {
+		t.Errorf("CommandType() = %v, want %v", got, "fakectl command")
+	}
+	if got := plugin.GetContext(); got != "Services: api, web" {
+		t.Errorf("GetContext() = %v, want %v", got, "Services: api, web")
+	}
+
+	output, err := plugin.ExecuteCommand("fakectl deploy api")
+	if err != nil {
+		t.Fatalf("ExecuteCommand() error = %v", err)
+	}
+	if output.Text != "ran fakectl deploy api" {
+		t.Errorf("ExecuteCommand() = %v, want %v", output.Text, "ran fakectl deploy api")
+	}
+
+	if _, err := plugin.FormatResultAsTable(output); err == nil {
+		t.Errorf("FormatResultAsTable() expected method not found error")
+	}
+
+	if _, err := plugin.PreviewCommand("fakectl deploy api"); !errors.Is(err, ErrPreviewNotSupported) {
+		t.Errorf("PreviewCommand() error = %v, want %v", err, ErrPreviewNotSupported)
+	}
+
+	if got := plugin.ClassifyCommand("fakectl deploy api"); got.Risk != RiskWrite {
+		t.Errorf("ClassifyCommand() = %v, want %v", got.Risk, RiskWrite)
+	}
+
+	output, err = plugin.ExecuteCommand("fakectl list")
+	if err != nil {
+		t.Fatalf("ExecuteCommand() error = %v", err)
+	}
+	wantRows := [][]interface{}{{"api", int64(3)}, {"web", nil}}
+	if !reflect.DeepEqual(output.Rows, wantRows) {
+		t.Errorf("ExecuteCommand() rows = %v, want %v", output.Rows, wantRows)
+	}
+	if _, err := plugin.FormatResultAsTable(output); err != nil {
+		t.Errorf("FormatResultAsTable() error = %v", err)
+	}
+}
+
+func TestPluginConnection_ReadOnly(t *testing.T) {
+	installFakePlugin(t)
+
+	connection := NewPluginConnection("test", AvailablePluginConnectionType{Subtype: "fake"}, nil)
+	connection.ReadOnly = true
+	plugin, err := NewPluginConnectionImpl(&connection)
+	if err != nil {
+		t.Fatalf("NewPluginConnectionImpl() error = %v", err)
+	}
+	defer plugin.Close()
+
+	if err := plugin.CheckReadOnly("fakectl list"); err != nil {
+		t.Errorf("CheckReadOnly() of a read error = %v", err)
+	}
+	if err := plugin.CheckReadOnly("fakectl deploy api"); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("CheckReadOnly() of a write error = %v, want %v", err, ErrReadOnly)
+	}
+
+	// Plugins that don't acknowledge read-only mode are not used.
+	unsupported := NewPluginConnection("unsupported", AvailablePluginConnectionType{Subtype: "fake"}, map[string]string{"readOnly": "unsupported"})
+	unsupported.ReadOnly = true
+	plugin, err = NewPluginConnectionImpl(&unsupported)
+	if err != nil {
+		t.Fatalf("NewPluginConnectionImpl() error = %v", err)
+	}
+	defer plugin.Close()
+	if err := plugin.CheckAuthentication(); err == nil || !strings.Contains(err.Error(), "read-only") {
+		t.Errorf("CheckAuthentication() error = %v, want the plugin to be refused", err)
+	}
+}
+
+func TestPluginConnection_Timeouts(t *testing.T) {
+	installFakePlugin(t)
+
+	callTimeout, stopTimeout := pluginCallTimeout, pluginStopTimeout
+	pluginCallTimeout, pluginStopTimeout = time.Second, time.Second
+	t.Cleanup(func() { pluginCallTimeout, pluginStopTimeout = callTimeout, stopTimeout })
+
+	connection := NewPluginConnection("test", AvailablePluginConnectionType{Subtype: "fake"}, nil)
+	plugin, err := NewPluginConnectionImpl(&connection)
+	if err != nil {
+		t.Fatalf("NewPluginConnectionImpl() error = %v", err)
+	}
+	defer plugin.Close()
+
+	// A request larger than the pipe buffer blocks until the plugin reads it.
+	if err := plugin.call("StopReading", nil, nil); err != nil {
+		t.Fatalf("call() error = %v", err)
+	}
+	err = plugin.call("ExecuteCommand", map[string]string{"command": strings.Repeat("x", 1<<20)}, nil)
+	if err == nil || !strings.Contains(err.Error(), "not read within") {
+		t.Fatalf("call() error = %v, want the request to time out", err)
+	}
+
+	// A plugin that doesn't exit when its stdin is closed is killed.
+	if err := plugin.call("IgnoreEOF", nil, nil); err != nil {
+		t.Fatalf("call() error = %v", err)
+	}
+	closed := make(chan error, 1)
+	go func() { closed <- plugin.Close() }()
+	select {
+	case err := <-closed:
+		if err == nil || !strings.Contains(err.Error(), "killed") {
+			t.Errorf("Close() error = %v, want the plugin to be killed", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Close() didn't return")
+	}
+}
+
+func TestPluginConnection_ProtocolErrors(t *testing.T) {
+	installFakePlugin(t)
+
+	timeout := pluginCallTimeout
+	pluginCallTimeout = time.Second
+	t.Cleanup(func() { pluginCallTimeout = timeout })
+
+	tests := []struct {
+		name      string
+		method    string
+		wantError string
+	}{
+		{
+			name:      "Test call times out when the plugin doesn't answer",
+			method:    "Hang",
+			wantError: "no response within",
+		},
+		{
+			name:      "Test call reports invalid responses with stderr",
+			method:    "Garbage",
+			wantError: "lost track of requests",
+		},
+		{
+			name:      "Test call reports responses to other requests",
+			method:    "WrongID",
+			wantError: "unexpected response id",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			connection := NewPluginConnection("test", AvailablePluginConnectionType{Subtype: "fake"}, nil)
+			plugin, err := NewPluginConnectionImpl(&connection)
+			if err != nil {
+				t.Fatalf("NewPluginConnectionImpl() error = %v", err)
+			}
+			defer plugin.Close()
+
+			err = plugin.call(tt.method, nil, nil)
+			if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+				t.Fatalf("call() error = %v, want %q", err, tt.wantError)
+			}
+
+			// The plugin is restarted, so the next call gets its own response.
+			if err := plugin.CheckAuthentication(); err != nil {
+				t.Errorf("CheckAuthentication() after a protocol error: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package conn
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// ErrPreviewNotSupported is returned by PreviewCommand for commands that can't be previewed.
+var ErrPreviewNotSupported = errors.New("preview is not supported")
+
+// PreviewSampleSize is how many changed rows or objects a preview shows.
+const PreviewSampleSize = 10
+
+// previewNotSupported returns an error wrapping ErrPreviewNotSupported with the reason.
+func previewNotSupported(reason string) error {
+	return fmt.Errorf("%w: %s", ErrPreviewNotSupported, reason)
+}
+
+// previewArgs parses a command for a preview.
+// Previews run a single executable, so pipelines can't be previewed.
+func previewArgs(connection Connection, allowed []string, command string) ([]string, error) {
+	stages, err := ParsePipeline(command)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkExecutables(connection, allowed, stages); err != nil {
+		return nil, err
+	}
+
+	if len(stages) > 1 {
+		return nil, previewNotSupported("pipelines can't be previewed")
+	}
+	return stages[0], nil
+}
+
+// runPreview runs a preview command and returns its output.
+// Standard error is kept out of the output, which is often parsed, and returned in the error instead.
+func runPreview(args []string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return output, nil
+}
+
+// diffContextLines is how many unchanged lines are shown around changes.
+const diffContextLines = 3
+
+// DiffLines returns a line based diff of before and after, with "-" for removed and "+" for added lines.
+// Unchanged lines further than a few lines away from a change are left out.
+// Returns an empty string if there are no changes.
+func DiffLines(before, after string) string {
+	a := splitLines(before)
+	b := splitLines(after)
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	type diffLine struct {
+		op   byte
+		text string
+	}
+	var lines []diffLine
+	changed := false
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, diffLine{' ', a[i]})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, diffLine{'-', a[i]})
+			changed = true
+			i++
+		default:
+			lines = append(lines, diffLine{'+', b[j]})
+			changed = true
+			j++
+		}
+	}
+	if !changed {
+		return ""
+	}
+
+	// Keep unchanged lines that are close to a change.
+	keep := make([]bool, len(lines))
+	for index, line := range lines {
+		if line.op == ' ' {
+			continue
+		}
+		for k := index - diffContextLines; k <= index+diffContextLines; k++ {
+			if k >= 0 && k < len(lines) {
+				keep[k] = true
+			}
+		}
+	}
+
+	var sb strings.Builder
+	skipped := false
+	for index, line := range lines {
+		if !keep[index] {
+			skipped = true
+			continue
+		}
+		if skipped {
+			sb.WriteString("  ...\n")
+			skipped = false
+		}
+		sb.WriteByte(line.op)
+		sb.WriteByte(' ')
+		sb.WriteString(line.text)
+		sb.WriteByte('\n')
+	}
+	if skipped {
+		sb.WriteString("  ...\n")
+	}
+	return sb.String()
+}
+
+// splitLines splits text into lines, ignoring a trailing line break.
+func splitLines(text string) []string {
+	text = strings.TrimSuffix(text, "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
@@ -0,0 +1,55 @@
+package conn
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name   string
+		before string
+		after  string
+		want   string
+	}{
+		{
+			name:   "Test DiffLines without changes",
+			before: "a\nb\n",
+			after:  "a\nb\n",
+			want:   "",
+		},
+		{
+			name:   "Test DiffLines with a changed line",
+			before: "kind: Deployment\nreplicas: 1\n",
+			after:  "kind: Deployment\nreplicas: 3\n",
+			want:   "  kind: Deployment\n- replicas: 1\n+ replicas: 3\n",
+		},
+		{
+			name:   "Test DiffLines with a new object",
+			before: "",
+			after:  "a\nb\n",
+			want:   "+ a\n+ b\n",
+		},
+		{
+			name:   "Test DiffLines leaves out distant lines",
+			before: "1\n2\n3\n4\n5\n6\n",
+			after:  "1\n2\n3\n4\n5\n7\n",
+			want:   "  ...\n  3\n  4\n  5\n- 6\n+ 7\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DiffLines(tt.before, tt.after); got != tt.want {
+				t.Errorf("DiffLines() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPreviewArgs_RejectsPipelines(t *testing.T) {
+	connection := Connection{Name: "test", AllowPipelines: true}
+	_, err := previewArgs(connection, []string{"kubectl"}, "kubectl get pods | kubectl apply -f -")
+	if !errors.Is(err, ErrPreviewNotSupported) {
+		t.Errorf("previewArgs() error = %v, want %v", err, ErrPreviewNotSupported)
+	}
+}
@@ -1,16 +1,121 @@
 package conn
 
+// TablesAndColumnsQueryMap holds the per-driver queries for columns.
+// Each query returns schema, table, column, data type, nullability ('YES'/'NO'),
+// column comment and a driver specific type detail used to resolve enum values.
 var TablesAndColumnsQueryMap = map[string]string{
 	"postgres": `
-        SELECT table_schema, table_name, column_name, data_type
-        FROM information_schema.columns
-        WHERE table_schema NOT IN ('information_schema', 'pg_catalog')
-        ORDER BY table_schema, table_name, ordinal_position;
+        SELECT c.table_schema, c.table_name, c.column_name,
+               CASE WHEN c.data_type = 'USER-DEFINED' THEN c.udt_name ELSE c.data_type END,
+               c.is_nullable,
+               COALESCE(col_description((quote_ident(c.table_schema) || '.' || quote_ident(c.table_name))::regclass, c.ordinal_position), ''),
+               c.udt_schema || '.' || c.udt_name
+        FROM information_schema.columns c
+        WHERE c.table_schema NOT IN ('information_schema', 'pg_catalog')
+        ORDER BY c.table_schema, c.table_name, c.ordinal_position;
     `,
 	"mysql": `
-        SELECT table_schema, table_name, column_name, data_type
+        SELECT table_schema, table_name, column_name, data_type, is_nullable, column_comment, column_type
         FROM information_schema.columns
         WHERE table_schema NOT IN ('information_schema', 'mysql', 'performance_schema', 'sys')
         ORDER BY table_schema, table_name, ordinal_position;
     `,
 }
+
+// TablesQueryMap holds the per-driver queries for table comments and approximate row counts.
+// Each query returns schema, table, table comment and the estimated number of rows.
+var TablesQueryMap = map[string]string{
+	"postgres": `
+        SELECT n.nspname, c.relname, COALESCE(obj_description(c.oid, 'pg_class'), ''), GREATEST(c.reltuples, 0)::bigint
+        FROM pg_class c
+        JOIN pg_namespace n ON n.oid = c.relnamespace
+        WHERE c.relkind IN ('r', 'p', 'v', 'm', 'f')
+          AND n.nspname NOT IN ('information_schema', 'pg_catalog')
+          AND n.nspname NOT LIKE 'pg_toast%'
+        ORDER BY n.nspname, c.relname;
+    `,
+	"mysql": `
+        SELECT table_schema, table_name, table_comment, COALESCE(table_rows, 0)
+        FROM information_schema.tables
+        WHERE table_schema NOT IN ('information_schema', 'mysql', 'performance_schema', 'sys')
+        ORDER BY table_schema, table_name;
+    `,
+}
+
+// ConstraintsQueryMap holds the per-driver queries for primary keys, unique constraints and foreign keys.
+// Each query returns one row per constrained column: schema, table, constraint name,
+// constraint type ('PRIMARY KEY', 'UNIQUE' or 'FOREIGN KEY'), column,
+// and the referenced schema, table and column for foreign keys (empty otherwise).
+var ConstraintsQueryMap = map[string]string{
+	"postgres": `
+        SELECT n.nspname, cl.relname, con.conname,
+               CASE con.contype WHEN 'p' THEN 'PRIMARY KEY' WHEN 'u' THEN 'UNIQUE' ELSE 'FOREIGN KEY' END,
+               a.attname,
+               COALESCE(rn.nspname, ''), COALESCE(rcl.relname, ''), COALESCE(ra.attname, '')
+        FROM pg_constraint con
+        JOIN pg_class cl ON cl.oid = con.conrelid
+        JOIN pg_namespace n ON n.oid = cl.relnamespace
+        CROSS JOIN LATERAL unnest(con.conkey, con.confkey) WITH ORDINALITY AS k(attnum, refattnum, ord)
+        JOIN pg_attribute a ON a.attrelid = con.conrelid AND a.attnum = k.attnum
+        LEFT JOIN pg_class rcl ON rcl.oid = con.confrelid
+        LEFT JOIN pg_namespace rn ON rn.oid = rcl.relnamespace
+        LEFT JOIN pg_attribute ra ON ra.attrelid = con.confrelid AND ra.attnum = k.refattnum
+        WHERE con.contype IN ('p', 'u', 'f')
+          AND n.nspname NOT IN ('information_schema', 'pg_catalog')
+        ORDER BY n.nspname, cl.relname, con.conname, k.ord;
+    `,
+	"mysql": `
+        SELECT k.table_schema, k.table_name, k.constraint_name, tc.constraint_type, k.column_name,
+               COALESCE(k.referenced_table_schema, ''), COALESCE(k.referenced_table_name, ''), COALESCE(k.referenced_column_name, '')
+        FROM information_schema.key_column_usage k
+        JOIN information_schema.table_constraints tc
+          ON tc.constraint_schema = k.constraint_schema
+         AND tc.table_name = k.table_name
+         AND tc.constraint_name = k.constraint_name
+        WHERE tc.constraint_type IN ('PRIMARY KEY', 'UNIQUE', 'FOREIGN KEY')
+          AND k.table_schema NOT IN ('information_schema', 'mysql', 'performance_schema', 'sys')
+        ORDER BY k.table_schema, k.table_name, k.constraint_name, k.ordinal_position;
+    `,
+}
+
+// IndexesQueryMap holds the per-driver queries for indexes.
+// Each query returns schema, table, index name, whether the index is unique
+// and the comma separated list of indexed columns or expressions.
+var IndexesQueryMap = map[string]string{
+	"postgres": `
+        SELECT n.nspname, t.relname, i.relname, ix.indisunique,
+               array_to_string(ARRAY(
+                   SELECT pg_get_indexdef(ix.indexrelid, k + 1, true)
+                   FROM generate_subscripts(ix.indkey, 1) AS k
+                   ORDER BY k
+               ), ', ')
+        FROM pg_index ix
+        JOIN pg_class i ON i.oid = ix.indexrelid
+        JOIN pg_class t ON t.oid = ix.indrelid
+        JOIN pg_namespace n ON n.oid = t.relnamespace
+        WHERE n.nspname NOT IN ('information_schema', 'pg_catalog')
+          AND n.nspname NOT LIKE 'pg_toast%'
+        ORDER BY n.nspname, t.relname, i.relname;
+    `,
+	"mysql": `
+        SELECT table_schema, table_name, index_name, non_unique = 0,
+               COALESCE(GROUP_CONCAT(COALESCE(column_name, '(expression)') ORDER BY seq_in_index SEPARATOR ', '), '')
+        FROM information_schema.statistics
+        WHERE table_schema NOT IN ('information_schema', 'mysql', 'performance_schema', 'sys')
+        GROUP BY table_schema, table_name, index_name, non_unique
+        ORDER BY table_schema, table_name, index_name;
+    `,
+}
+
+// EnumValuesQueryMap holds the per-driver queries for enum types.
+// Each query returns the enum type (matching the type detail of TablesAndColumnsQueryMap) and one label per row.
+// MySQL is not listed because its enum values are parsed from the column type.
+var EnumValuesQueryMap = map[string]string{
+	"postgres": `
+        SELECT n.nspname || '.' || t.typname, e.enumlabel
+        FROM pg_type t
+        JOIN pg_enum e ON e.enumtypid = t.oid
+        JOIN pg_namespace n ON n.oid = t.typnamespace
+        ORDER BY n.nspname, t.typname, e.enumsortorder;
+    `,
+}
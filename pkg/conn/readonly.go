@@ -0,0 +1,132 @@
+package conn
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ErrReadOnly is returned for commands that could change anything on a read-only connection.
+var ErrReadOnly = errors.New("connection is read-only")
+
+// readOnlyViolation returns an error wrapping ErrReadOnly that explains why the command was blocked.
+func readOnlyViolation(connection Connection, reason string) error {
+	return fmt.Errorf("%w: %s (connection '%s' only runs commands that don't change anything)", ErrReadOnly, reason, connection.Name)
+}
+
+// readOnlyKubectlVerbs are the kubectl commands allowed on read-only connections.
+var readOnlyKubectlVerbs = []string{"get", "describe", "logs", "top"}
+
+// readOnlySQLStatements are the SQL statements allowed on read-only connections.
+var readOnlySQLStatements = []string{"SELECT", "SHOW", "EXPLAIN", "WITH", "TABLE", "VALUES"}
+
+// readOnlyFilters are the extra tools allowed in pipelines of read-only connections,
+// as they only filter or transform the output of the connection's tool.
+var readOnlyFilters = []string{"jq", "grep", "head", "tail", "wc", "cut", "tr", "sort", "column"}
+
+// sortFlags are the flags of sort allowed on read-only connections. Other flags, like -o, which writes
+// a file, or --compress-program, which runs a program, are rejected.
+var sortFlags = []string{
+	"-b", "-d", "-f", "-g", "-h", "-i", "-M", "-n", "-r", "-s", "-u", "-V", "-z",
+	"--ignore-leading-blanks", "--dictionary-order", "--ignore-case", "--general-numeric-sort", "--human-numeric-sort",
+	"--ignore-nonprinting", "--month-sort", "--numeric-sort", "--reverse", "--stable", "--unique", "--version-sort", "--zero-terminated",
+}
+
+// sortValueFlags are the flags of sort allowed on read-only connections that take a value, like -k2 or --key=2.
+var sortValueFlags = []string{"-k", "-t", "--key", "--field-separator"}
+
+// checkReadOnlyFilter rejects stages that are not one of readOnlyFilters, or that use one to write a file.
+// Filters are matched exactly, as `SORT` may run sort on case-insensitive filesystems without being checked as sort.
+func checkReadOnlyFilter(connection Connection, args []string) error {
+	if !slices.Contains(readOnlyFilters, args[0]) {
+		return readOnlyViolation(connection, fmt.Sprintf("`%s` is not allowed in pipelines, only %s", args[0], strings.Join(readOnlyFilters, ", ")))
+	}
+	if args[0] == "sort" {
+		if err := checkSortFlags(args[1:]); err != nil {
+			return readOnlyViolation(connection, err.Error())
+		}
+	}
+	return nil
+}
+
+// checkSortFlags rejects flags of sort that are not in sortFlags or sortValueFlags.
+// Long flags must be spelled out, as sort would take an abbreviation like --outp for --output.
+func checkSortFlags(args []string) error {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			return nil
+		case !strings.HasPrefix(arg, "-") || arg == "-":
+			continue
+		case strings.HasPrefix(arg, "--"):
+			name, _, hasValue := strings.Cut(arg, "=")
+			if slices.Contains(sortValueFlags, name) {
+				if !hasValue {
+					i++
+				}
+				continue
+			}
+			if hasValue || !slices.Contains(sortFlags, name) {
+				return fmt.Errorf("`sort %s` is not allowed; sort only takes flags that order its input", name)
+			}
+		default:
+			// A cluster of short flags like -rn; -o anywhere in it writes a file.
+			if strings.Contains(arg, "o") {
+				return fmt.Errorf("`sort %s` can write files", arg)
+			}
+			for j := 1; j < len(arg); j++ {
+				flag := "-" + arg[j:j+1]
+				if slices.Contains(sortValueFlags, flag) {
+					// The rest of the cluster, or the next argument, is the value.
+					if j == len(arg)-1 {
+						i++
+					}
+					break
+				}
+				if !slices.Contains(sortFlags, flag) {
+					return fmt.Errorf("`sort %s` is not allowed; sort only takes flags that order its input", flag)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// checkReadOnlyStages checks every stage of a pipeline that runs executable with check.
+// Other stages must be filters like jq that only transform the output; any other tool is rejected,
+// even if it is one of the connection's extra tools, as pops can't tell what it changes.
+func checkReadOnlyStages(connection Connection, command, executable string, check func(args []string) error) error {
+	if !connection.ReadOnly {
+		return nil
+	}
+
+	stages, err := ParsePipeline(command)
+	if err != nil {
+		return err
+	}
+
+	for _, stage := range stages {
+		if stage[0] != executable {
+			if err := checkReadOnlyFilter(connection, stage); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := check(stage); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// containsFold reports whether values contains value, ignoring case.
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
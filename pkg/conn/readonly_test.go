@@ -0,0 +1,163 @@
+package conn
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckReadOnly(t *testing.T) {
+	kubernetes := NewKubernetesConnection("k8s", "dev")
+	kubernetes.ReadOnly = true
+	kubernetes.AllowPipelines = true
+
+	azure := NewAzureCloudConnection("azure", AzureSubscription{ID: "sub-id"})
+	azure.ReadOnly = true
+
+	postgres := NewDatabaseConnection("db", PostgreSQLDatabaseConnection, "postgres://localhost/db")
+	postgres.ReadOnly = true
+
+	writable := NewKubernetesConnection("writable", "dev")
+
+	helm := NewKubernetesConnection("helm", "dev")
+	helm.ReadOnly = true
+	helm.AllowPipelines = true
+	helm.ExtraTools = []string{"helm", "jq"}
+
+	tests := []struct {
+		name       string
+		connection ConnectionInterface
+		command    string
+		wantErr    bool
+	}{
+		{
+			name:       "Test CheckReadOnly allows kubectl get",
+			connection: NewKubernetesConnectionImpl(&kubernetes),
+			command:    "kubectl -n prod get pods -o json | jq .items",
+		},
+		{
+			name:       "Test CheckReadOnly blocks kubectl delete",
+			connection: NewKubernetesConnectionImpl(&kubernetes),
+			command:    "kubectl delete ns prod",
+			wantErr:    true,
+		},
+		{
+			name:       "Test CheckReadOnly blocks extra tools that aren't filters",
+			connection: NewKubernetesConnectionImpl(&helm),
+			command:    "helm uninstall payments",
+			wantErr:    true,
+		},
+		{
+			name:       "Test CheckReadOnly blocks extra tools after kubectl",
+			connection: NewKubernetesConnectionImpl(&helm),
+			command:    "kubectl get pods | helm uninstall payments",
+			wantErr:    true,
+		},
+		{
+			name:       "Test CheckReadOnly allows filters",
+			connection: NewKubernetesConnectionImpl(&helm),
+			command:    "kubectl get pods -o json | jq .items | head -n 5",
+		},
+		{
+			name:       "Test CheckReadOnly blocks filters that write files",
+			connection: NewKubernetesConnectionImpl(&helm),
+			command:    "kubectl get pods | sort -o /tmp/pods",
+			wantErr:    true,
+		},
+		{
+			name:       "Test CheckReadOnly allows flags of sort that order its input",
+			connection: NewKubernetesConnectionImpl(&helm),
+			command:    "kubectl get pods | sort -rn -k2 -t , --key=3 --field-separator : -u",
+		},
+		{
+			name:       "Test CheckReadOnly blocks sort running a program",
+			connection: NewKubernetesConnectionImpl(&helm),
+			command:    "kubectl get pods | sort --compress-program=sh",
+			wantErr:    true,
+		},
+		{
+			name:       "Test CheckReadOnly blocks sort writing a file in a cluster of short flags",
+			connection: NewKubernetesConnectionImpl(&helm),
+			command:    "kubectl get pods | sort -ro out.txt",
+			wantErr:    true,
+		},
+		{
+			name:       "Test CheckReadOnly blocks abbreviated long flags of sort",
+			connection: NewKubernetesConnectionImpl(&helm),
+			command:    "kubectl get pods | sort --outp=f",
+			wantErr:    true,
+		},
+		{
+			name:       "Test CheckReadOnly matches filters exactly",
+			connection: NewKubernetesConnectionImpl(&helm),
+			command:    "kubectl get pods | SORT -o f",
+			wantErr:    true,
+		},
+		{
+			name:       "Test CheckReadOnly skips values of known flags before the kubectl command",
+			connection: NewKubernetesConnectionImpl(&kubernetes),
+			command:    "kubectl --request-timeout get delete ns payments",
+			wantErr:    true,
+		},
+		{
+			name:       "Test CheckReadOnly allows known flags before the kubectl command",
+			connection: NewKubernetesConnectionImpl(&kubernetes),
+			command:    "kubectl --request-timeout=5s -n prod get pods",
+		},
+		{
+			name:       "Test CheckReadOnly blocks unknown flags before the kubectl command",
+			connection: NewKubernetesConnectionImpl(&kubernetes),
+			command:    "kubectl --some-flag get delete ns payments",
+			wantErr:    true,
+		},
+		{
+			name:       "Test CheckReadOnly blocks flags before the az command",
+			connection: NewAzureConnection(&azure),
+			command:    "az --only-show-errors group delete -n rg --yes",
+			wantErr:    true,
+		},
+		{
+			name:       "Test CheckReadOnly allows az list",
+			connection: NewAzureConnection(&azure),
+			command:    "az vm list -g rg --query \"[].name\"",
+		},
+		{
+			name:       "Test CheckReadOnly blocks az delete",
+			connection: NewAzureConnection(&azure),
+			command:    "az group delete -n rg --yes",
+			wantErr:    true,
+		},
+		{
+			name:       "Test CheckReadOnly allows SELECT",
+			connection: NewPostgreSQLConnection(&postgres),
+			command:    "select * from users;",
+		},
+		{
+			name:       "Test CheckReadOnly blocks DROP",
+			connection: NewPostgreSQLConnection(&postgres),
+			command:    "DROP TABLE users",
+			wantErr:    true,
+		},
+		{
+			name:       "Test CheckReadOnly blocks multiple statements",
+			connection: NewPostgreSQLConnection(&postgres),
+			command:    "SELECT 1; COMMIT; DELETE FROM users",
+			wantErr:    true,
+		},
+		{
+			name:       "Test CheckReadOnly allows anything on writable connections",
+			connection: NewKubernetesConnectionImpl(&writable),
+			command:    "kubectl delete ns prod",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.connection.CheckReadOnly(tt.command)
+			if tt.wantErr && !errors.Is(err, ErrReadOnly) {
+				t.Errorf("CheckReadOnly() error = %v, want %v", err, ErrReadOnly)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("CheckReadOnly() error = %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package conn
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ConnectionTypeRegistration describes everything the generic code needs to know about a connection type.
+// Each connection type registers itself with RegisterConnectionType, usually from an init function
+// in the file that defines the type.
+type ConnectionTypeRegistration struct {
+	// MainType is the main type of the connection.
+	// Example: "Database", "Cloud", "Kubernetes".
+	MainType string
+
+	// DecodeType decodes the "type" field of a stored connection.
+	DecodeType func(data json.RawMessage) (ConnectionType, error)
+
+	// DecodeDetails decodes the "details" field of a stored connection.
+	DecodeDetails func(data json.RawMessage) (ConnectionDetails, error)
+
+	// New creates the implementation of the ConnectionInterface for the given connection.
+	// Subtypes are resolved by the connection type itself.
+	New func(connection Connection) (ConnectionInterface, error)
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]ConnectionTypeRegistration{}
+)
+
+// RegisterConnectionType registers a connection type.
+// It panics if the registration is incomplete or the main type is already registered.
+func RegisterConnectionType(registration ConnectionTypeRegistration) {
+	if registration.MainType == "" || registration.DecodeType == nil || registration.DecodeDetails == nil || registration.New == nil {
+		panic(fmt.Sprintf("incomplete registration for connection type %q", registration.MainType))
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	key := strings.ToLower(registration.MainType)
+	if _, exists := registry[key]; exists {
+		panic(fmt.Sprintf("connection type %q is already registered", registration.MainType))
+	}
+	registry[key] = registration
+}
+
+// GetConnectionTypeRegistration returns the registration of the given main type.
+// The lookup is case-insensitive.
+func GetConnectionTypeRegistration(mainType string) (ConnectionTypeRegistration, error) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	registration, ok := registry[strings.ToLower(mainType)]
+	if !ok {
+		return ConnectionTypeRegistration{}, fmt.Errorf("unknown main type: %s", mainType)
+	}
+	return registration, nil
+}
+
+// AvailableConnectionTypes is a list of available connection types.
+func AvailableConnectionTypes() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	connectionTypes := make([]string, 0, len(registry))
+	for _, registration := range registry {
+		connectionTypes = append(connectionTypes, registration.MainType)
+	}
+	sort.Strings(connectionTypes)
+
+	return connectionTypes
+}
+
+// DecodeTypeAs returns a DecodeType function that decodes the type field into T.
+func DecodeTypeAs[T ConnectionType]() func(data json.RawMessage) (ConnectionType, error) {
+	return func(data json.RawMessage) (ConnectionType, error) {
+		var connectionType T
+		if err := json.Unmarshal(data, &connectionType); err != nil {
+			return nil, err
+		}
+		return connectionType, nil
+	}
+}
+
+// DecodeDetailsAs returns a DecodeDetails function that decodes the details field into T.
+func DecodeDetailsAs[T ConnectionDetails]() func(data json.RawMessage) (ConnectionDetails, error) {
+	return func(data json.RawMessage) (ConnectionDetails, error) {
+		var details T
+		if err := json.Unmarshal(data, &details); err != nil {
+			return nil, err
+		}
+		return details, nil
+	}
+}
@@ -0,0 +1,60 @@
+package conn
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestConnection_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		conn    Connection
+		wantErr bool
+	}{
+		{
+			name: "Test UnmarshalJSON with database connection",
+			conn: NewDatabaseConnection("db", PostgreSQLDatabaseConnection, "host=localhost"),
+		},
+		{
+			name: "Test UnmarshalJSON with kubernetes connection",
+			conn: NewKubernetesConnection("k8s", "test-context", "dev"),
+		},
+		{
+			name: "Test UnmarshalJSON with cloud connection",
+			conn: NewAzureCloudConnection("azure", AzureSubscription{ID: "sub-id"}),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.conn)
+			if err != nil {
+				t.Fatalf("json.Marshal() error = %v", err)
+			}
+
+			var got Connection
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatalf("json.Unmarshal() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.conn) {
+				t.Errorf("json.Unmarshal() = %v, want %v", got, tt.conn)
+			}
+		})
+	}
+}
+
+func TestConnection_UnmarshalJSON_UnknownType(t *testing.T) {
+	data := []byte(`{"name":"test","type":{"mainType":"Unknown"},"details":{}}`)
+
+	var got Connection
+	if err := json.Unmarshal(data, &got); err == nil {
+		t.Errorf("json.Unmarshal() expected error for unknown main type")
+	}
+}
+
+func TestAvailableConnectionTypes(t *testing.T) {
+	want := []string{ConnectionTypeCloud, ConnectionTypeDatabase, ConnectionTypeKubernetes, ConnectionTypePlugin}
+	if got := AvailableConnectionTypes(); !reflect.DeepEqual(got, want) {
+		t.Errorf("AvailableConnectionTypes() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,642 @@
+package conn
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+// NullValue is how NULL values are shown.
+const NullValue = "NULL"
+
+// DefaultRowLimit is how many rows of a result are fetched at once, unless the connection sets RowLimit.
+const DefaultRowLimit = 500
+
+// Column is a column of a ResultSet.
+type Column struct {
+	// Name of the column.
+	Name string `json:"name"`
+
+	// Type is the type of the column as reported by the source.
+	// Example: "INT4", "TIMESTAMPTZ" for databases, "string", "number" for JSON output.
+	// Empty if unknown.
+	Type string `json:"type,omitempty"`
+}
+
+// ResultSet is the result of an executed command.
+//
+// Values in Rows are one of: nil (NULL), string, int64, float64, bool, time.Time,
+// json.Number (exact numerics) or json.RawMessage (JSON documents, nested objects and arrays).
+// Binary values are converted to their "\x" prefixed hex form.
+type ResultSet struct {
+	// Columns of the result, in order.
+	Columns []Column `json:"columns"`
+
+	// Rows of the result. Every row has a value for every column.
+	Rows [][]interface{} `json:"rows"`
+
+	// Text is the output of commands that don't produce tabular output,
+	// like `kubectl describe` or `az ... -o yaml`. Columns and Rows are empty if it is set.
+	Text string `json:"text,omitempty"`
+
+	// source streams the rest of the result, if it was not read completely.
+	source rowSource
+
+	// peeked is the row read ahead by fetch, so HasMore only reports rows that exist.
+	peeked []interface{}
+
+	// limit is how many rows FetchMore fetches.
+	limit int
+}
+
+// rowSource streams the rows of a result.
+type rowSource interface {
+	// next returns the next row. It may add columns to the result before returning the row.
+	// Text results return the next line as the only value. Returns io.EOF after the last row.
+	next(result *ResultSet) ([]interface{}, error)
+
+	// close stops streaming and releases the resources, like a running command or an open query.
+	close() error
+}
+
+// NewTextResultSet creates a result set for output that has no tabular form.
+func NewTextResultSet(text string) *ResultSet {
+	return &ResultSet{
+		Text: text,
+	}
+}
+
+// newStreamingResultSet fetches the first limit rows of the source into the result.
+// The source is closed once it is read completely, or when the result is closed.
+func newStreamingResultSet(result *ResultSet, source rowSource, limit int) (*ResultSet, error) {
+	result.source = source
+	result.limit = limit
+
+	if err := result.fetch(limit); err != nil {
+		// Failing commands explain the failure in their output.
+		if !result.IsTabular() && strings.TrimSpace(result.Text) != "" {
+			return nil, fmt.Errorf("%w\n%s", err, strings.TrimSpace(result.Text))
+		}
+		return nil, err
+	}
+	return result, nil
+}
+
+// HasMore returns true if there are rows left to fetch with FetchMore.
+func (r *ResultSet) HasMore() bool {
+	return r.source != nil
+}
+
+// FetchMore fetches the next rows of a result that was not read completely.
+func (r *ResultSet) FetchMore() error {
+	return r.fetch(r.limit)
+}
+
+// Close stops fetching the result. Rows fetched so far are kept.
+func (r *ResultSet) Close() error {
+	if r.source == nil {
+		return nil
+	}
+
+	err := r.source.close()
+	r.source = nil
+	r.peeked = nil
+	return err
+}
+
+// fetch appends up to limit rows from the source.
+func (r *ResultSet) fetch(limit int) error {
+	if limit <= 0 {
+		limit = DefaultRowLimit
+	}
+
+	for i := 0; i < limit && r.source != nil; i++ {
+		row := r.peeked
+		r.peeked = nil
+		if row == nil {
+			var err error
+			if row, err = r.next(); err != nil || row == nil {
+				return err
+			}
+		}
+		r.appendRow(row)
+	}
+
+	// Read ahead, so HasMore is false if the result ends exactly at the limit.
+	if r.source != nil && r.peeked == nil {
+		row, err := r.next()
+		if err != nil {
+			return err
+		}
+		r.peeked = row
+	}
+	return nil
+}
+
+// next reads the next row from the source, closing it at the end or on error.
+// Returns nil without an error at the end.
+func (r *ResultSet) next() ([]interface{}, error) {
+	row, err := r.source.next(r)
+	if err == io.EOF {
+		return nil, r.Close()
+	}
+	if err != nil {
+		_ = r.Close()
+		return nil, err
+	}
+	return row, nil
+}
+
+// appendRow appends a row, or a line of text to results that are not tabular.
+func (r *ResultSet) appendRow(row []interface{}) {
+	if !r.IsTabular() {
+		r.Text += FormatValue(row[0]) + "\n"
+		return
+	}
+
+	for len(row) < len(r.Columns) {
+		row = append(row, nil)
+	}
+	r.Rows = append(r.Rows, row)
+}
+
+// addColumn adds a column, setting it to NULL in the rows fetched so far.
+func (r *ResultSet) addColumn(column Column) int {
+	r.Columns = append(r.Columns, column)
+	for i := range r.Rows {
+		r.Rows[i] = append(r.Rows[i], nil)
+	}
+	return len(r.Columns) - 1
+}
+
+// IsTabular returns true if the result has columns.
+func (r *ResultSet) IsTabular() bool {
+	return len(r.Columns) > 0
+}
+
+// ColumnNames returns the names of the columns, in order.
+func (r *ResultSet) ColumnNames() []string {
+	names := make([]string, len(r.Columns))
+	for i, column := range r.Columns {
+		names[i] = column.Name
+	}
+	return names
+}
+
+// StringRows returns the rows with every value formatted by FormatValue.
+// Values longer than maxWidth are truncated; 0 means no limit.
+func (r *ResultSet) StringRows(maxWidth int) [][]string {
+	rows := make([][]string, len(r.Rows))
+	for i, row := range r.Rows {
+		rows[i] = make([]string, len(row))
+		for j, value := range row {
+			formatted := FormatValue(value)
+			if maxWidth > 3 && len([]rune(formatted)) > maxWidth {
+				formatted = string([]rune(formatted)[:maxWidth-3]) + "..."
+			}
+			rows[i][j] = formatted
+		}
+	}
+	return rows
+}
+
+// formatTable renders the result as a table with a line between rows.
+func formatTable(result *ResultSet) string {
+	var buffer bytes.Buffer
+	table := tablewriter.NewWriter(&buffer)
+	table.SetAutoWrapText(false)
+	table.SetReflowDuringAutoWrap(false)
+	table.SetRowLine(true)
+	table.SetAutoFormatHeaders(false)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetHeader(result.ColumnNames())
+
+	for _, row := range result.StringRows(0) {
+		table.Append(row)
+	}
+	table.Render()
+
+	return buffer.String()
+}
+
+// FormatValue formats a value of a ResultSet for display.
+func FormatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return NullValue
+	case string:
+		return v
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case json.RawMessage:
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, v); err != nil {
+			return string(v)
+		}
+		return compact.String()
+	case []byte:
+		return formatBytes(v)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// ConvertDatabaseValue converts a value scanned from a database column of the given type into a ResultSet value.
+func ConvertDatabaseValue(databaseType string, value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []byte:
+		switch strings.ToUpper(databaseType) {
+		case "BYTEA", "BLOB", "BINARY", "VARBINARY", "LONGBLOB", "MEDIUMBLOB", "TINYBLOB":
+			return formatBytes(v)
+		case "JSON", "JSONB":
+			return json.RawMessage(append([]byte{}, v...))
+		case "NUMERIC", "DECIMAL", "MONEY":
+			return json.Number(v)
+		default:
+			return string(v)
+		}
+	case int:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case float32:
+		return float64(v)
+	default:
+		return v
+	}
+}
+
+// formatBytes returns the hex form of binary values, like PostgreSQL shows bytea.
+func formatBytes(value []byte) string {
+	return `\x` + hex.EncodeToString(value)
+}
+
+// ParseJSONResult converts JSON output, like the default output of `az`, into a result set.
+// An array of objects becomes one row per object, a single object becomes one row,
+// with columns in the order keys first appear. Any other output is returned as text.
+func ParseJSONResult(output []byte) *ResultSet {
+	result, err := NewJSONResultSet(io.NopCloser(bytes.NewReader(output)), math.MaxInt)
+	if err != nil {
+		return NewTextResultSet(string(output))
+	}
+	return result
+}
+
+// NewJSONResultSet streams JSON output into a result set like ParseJSONResult, fetching the first limit rows.
+// The output is closed once it is read completely, or when the result is closed.
+func NewJSONResultSet(output io.ReadCloser, limit int) (*ResultSet, error) {
+	reader := bufio.NewReader(output)
+
+	first, err := peekNonSpace(reader)
+	if err != nil && err != io.EOF {
+		output.Close()
+		return nil, err
+	}
+
+	switch first {
+	case '[':
+		source := &jsonArraySource{
+			output:  output,
+			reader:  reader,
+			decoder: json.NewDecoder(reader),
+			indexes: map[string]int{},
+		}
+		return newStreamingResultSet(&ResultSet{Columns: []Column{}, Rows: [][]interface{}{}}, source, limit)
+
+	case '{':
+		// A single object is one row; it is read at once.
+		defer output.Close()
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			return nil, err
+		}
+
+		source := &jsonArraySource{
+			reader:  bufio.NewReader(bytes.NewReader(data)),
+			indexes: map[string]int{},
+			single:  true,
+		}
+		source.decoder = json.NewDecoder(source.reader)
+		result, err := newStreamingResultSet(&ResultSet{Columns: []Column{}, Rows: [][]interface{}{}}, source, limit)
+		if err != nil {
+			return NewTextResultSet(string(data)), nil
+		}
+		return result, nil
+
+	default:
+		return newStreamingResultSet(&ResultSet{}, &textSource{output: output, reader: reader}, limit)
+	}
+}
+
+// peekNonSpace skips leading white space and returns the next byte without consuming it.
+func peekNonSpace(reader *bufio.Reader) (byte, error) {
+	for {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		if b != ' ' && b != '\t' && b != '\n' && b != '\r' {
+			return b, reader.UnreadByte()
+		}
+	}
+}
+
+// jsonArraySource streams the objects of a JSON array, or a single object, as rows.
+type jsonArraySource struct {
+	output  io.Closer
+	reader  *bufio.Reader
+	decoder *json.Decoder
+
+	// indexes maps keys to their columns.
+	indexes map[string]int
+
+	// single is true if the output is a single object instead of an array.
+	single bool
+
+	started bool
+}
+
+func (s *jsonArraySource) next(result *ResultSet) ([]interface{}, error) {
+	if !s.started {
+		s.started = true
+		if !s.single {
+			if _, err := s.decoder.Token(); err != nil {
+				return nil, err
+			}
+		}
+	} else if s.single {
+		return nil, io.EOF
+	}
+
+	if !s.single && !s.decoder.More() {
+		if _, err := s.decoder.Token(); err != nil {
+			return nil, err
+		}
+		// Read the rest of the output, which reports if the command failed.
+		if _, err := io.Copy(io.Discard, s.reader); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+
+	var object json.RawMessage
+	if err := s.decoder.Decode(&object); err != nil {
+		return nil, err
+	}
+
+	keys, values, err := decodeOrderedObject(object)
+	if err != nil {
+		return nil, err
+	}
+
+	row := make([]interface{}, len(result.Columns))
+	for i, key := range keys {
+		index, ok := s.indexes[key]
+		if !ok {
+			index = result.addColumn(Column{Name: key, Type: jsonType(values[i])})
+			s.indexes[key] = index
+			row = append(row, nil)
+		}
+		row[index] = values[i]
+	}
+	return row, nil
+}
+
+func (s *jsonArraySource) close() error {
+	if s.output == nil {
+		return nil
+	}
+	return s.output.Close()
+}
+
+// textSource streams output that has no tabular form line by line.
+type textSource struct {
+	output io.Closer
+	reader *bufio.Reader
+
+	// err is returned after the last line was returned.
+	err error
+}
+
+func (s *textSource) next(result *ResultSet) ([]interface{}, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	line, err := s.reader.ReadString('\n')
+	if err != nil {
+		if line == "" {
+			return nil, err
+		}
+		s.err = err
+	}
+	return []interface{}{strings.TrimSuffix(line, "\n")}, nil
+}
+
+func (s *textSource) close() error {
+	return s.output.Close()
+}
+
+// decodeOrderedObject decodes a JSON object, keeping the order of its keys.
+func decodeOrderedObject(data []byte) ([]string, []interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, nil, err
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '{' {
+		return nil, nil, fmt.Errorf("expected a JSON object")
+	}
+
+	var keys []string
+	var values []interface{}
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+		key, ok := token.(string)
+		if !ok {
+			return nil, nil, fmt.Errorf("expected an object key")
+		}
+
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			return nil, nil, err
+		}
+
+		keys = append(keys, key)
+		values = append(values, ConvertJSONValue(raw))
+	}
+
+	if _, err := decoder.Token(); err != nil && err != io.EOF {
+		return nil, nil, err
+	}
+
+	return keys, values, nil
+}
+
+// ConvertJSONValue converts a JSON value into a ResultSet value.
+// Scalars are converted to their Go types, objects and arrays are kept as JSON.
+func ConvertJSONValue(raw json.RawMessage) interface{} {
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) == 0 {
+		return nil
+	}
+
+	switch trimmed[0] {
+	case 'n':
+		return nil
+	case 't', 'f':
+		return trimmed[0] == 't'
+	case '"':
+		var s string
+		if err := json.Unmarshal(trimmed, &s); err != nil {
+			return string(trimmed)
+		}
+		return s
+	case '{', '[':
+		return json.RawMessage(trimmed)
+	default:
+		if i, err := strconv.ParseInt(string(trimmed), 10, 64); err == nil {
+			return i
+		}
+		return json.Number(trimmed)
+	}
+}
+
+// jsonType returns the JSON type name of a converted JSON value.
+func jsonType(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case int64, json.Number:
+		return "number"
+	case json.RawMessage:
+		if bytes.HasPrefix(v, []byte("[")) {
+			return "array"
+		}
+		return "object"
+	default:
+		return ""
+	}
+}
+
+// ParseColumnarText converts column aligned output, like the default output of `kubectl get`, into a result set.
+// Columns are found from the header, whose names are upper case and separated by at least two spaces.
+// Any other output is returned as text.
+func ParseColumnarText(output []byte) *ResultSet {
+	result, err := NewColumnarTextResultSet(io.NopCloser(bytes.NewReader(output)), math.MaxInt)
+	if err != nil {
+		return NewTextResultSet(string(output))
+	}
+	return result
+}
+
+// NewColumnarTextResultSet streams column aligned output into a result set like ParseColumnarText,
+// fetching the first limit rows. The output is closed once it is read completely, or when the result is closed.
+func NewColumnarTextResultSet(output io.ReadCloser, limit int) (*ResultSet, error) {
+	reader := bufio.NewReader(output)
+
+	header, err := reader.ReadString('\n')
+	if err != nil && err != io.EOF {
+		output.Close()
+		return nil, err
+	}
+
+	// Output that isn't column aligned is streamed as text, starting with the line already read.
+	source := &textSource{output: output, reader: bufio.NewReader(io.MultiReader(strings.NewReader(header), reader))}
+
+	header = strings.TrimRight(header, "\r\n")
+	if strings.IndexFunc(header, unicode.IsLetter) < 0 || header != strings.ToUpper(header) || strings.ContainsAny(header, "{}[]:") {
+		return newStreamingResultSet(&ResultSet{}, source, limit)
+	}
+
+	starts := columnStarts(header)
+	result := &ResultSet{
+		Columns: make([]Column, len(starts)),
+		Rows:    [][]interface{}{},
+	}
+	for i := range starts {
+		result.Columns[i] = Column{Name: columnField(header, starts, i)}
+	}
+
+	return newStreamingResultSet(result, &columnarSource{textSource: textSource{output: output, reader: reader}, starts: starts}, limit)
+}
+
+// columnarSource streams the lines of column aligned output as rows.
+type columnarSource struct {
+	textSource
+
+	// starts are the offsets at which the columns start.
+	starts []int
+}
+
+func (s *columnarSource) next(result *ResultSet) ([]interface{}, error) {
+	for {
+		line, err := s.textSource.next(result)
+		if err != nil {
+			return nil, err
+		}
+
+		text := line[0].(string)
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+
+		row := make([]interface{}, len(s.starts))
+		for i := range s.starts {
+			row[i] = columnField(text, s.starts, i)
+		}
+		return row, nil
+	}
+}
+
+// columnStarts returns the offsets at which the columns of a header start.
+func columnStarts(header string) []int {
+	var starts []int
+	spaces := 2
+	for i, r := range header {
+		if r == ' ' || r == '\t' {
+			spaces++
+			continue
+		}
+		if spaces >= 2 {
+			starts = append(starts, i)
+		}
+		spaces = 0
+	}
+	return starts
+}
+
+// columnField returns the value of the i-th column in the line.
+func columnField(line string, starts []int, i int) string {
+	start := starts[i]
+	if start >= len(line) {
+		return ""
+	}
+
+	end := len(line)
+	if i+1 < len(starts) && starts[i+1] < end {
+		end = starts[i+1]
+	}
+	return strings.TrimSpace(line[start:end])
+}
@@ -0,0 +1,243 @@
+package conn
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConvertDatabaseValue(t *testing.T) {
+	timestamp := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		databaseType string
+		value        interface{}
+		want         interface{}
+		wantString   string
+	}{
+		{
+			name:       "Test ConvertDatabaseValue with NULL",
+			value:      nil,
+			want:       nil,
+			wantString: "NULL",
+		},
+		{
+			name:         "Test ConvertDatabaseValue with text",
+			databaseType: "TEXT",
+			value:        []byte("hello"),
+			want:         "hello",
+			wantString:   "hello",
+		},
+		{
+			name:         "Test ConvertDatabaseValue with bytea",
+			databaseType: "BYTEA",
+			value:        []byte{0xde, 0xad, 0xbe, 0xef},
+			want:         `\xdeadbeef`,
+			wantString:   `\xdeadbeef`,
+		},
+		{
+			name:         "Test ConvertDatabaseValue with numeric",
+			databaseType: "NUMERIC",
+			value:        []byte("12.50"),
+			want:         json.Number("12.50"),
+			wantString:   "12.50",
+		},
+		{
+			name:         "Test ConvertDatabaseValue with jsonb",
+			databaseType: "JSONB",
+			value:        []byte(`{"a": 1}`),
+			want:         json.RawMessage(`{"a": 1}`),
+			wantString:   `{"a":1}`,
+		},
+		{
+			name:         "Test ConvertDatabaseValue with timestamp",
+			databaseType: "TIMESTAMPTZ",
+			value:        timestamp,
+			want:         timestamp,
+			wantString:   "2024-05-01T12:30:00Z",
+		},
+		{
+			name:         "Test ConvertDatabaseValue with integer",
+			databaseType: "INT4",
+			value:        int64(42),
+			want:         int64(42),
+			wantString:   "42",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ConvertDatabaseValue(tt.databaseType, tt.value)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ConvertDatabaseValue() = %#v, want %#v", got, tt.want)
+			}
+			if s := FormatValue(got); s != tt.wantString {
+				t.Errorf("FormatValue() = %v, want %v", s, tt.wantString)
+			}
+		})
+	}
+}
+
+func TestParseJSONResult(t *testing.T) {
+	tests := []struct {
+		name        string
+		output      string
+		wantColumns []Column
+		wantRows    [][]interface{}
+		wantText    string
+	}{
+		{
+			name:   "Test ParseJSONResult with array of objects",
+			output: `[{"name": "rg1", "location": "eastus", "tags": {"env": "dev"}}, {"name": "rg2", "location": "westus", "count": 2}]`,
+			wantColumns: []Column{
+				{Name: "name", Type: "string"},
+				{Name: "location", Type: "string"},
+				{Name: "tags", Type: "object"},
+				{Name: "count", Type: "number"},
+			},
+			wantRows: [][]interface{}{
+				{"rg1", "eastus", json.RawMessage(`{"env": "dev"}`), nil},
+				{"rg2", "westus", nil, int64(2)},
+			},
+		},
+		{
+			name:        "Test ParseJSONResult with object",
+			output:      `{"id": "sub", "isDefault": true}`,
+			wantColumns: []Column{{Name: "id", Type: "string"}, {Name: "isDefault", Type: "boolean"}},
+			wantRows:    [][]interface{}{{"sub", true}},
+		},
+		{
+			name:     "Test ParseJSONResult with text",
+			output:   "Name    Location\nrg1     eastus\n",
+			wantText: "Name    Location\nrg1     eastus\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseJSONResult([]byte(tt.output))
+			if !reflect.DeepEqual(got.Columns, tt.wantColumns) {
+				t.Errorf("ParseJSONResult() columns = %v, want %v", got.Columns, tt.wantColumns)
+			}
+			if !reflect.DeepEqual(got.Rows, tt.wantRows) {
+				t.Errorf("ParseJSONResult() rows = %v, want %v", got.Rows, tt.wantRows)
+			}
+			if got.Text != tt.wantText {
+				t.Errorf("ParseJSONResult() text = %v, want %v", got.Text, tt.wantText)
+			}
+		})
+	}
+}
+
+func TestParseColumnarText(t *testing.T) {
+	output := "NAME    READY   STATUS    NOMINATED NODE\n" +
+		"web-1   1/1     Running   <none>\n" +
+		"web-2   0/1     Pending\n"
+
+	got := ParseColumnarText([]byte(output))
+
+	wantColumns := []string{"NAME", "READY", "STATUS", "NOMINATED NODE"}
+	if !reflect.DeepEqual(got.ColumnNames(), wantColumns) {
+		t.Errorf("ParseColumnarText() columns = %v, want %v", got.ColumnNames(), wantColumns)
+	}
+
+	wantRows := [][]interface{}{
+		{"web-1", "1/1", "Running", "<none>"},
+		{"web-2", "0/1", "Pending", ""},
+	}
+	if !reflect.DeepEqual(got.Rows, wantRows) {
+		t.Errorf("ParseColumnarText() rows = %v, want %v", got.Rows, wantRows)
+	}
+
+	text := "Name:         web-1\nNamespace:    default\n"
+	if got := ParseColumnarText([]byte(text)); got.IsTabular() || got.Text != text {
+		t.Errorf("ParseColumnarText() = %v, want text %v", got, text)
+	}
+}
+
+func TestResultSet_FetchMore(t *testing.T) {
+	output := `[{"name": "a"}, {"name": "b", "size": 2}, {"name": "c"}]`
+	result, err := NewJSONResultSet(io.NopCloser(strings.NewReader(output)), 1)
+	if err != nil {
+		t.Fatalf("NewJSONResultSet() error = %v", err)
+	}
+
+	// The row read ahead adds its columns to the rows fetched so far.
+	wantColumns := []string{"name", "size"}
+	if !reflect.DeepEqual(result.ColumnNames(), wantColumns) {
+		t.Errorf("NewJSONResultSet() columns = %v, want %v", result.ColumnNames(), wantColumns)
+	}
+	if !reflect.DeepEqual(result.Rows, [][]interface{}{{"a", nil}}) {
+		t.Errorf("NewJSONResultSet() rows = %v", result.Rows)
+	}
+	if !result.HasMore() {
+		t.Fatalf("HasMore() = false, want true")
+	}
+
+	if err := result.FetchMore(); err != nil {
+		t.Fatalf("FetchMore() error = %v", err)
+	}
+	if err := result.FetchMore(); err != nil {
+		t.Fatalf("FetchMore() error = %v", err)
+	}
+	wantRows := [][]interface{}{{"a", nil}, {"b", int64(2)}, {"c", nil}}
+	if !reflect.DeepEqual(result.Rows, wantRows) {
+		t.Errorf("FetchMore() rows = %v, want %v", result.Rows, wantRows)
+	}
+	if result.HasMore() {
+		t.Errorf("HasMore() = true, want false")
+	}
+}
+
+func TestResultSet_StreamCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires yes")
+	}
+
+	// yes never ends; only the fetched lines are read before it is stopped.
+	output, err := StartPipeline([][]string{{"yes"}})
+	if err != nil {
+		t.Fatalf("StartPipeline() error = %v", err)
+	}
+
+	result, err := NewColumnarTextResultSet(output, 100)
+	if err != nil {
+		t.Fatalf("NewColumnarTextResultSet() error = %v", err)
+	}
+	if result.IsTabular() || strings.Count(result.Text, "y\n") != 100 || !result.HasMore() {
+		t.Fatalf("NewColumnarTextResultSet() = %d lines, more %v, want 100 lines and more", strings.Count(result.Text, "\n"), result.HasMore())
+	}
+
+	if err := result.FetchMore(); err != nil {
+		t.Fatalf("FetchMore() error = %v", err)
+	}
+	if got := strings.Count(result.Text, "\n"); got != 200 {
+		t.Errorf("FetchMore() = %d lines, want 200", got)
+	}
+
+	if err := result.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+	if result.HasMore() {
+		t.Errorf("HasMore() = true after Close")
+	}
+}
+
+func TestResultSet_CommandFailure(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires sh")
+	}
+
+	output, err := StartPipeline([][]string{{"sh", "-c", "echo 'Error from server (NotFound): pods \"web\" not found'; exit 1"}})
+	if err != nil {
+		t.Fatalf("StartPipeline() error = %v", err)
+	}
+
+	_, err = NewColumnarTextResultSet(output, 100)
+	if err == nil || !strings.Contains(err.Error(), "NotFound") {
+		t.Errorf("NewColumnarTextResultSet() error = %v, want the output of the failed command", err)
+	}
+}
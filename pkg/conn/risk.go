@@ -0,0 +1,151 @@
+package conn
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Risk is how much damage running a command can do.
+type Risk int
+
+const (
+	// RiskRead is for commands that only read, like SELECT or `kubectl get`.
+	RiskRead Risk = iota
+
+	// RiskWrite is for commands that change data or resources in a way that can be undone.
+	RiskWrite
+
+	// RiskDestructive is for commands that delete data or resources, or could cause an outage.
+	RiskDestructive
+)
+
+// AIRiskReviewEnvVar enables an AI second opinion on the risk of generated commands when set to a true value.
+const AIRiskReviewEnvVar = "POPS_AI_RISK_REVIEW"
+
+func (r Risk) String() string {
+	switch r {
+	case RiskRead:
+		return "read"
+	case RiskWrite:
+		return "write"
+	case RiskDestructive:
+		return "destructive"
+	default:
+		return fmt.Sprintf("Risk(%d)", int(r))
+	}
+}
+
+// ParseRisk parses "read", "write" or "destructive".
+func ParseRisk(value string) (Risk, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "read":
+		return RiskRead, nil
+	case "write":
+		return RiskWrite, nil
+	case "destructive":
+		return RiskDestructive, nil
+	default:
+		return RiskWrite, fmt.Errorf("unknown risk: %s", value)
+	}
+}
+
+// RiskAssessment is the risk of a command and why.
+type RiskAssessment struct {
+	Risk Risk
+
+	// Reason explains the risk.
+	// Example: "DELETE without WHERE deletes every row".
+	Reason string
+
+	// Target is the name that has to be typed to confirm a destructive command,
+	// like the dropped table or the deleted namespace. It is the connection name if there is no better one.
+	Target string
+}
+
+// riskOf returns an assessment for the connection. An empty target is replaced by the connection name.
+func riskOf(connection Connection, risk Risk, reason, target string) RiskAssessment {
+	if target == "" {
+		target = connection.Name
+	}
+	return RiskAssessment{
+		Risk:   risk,
+		Reason: reason,
+		Target: target,
+	}
+}
+
+// destructiveTools are tools that delete or stop things whatever their arguments are.
+var destructiveTools = []string{"rm", "rmdir", "shred", "dd", "kill", "pkill"}
+
+// destructiveToolVerbs are commands of other tools, like `helm uninstall`, that delete things.
+var destructiveToolVerbs = []string{"delete", "uninstall", "destroy", "remove", "rm", "purge", "drop", "terminate"}
+
+// classifyOtherTool rates a pipeline stage that doesn't run the connection's own tool.
+// Filters like jq only read; pops can't tell what other tools do, so they are rated as writes at least.
+func classifyOtherTool(connection Connection, stage []string) RiskAssessment {
+	if checkReadOnlyFilter(connection, stage) == nil {
+		return riskOf(connection, RiskRead, fmt.Sprintf("`%s` only filters the output", stage[0]), "")
+	}
+	if containsFold(destructiveTools, stage[0]) {
+		return riskOf(connection, RiskDestructive, fmt.Sprintf("`%s` deletes or stops things", stage[0]), "")
+	}
+
+	var positional []string
+	for _, arg := range stage[1:] {
+		if !strings.HasPrefix(arg, "-") {
+			positional = append(positional, arg)
+		}
+	}
+	for i, arg := range positional {
+		if containsFold(destructiveToolVerbs, arg) {
+			// A single name after the command, like "payments" in `helm uninstall payments`, is typed to confirm.
+			target := ""
+			if len(positional) == i+2 {
+				target = positional[i+1]
+			}
+			return riskOf(connection, RiskDestructive, fmt.Sprintf("`%s %s` deletes things", stage[0], arg), target)
+		}
+	}
+	return riskOf(connection, RiskWrite, fmt.Sprintf("pops can't tell what `%s` changes", stage[0]), "")
+}
+
+// maxRisk returns the riskier of two assessments, preferring the first one if they are equal.
+func maxRisk(a, b RiskAssessment) RiskAssessment {
+	if b.Risk > a.Risk {
+		return b
+	}
+	return a
+}
+
+// AIRiskReviewEnabled reports whether generated commands get an AI second opinion on their risk.
+func AIRiskReviewEnabled() bool {
+	enabled, err := strconv.ParseBool(os.Getenv(AIRiskReviewEnvVar))
+	return err == nil && enabled
+}
+
+// ReviewRisk asks the AI model for a second opinion on the risk of a command.
+// The AI can only raise the risk; if it rates the command lower or fails, the static assessment is kept.
+func ReviewRisk(c ConnectionInterface, command string, assessment RiskAssessment) RiskAssessment {
+	aiModel, err := newAIModel(c.GetConnection(), c.CommandType(), c.GetContext())
+	if err != nil {
+		return assessment
+	}
+
+	response, err := aiModel.ClassifyCommand(command)
+	if err != nil {
+		return assessment
+	}
+
+	risk, err := ParseRisk(response.Risk)
+	if err != nil || risk <= assessment.Risk {
+		return assessment
+	}
+
+	return RiskAssessment{
+		Risk:   risk,
+		Reason: "AI review: " + response.Reason,
+		Target: assessment.Target,
+	}
+}
@@ -0,0 +1,167 @@
+package conn
+
+import (
+	"testing"
+)
+
+func TestClassifyCommand(t *testing.T) {
+	kubernetes := NewKubernetesConnection("k8s", "dev")
+	azure := NewAzureCloudConnection("azure", AzureSubscription{ID: "sub-id"})
+	postgres := NewDatabaseConnection("db", PostgreSQLDatabaseConnection, "postgres://localhost/db")
+
+	tests := []struct {
+		name       string
+		connection ConnectionInterface
+		command    string
+		wantRisk   Risk
+		wantTarget string
+	}{
+		{
+			name:       "Test ClassifyCommand SELECT",
+			connection: NewPostgreSQLConnection(&postgres),
+			command:    "SELECT * FROM users",
+			wantRisk:   RiskRead,
+			wantTarget: "db",
+		},
+		{
+			name:       "Test ClassifyCommand DELETE with WHERE",
+			connection: NewPostgreSQLConnection(&postgres),
+			command:    "DELETE FROM users WHERE id = 1",
+			wantRisk:   RiskWrite,
+			wantTarget: "db",
+		},
+		{
+			name:       "Test ClassifyCommand DELETE without WHERE",
+			connection: NewPostgreSQLConnection(&postgres),
+			command:    "delete from users;",
+			wantRisk:   RiskDestructive,
+			wantTarget: "users",
+		},
+		{
+			name:       "Test ClassifyCommand DROP DATABASE",
+			connection: NewPostgreSQLConnection(&postgres),
+			command:    "SELECT 1; DROP DATABASE IF EXISTS shop",
+			wantRisk:   RiskDestructive,
+			wantTarget: "shop",
+		},
+		{
+			name:       "Test ClassifyCommand EXPLAIN ANALYZE",
+			connection: NewPostgreSQLConnection(&postgres),
+			command:    "EXPLAIN ANALYZE UPDATE users SET active = false",
+			wantRisk:   RiskDestructive,
+			wantTarget: "users",
+		},
+		{
+			name:       "Test ClassifyCommand kubectl get",
+			connection: NewKubernetesConnectionImpl(&kubernetes),
+			command:    "kubectl get pods -n payments",
+			wantRisk:   RiskRead,
+			wantTarget: "k8s",
+		},
+		{
+			name:       "Test ClassifyCommand kubectl scale",
+			connection: NewKubernetesConnectionImpl(&kubernetes),
+			command:    "kubectl scale deployment/api --replicas=3",
+			wantRisk:   RiskWrite,
+			wantTarget: "k8s",
+		},
+		{
+			name:       "Test ClassifyCommand kubectl delete namespace",
+			connection: NewKubernetesConnectionImpl(&kubernetes),
+			command:    "kubectl delete ns payments --wait=false",
+			wantRisk:   RiskDestructive,
+			wantTarget: "payments",
+		},
+		{
+			name:       "Test ClassifyCommand kubectl delete by selector",
+			connection: NewKubernetesConnectionImpl(&kubernetes),
+			command:    "kubectl delete pods -l app=api -n prod",
+			wantRisk:   RiskDestructive,
+			wantTarget: "k8s",
+		},
+		{
+			name:       "Test ClassifyCommand az list",
+			connection: NewAzureConnection(&azure),
+			command:    "az vm list -g rg",
+			wantRisk:   RiskRead,
+			wantTarget: "azure",
+		},
+		{
+			name:       "Test ClassifyCommand az create",
+			connection: NewAzureConnection(&azure),
+			command:    "az group create -n rg -l eastus",
+			wantRisk:   RiskWrite,
+			wantTarget: "azure",
+		},
+		{
+			name:       "Test ClassifyCommand az delete",
+			connection: NewAzureConnection(&azure),
+			command:    "az group delete --name core-network --yes",
+			wantRisk:   RiskDestructive,
+			wantTarget: "core-network",
+		},
+		{
+			name:       "Test ClassifyCommand kubectl auth can-i",
+			connection: NewKubernetesConnectionImpl(&kubernetes),
+			command:    "kubectl auth can-i delete pods -n prod",
+			wantRisk:   RiskRead,
+			wantTarget: "k8s",
+		},
+		{
+			name:       "Test ClassifyCommand kubectl auth reconcile",
+			connection: NewKubernetesConnectionImpl(&kubernetes),
+			command:    "kubectl auth reconcile -f rbac.yaml",
+			wantRisk:   RiskWrite,
+			wantTarget: "k8s",
+		},
+		{
+			name:       "Test ClassifyCommand filters after kubectl",
+			connection: NewKubernetesConnectionImpl(&kubernetes),
+			command:    "kubectl get pods -o json | jq .items | head -n 3",
+			wantRisk:   RiskRead,
+			wantTarget: "k8s",
+		},
+		{
+			name:       "Test ClassifyCommand other tools",
+			connection: NewKubernetesConnectionImpl(&kubernetes),
+			command:    "helm upgrade payments ./chart",
+			wantRisk:   RiskWrite,
+			wantTarget: "k8s",
+		},
+		{
+			name:       "Test ClassifyCommand destructive verbs of other tools",
+			connection: NewKubernetesConnectionImpl(&kubernetes),
+			command:    "helm -n prod uninstall payments",
+			wantRisk:   RiskDestructive,
+			wantTarget: "payments",
+		},
+		{
+			name:       "Test ClassifyCommand other tools in az pipelines",
+			connection: NewAzureConnection(&azure),
+			command:    "az vm list -g rg | xargs rm",
+			wantRisk:   RiskDestructive,
+			wantTarget: "azure",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.connection.ClassifyCommand(tt.command)
+			if got.Risk != tt.wantRisk || got.Target != tt.wantTarget {
+				t.Errorf("ClassifyCommand() = %v (%s), %q, want %v, %q", got.Risk, got.Reason, got.Target, tt.wantRisk, tt.wantTarget)
+			}
+		})
+	}
+}
+
+func TestParseRisk(t *testing.T) {
+	for _, risk := range []Risk{RiskRead, RiskWrite, RiskDestructive} {
+		got, err := ParseRisk(risk.String())
+		if err != nil || got != risk {
+			t.Errorf("ParseRisk(%q) = %v, %v, want %v", risk.String(), got, err, risk)
+		}
+	}
+
+	if _, err := ParseRisk("harmless"); err == nil {
+		t.Errorf("ParseRisk() expected error for unknown risk")
+	}
+}
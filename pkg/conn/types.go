@@ -2,7 +2,10 @@ package conn
 
 import (
 	"encoding/json"
-	"fmt"
+	"time"
+
+	"github.com/prompt-ops/pops/pkg/secret"
+	"github.com/prompt-ops/pops/pkg/settings"
 )
 
 var (
@@ -11,15 +14,6 @@ var (
 	ConnectionTypeKubernetes = "Kubernetes"
 )
 
-// AvailableConnectionTypes is a list of available connection types.
-func AvailableConnectionTypes() []string {
-	return []string{
-		ConnectionTypeCloud,
-		ConnectionTypeDatabase,
-		ConnectionTypeKubernetes,
-	}
-}
-
 type ConnectionType interface {
 	// GetMainType returns the main type of the connection.
 	// Example: "database", "cloud", "kubernetes".
@@ -38,6 +32,34 @@ type ConnectionDetails interface {
 	GetDriver() string
 }
 
+// SecretDetails is implemented by connection details holding secrets, like passwords in connection strings.
+// The secrets are kept in the secret store, and connections.json only keeps references to them.
+type SecretDetails interface {
+	ConnectionDetails
+
+	// HasPlaintextSecrets reports whether the details hold secrets that are not in the secret store yet.
+	HasPlaintextSecrets() bool
+
+	// StoreSecrets saves the secrets held in plain text in the secret store
+	// and returns the details with references to them instead.
+	// connectionName names the secrets; it includes the workspace of connections outside the default workspace.
+	StoreSecrets(connectionName string) (ConnectionDetails, error)
+
+	// SecretRefs returns the references to the stored secrets.
+	SecretRefs() []secret.Ref
+
+	// LoadSecrets returns the details with the stored secrets read from the secret store, in plain text.
+	LoadSecrets() (ConnectionDetails, error)
+
+	// WithoutSecrets returns the details without their secrets, neither in plain text nor as references.
+	// References like ${env:PGPASSWORD} are kept, as they hold no secrets.
+	WithoutSecrets() ConnectionDetails
+
+	// WithoutSecretRefs returns the details without references to the secret store, keeping secrets held in plain text.
+	// Imported details must not refer to the secret store, where the secrets of other connections are kept.
+	WithoutSecretRefs() ConnectionDetails
+}
+
 type Connection struct {
 	// Name of the connection.
 	Name string `json:"name"`
@@ -50,6 +72,53 @@ type Connection struct {
 	// Can include different details based on the connection type.
 	// Like database connection string, cloud credentials, etc.
 	Details ConnectionDetails `json:"details"`
+
+	// AllowPipelines opts the connection in to running generated commands
+	// that pipe into other programs, e.g. `kubectl get pods -o json | jq .items`.
+	AllowPipelines bool `json:"allowPipelines,omitempty"`
+
+	// ExtraTools are executables, besides the connection type's own tool,
+	// that generated commands are allowed to run. Example: "jq", "helm".
+	ExtraTools []string `json:"extraTools,omitempty"`
+
+	// RowLimit is how many rows of a result are fetched at once.
+	// The output.rowLimit setting is used if it is not set.
+	RowLimit int `json:"rowLimit,omitempty"`
+
+	// ReadOnly restricts the connection to commands that don't change anything,
+	// e.g. SELECT queries in read-only transactions or `kubectl get`.
+	ReadOnly bool `json:"readOnly,omitempty"`
+
+	// Description says what the connection is for.
+	Description string `json:"description,omitempty"`
+
+	// Labels are free-form key=value pairs to find connections by, with a Selector.
+	// Example: {"team": "payments", "region": "eu"}.
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Environment is "dev", "staging" or "prod", or empty if it is not set.
+	// The shell shows a banner for prod connections.
+	Environment string `json:"environment,omitempty"`
+
+	// Owner is the person or team responsible for the connection.
+	Owner string `json:"owner,omitempty"`
+
+	// CreatedAt is when the connection was saved for the first time.
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+
+	// LastUsedAt is when the connection was last opened.
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+}
+
+// GetRowLimit returns how many rows of a result are fetched at once.
+func (c Connection) GetRowLimit() int {
+	if c.RowLimit > 0 {
+		return c.RowLimit
+	}
+	if limit := settings.For(c.Name).OutputRowLimit; limit > 0 {
+		return limit
+	}
+	return DefaultRowLimit
 }
 
 // UnmarshalJSON implements custom JSON decoding for the Connection struct.
@@ -76,49 +145,22 @@ func (c *Connection) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
-	switch mainType.MainType {
-	case ConnectionTypeDatabase:
-		var dbType DatabaseConnectionType
-		if err := json.Unmarshal(aux.Type, &dbType); err != nil {
-			return err
-		}
-		c.Type = dbType
-
-		var dbDetails DatabaseConnectionDetails
-		if err := json.Unmarshal(aux.Details, &dbDetails); err != nil {
-			return err
-		}
-		c.Details = dbDetails
-
-	case ConnectionTypeKubernetes:
-		var k8sType KubernetesConnectionType
-		if err := json.Unmarshal(aux.Type, &k8sType); err != nil {
-			return err
-		}
-		c.Type = k8sType
-
-		var k8sDetails KubernetesConnectionDetails
-		if err := json.Unmarshal(aux.Details, &k8sDetails); err != nil {
-			return err
-		}
-		c.Details = k8sDetails
-
-	case ConnectionTypeCloud:
-		var cloudType CloudConnectionType
-		if err := json.Unmarshal(aux.Type, &cloudType); err != nil {
-			return err
-		}
-		c.Type = cloudType
-
-		var cloudDetails CloudConnectionDetails
-		if err := json.Unmarshal(aux.Details, &cloudDetails); err != nil {
-			return err
-		}
-		c.Details = cloudDetails
-
-	default:
-		return fmt.Errorf("unknown main type: %s", mainType.MainType)
+	registration, err := GetConnectionTypeRegistration(mainType.MainType)
+	if err != nil {
+		return err
+	}
+
+	connectionType, err := registration.DecodeType(aux.Type)
+	if err != nil {
+		return err
 	}
+	c.Type = connectionType
+
+	details, err := registration.DecodeDetails(aux.Details)
+	if err != nil {
+		return err
+	}
+	c.Details = details
 
 	return nil
 }
@@ -141,11 +183,22 @@ type ConnectionInterface interface {
 	// GetFormattedContext returns the formatted context for the AI model.
 	GetFormattedContext() (string, error)
 
-	// ExecuteCommand executes the given command and returns the output as byte array.
-	ExecuteCommand(command string) ([]byte, error)
+	// ExportContext returns the information set by the SetContext method so it can be cached.
+	ExportContext() ([]byte, error)
+
+	// ImportContext restores information returned by ExportContext instead of calling SetContext.
+	ImportContext(data []byte) error
+
+	// ExecuteCommand executes the given command and returns its result.
+	ExecuteCommand(command string) (*ResultSet, error)
+
+	// PreviewCommand shows what the command would change without applying it.
+	// Example: running SQL in a transaction that is rolled back, or `kubectl --dry-run=server`.
+	// Returns an error wrapping ErrPreviewNotSupported if the command can't be previewed.
+	PreviewCommand(command string) (string, error)
 
 	// FormatResultAsTable formats the result as a table.
-	FormatResultAsTable(result []byte) (string, error)
+	FormatResultAsTable(result *ResultSet) (string, error)
 
 	// GetCommand gets the command from AI using context and the user prompt.
 	GetCommand(prompt string) (string, error)
@@ -156,4 +209,18 @@ type ConnectionInterface interface {
 	// CommandType returns the type of the command.
 	// Example: "psql", "az", "kubectl".
 	CommandType() string
+
+	// ClassifyCommand rates how risky it is to run the command, using static rules of the connection type.
+	// Example: DROP TABLE is destructive, UPDATE with WHERE is a write, SELECT is a read.
+	ClassifyCommand(command string) RiskAssessment
+
+	// CheckReadOnly returns an error wrapping ErrReadOnly if the connection is read-only
+	// and the command could change anything. ExecuteCommand and PreviewCommand check it too.
+	// Example: only get, describe, logs and top are allowed for kubectl.
+	CheckReadOnly(command string) error
+
+	// AllowedExecutables returns the executables generated commands are allowed to run.
+	// Example: "kubectl" for Kubernetes, "az" for Azure.
+	// Returns nil if commands are not run as executables, like SQL queries.
+	AllowedExecutables() []string
 }
@@ -0,0 +1,4 @@
+package policy
+
+// This package evaluates the rules of the policy file (~/.pops/policy.yaml) against generated commands,
+// so a team can allow, warn about or deny commands per connection before they are confirmed and run.
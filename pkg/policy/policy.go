@@ -0,0 +1,349 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/prompt-ops/pops/pkg/conn"
+	"github.com/prompt-ops/pops/pkg/project"
+	"github.com/prompt-ops/pops/pkg/workspace"
+	"gopkg.in/yaml.v3"
+)
+
+// PathEnvVar is the environment variable to use a different policy file than ~/.pops/policy.yaml.
+const PathEnvVar = "POPS_POLICY_FILE"
+
+// ErrDenied is returned for commands denied by a policy rule.
+var ErrDenied = errors.New("command denied by policy")
+
+// Action is what happens to a command that matches a rule.
+type Action string
+
+const (
+	// ActionAllow runs the command without checking the rules after the matching one.
+	ActionAllow Action = "allow"
+
+	// ActionWarn shows the message of the rule when the command is confirmed.
+	ActionWarn Action = "warn"
+
+	// ActionDeny blocks the command.
+	ActionDeny Action = "deny"
+)
+
+// Policy is a list of rules. The first rule matching a command decides what happens to it.
+type Policy struct {
+	Rules []Rule `yaml:"rules"`
+}
+
+// Rule matches commands and decides what happens to them.
+// All conditions that are set must hold for a command to match.
+type Rule struct {
+	// Name identifies the rule in messages.
+	Name string `yaml:"name"`
+
+	// Connections are the connection names the rule applies to, as glob patterns like "prod-*".
+	// The rule applies to all connections if it is empty.
+	Connections []string `yaml:"connections,omitempty"`
+
+	// Types are the connection types the rule applies to, main types like "Kubernetes" or subtypes like "PostgreSQL".
+	// The rule applies to all connection types if it is empty.
+	Types []string `yaml:"types,omitempty"`
+
+	// Match are regular expressions that must all match the command.
+	Match []string `yaml:"match,omitempty"`
+
+	// Unless are regular expressions that must not match the command.
+	Unless []string `yaml:"unless,omitempty"`
+
+	// Risk is the minimum risk of the command: "read", "write" or "destructive".
+	Risk string `yaml:"risk,omitempty"`
+
+	// Action is "allow", "warn" or "deny".
+	Action Action `yaml:"action"`
+
+	// Message explains the rule to the user.
+	Message string `yaml:"message,omitempty"`
+
+	match   []*regexp.Regexp
+	unless  []*regexp.Regexp
+	minRisk *conn.Risk
+}
+
+// Input is a command to evaluate.
+type Input struct {
+	Connection conn.Connection
+	Command    string
+
+	// Risk is the risk of the command, as classified by the connection.
+	Risk conn.Risk
+}
+
+// Decision is the outcome of evaluating a command.
+type Decision struct {
+	Action Action
+
+	// Rule is the name of the matching rule, or empty if no rule matched.
+	Rule string
+
+	// Message is the message of the matching rule.
+	Message string
+}
+
+// Err returns an error wrapping ErrDenied if the command is denied.
+func (d Decision) Err() error {
+	if d.Action != ActionDeny {
+		return nil
+	}
+	return fmt.Errorf("%w (rule '%s'): %s", ErrDenied, d.Rule, d.Message)
+}
+
+// Path returns the path of the policy file: the one of the current workspace if it has one, otherwise ~/.pops/policy.yaml.
+func Path() string {
+	if value := strings.TrimSpace(os.Getenv(PathEnvVar)); value != "" {
+		return value
+	}
+
+	return workspace.Lookup("policy.yaml")
+}
+
+// Load reads the policy file. Without a policy file every command is allowed.
+func Load() (*Policy, error) {
+	return LoadFile(Path())
+}
+
+// Policies are policies that all apply to commands, like the policy of the workspace and the one of the project.
+type Policies []*Policy
+
+// ProjectPath returns the path of the policy file of the trusted project directory, or "" if there is none.
+// Untrusted project directories are reported when their connections are read, and ignored here.
+func ProjectPath() string {
+	p, err := project.Trusted()
+	if err != nil || p == nil {
+		return ""
+	}
+	return p.Path("policy.yaml")
+}
+
+// LoadAll reads the policy file and the policy file of the trusted project directory.
+func LoadAll() (Policies, error) {
+	policy, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	policies := Policies{policy}
+
+	if projectPath := ProjectPath(); projectPath != "" {
+		projectPolicy, err := LoadFile(projectPath)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, projectPolicy)
+	}
+	return policies, nil
+}
+
+// Evaluate returns the strictest decision of the policies, so a project policy can't allow
+// what the user's policy denies, and the other way around.
+func (ps Policies) Evaluate(input Input) Decision {
+	decision := Decision{Action: ActionAllow}
+	for _, p := range ps {
+		d := p.Evaluate(input)
+		// Of equally strict decisions, the first one made by a rule is kept.
+		if strictness(d.Action) > strictness(decision.Action) || (decision.Rule == "" && d.Action == decision.Action) {
+			decision = d
+		}
+	}
+	return decision
+}
+
+// hasRules reports whether any of the policies has rules.
+func (ps Policies) hasRules() bool {
+	for _, p := range ps {
+		if len(p.Rules) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// strictness orders actions from allow to deny.
+func strictness(action Action) int {
+	switch action {
+	case ActionDeny:
+		return 2
+	case ActionWarn:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// LoadFile reads a policy file. A missing file is an empty policy.
+func LoadFile(filename string) (*Policy, error) {
+	data, err := os.ReadFile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Policy{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid policy file %s: %w", filename, err)
+	}
+	return policy, nil
+}
+
+// Parse parses and validates a policy.
+func Parse(data []byte) (*Policy, error) {
+	var policy Policy
+	if err := yaml.Unmarshal(data, &policy); err != nil {
+		return nil, err
+	}
+
+	for i := range policy.Rules {
+		rule := &policy.Rules[i]
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("rule %d", i+1)
+		}
+		if err := rule.compile(); err != nil {
+			return nil, fmt.Errorf("rule '%s': %w", rule.Name, err)
+		}
+	}
+	return &policy, nil
+}
+
+// compile validates the rule and compiles its expressions.
+func (r *Rule) compile() error {
+	switch r.Action {
+	case ActionAllow, ActionWarn, ActionDeny:
+	case "":
+		return fmt.Errorf("action is required (allow, warn or deny)")
+	default:
+		return fmt.Errorf("unknown action '%s' (allow, warn or deny)", r.Action)
+	}
+
+	for _, pattern := range r.Connections {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid connection pattern '%s': %w", pattern, err)
+		}
+	}
+
+	var err error
+	if r.match, err = compileAll(r.Match); err != nil {
+		return err
+	}
+	if r.unless, err = compileAll(r.Unless); err != nil {
+		return err
+	}
+
+	if r.Risk != "" {
+		risk, err := conn.ParseRisk(r.Risk)
+		if err != nil {
+			return err
+		}
+		r.minRisk = &risk
+	}
+	return nil
+}
+
+func compileAll(expressions []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, len(expressions))
+	for i, expression := range expressions {
+		re, err := regexp.Compile(expression)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expression '%s': %w", expression, err)
+		}
+		compiled[i] = re
+	}
+	return compiled, nil
+}
+
+// Matches reports whether the rule applies to the command.
+func (r *Rule) Matches(input Input) bool {
+	if len(r.Connections) > 0 {
+		matched := false
+		for _, pattern := range r.Connections {
+			if ok, _ := path.Match(strings.ToLower(pattern), strings.ToLower(input.Connection.Name)); ok {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	if len(r.Types) > 0 {
+		matched := false
+		for _, connectionType := range r.Types {
+			if strings.EqualFold(connectionType, input.Connection.Type.GetMainType()) ||
+				strings.EqualFold(connectionType, input.Connection.Type.GetSubtype()) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	for _, re := range r.match {
+		if !re.MatchString(input.Command) {
+			return false
+		}
+	}
+	for _, re := range r.unless {
+		if re.MatchString(input.Command) {
+			return false
+		}
+	}
+
+	if r.minRisk != nil && input.Risk < *r.minRisk {
+		return false
+	}
+	return true
+}
+
+// Evaluate returns the decision of the first rule matching the command.
+// Commands that match no rule are allowed.
+func (p *Policy) Evaluate(input Input) Decision {
+	for i := range p.Rules {
+		rule := &p.Rules[i]
+		if rule.Matches(input) {
+			return Decision{
+				Action:  rule.Action,
+				Rule:    rule.Name,
+				Message: rule.Message,
+			}
+		}
+	}
+	return Decision{Action: ActionAllow}
+}
+
+// Check evaluates a command generated for the connection against the policy files.
+// An invalid policy file denies every command, so a broken file doesn't turn the rules off.
+func Check(c conn.ConnectionInterface, command string) Decision {
+	policies, err := LoadAll()
+	if err != nil {
+		return Decision{
+			Action:  ActionDeny,
+			Rule:    "policy file",
+			Message: err.Error(),
+		}
+	}
+	if !policies.hasRules() {
+		return Decision{Action: ActionAllow}
+	}
+
+	return policies.Evaluate(Input{
+		Connection: c.GetConnection(),
+		Command:    command,
+		Risk:       c.ClassifyCommand(command).Risk,
+	})
+}
@@ -0,0 +1,211 @@
+package policy
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/prompt-ops/pops/pkg/conn"
+)
+
+const testPolicy = `
+rules:
+  - name: allow-prod-db-admin
+    connections: ["prod-db"]
+    match: ['(?i)^\s*delete\s+from\s+sessions\b']
+    action: allow
+  - name: no-unbounded-delete
+    connections: ["prod-*"]
+    types: ["PostgreSQL"]
+    match: ['(?i)^\s*delete\b']
+    unless: ['(?i)\bwhere\b']
+    action: deny
+    message: DELETE without WHERE is not allowed.
+  - name: no-exec-in-payments
+    types: ["kubernetes"]
+    match: ['\bexec\b', '(-n|--namespace)[= ]payments\b']
+    action: deny
+  - name: destructive
+    risk: destructive
+    action: warn
+    message: This command is destructive.
+`
+
+func TestPolicy_Evaluate(t *testing.T) {
+	policy, err := Parse([]byte(testPolicy))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	prodDB := conn.NewDatabaseConnection("prod-db", conn.PostgreSQLDatabaseConnection, "postgres://localhost/db")
+	devDB := conn.NewDatabaseConnection("dev-db", conn.PostgreSQLDatabaseConnection, "postgres://localhost/db")
+	cluster := conn.NewKubernetesConnection("cluster", "dev")
+
+	tests := []struct {
+		name       string
+		input      Input
+		wantAction Action
+		wantRule   string
+	}{
+		{
+			name:       "Test Evaluate denies DELETE without WHERE",
+			input:      Input{Connection: prodDB, Command: "DELETE FROM users", Risk: conn.RiskDestructive},
+			wantAction: ActionDeny,
+			wantRule:   "no-unbounded-delete",
+		},
+		{
+			name:       "Test Evaluate allows the exception before the rule",
+			input:      Input{Connection: prodDB, Command: "delete from sessions", Risk: conn.RiskDestructive},
+			wantAction: ActionAllow,
+			wantRule:   "allow-prod-db-admin",
+		},
+		{
+			name:       "Test Evaluate allows DELETE with WHERE",
+			input:      Input{Connection: prodDB, Command: "DELETE FROM users WHERE id = 1", Risk: conn.RiskWrite},
+			wantAction: ActionAllow,
+		},
+		{
+			name:       "Test Evaluate warns on other connections",
+			input:      Input{Connection: devDB, Command: "DELETE FROM users", Risk: conn.RiskDestructive},
+			wantAction: ActionWarn,
+			wantRule:   "destructive",
+		},
+		{
+			name:       "Test Evaluate denies exec in payments",
+			input:      Input{Connection: cluster, Command: "kubectl exec -n payments api-0 -- sh", Risk: conn.RiskWrite},
+			wantAction: ActionDeny,
+			wantRule:   "no-exec-in-payments",
+		},
+		{
+			name:       "Test Evaluate allows exec in other namespaces",
+			input:      Input{Connection: cluster, Command: "kubectl exec -n web api-0 -- sh", Risk: conn.RiskWrite},
+			wantAction: ActionAllow,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := policy.Evaluate(tt.input)
+			if got.Action != tt.wantAction || got.Rule != tt.wantRule {
+				t.Errorf("Evaluate() = %v (%s), want %v (%s)", got.Action, got.Rule, tt.wantAction, tt.wantRule)
+			}
+			if gotDenied := errors.Is(got.Err(), ErrDenied); gotDenied != (tt.wantAction == ActionDeny) {
+				t.Errorf("Err() = %v", got.Err())
+			}
+		})
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy string
+	}{
+		{
+			name:   "Test Parse without action",
+			policy: "rules:\n  - name: a\n",
+		},
+		{
+			name:   "Test Parse with unknown action",
+			policy: "rules:\n  - name: a\n    action: block\n",
+		},
+		{
+			name:   "Test Parse with invalid expression",
+			policy: "rules:\n  - name: a\n    match: ['(']\n    action: deny\n",
+		},
+		{
+			name:   "Test Parse with unknown risk",
+			policy: "rules:\n  - name: a\n    risk: scary\n    action: deny\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse([]byte(tt.policy)); err == nil {
+				t.Errorf("Parse() expected error")
+			}
+		})
+	}
+}
+
+func TestLoadFile_Missing(t *testing.T) {
+	policy, err := LoadFile(filepath.Join(t.TempDir(), "policy.yaml"))
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	if len(policy.Rules) != 0 {
+		t.Errorf("LoadFile() = %v, want no rules", policy.Rules)
+	}
+}
+
+func TestCheck_InvalidPolicyDenies(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := os.WriteFile(filename, []byte("rules:\n  - name: a\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(PathEnvVar, filename)
+
+	connection := conn.NewKubernetesConnection("cluster", "dev")
+	decision := Check(conn.NewKubernetesConnectionImpl(&connection), "kubectl get pods")
+	if decision.Action != ActionDeny {
+		t.Errorf("Check() = %v, want %v", decision.Action, ActionDeny)
+	}
+}
+
+func TestPolicies_Evaluate(t *testing.T) {
+	user, err := Parse([]byte(`
+rules:
+  - name: allow-everything
+    action: allow
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	project, err := Parse([]byte(`
+rules:
+  - name: no-delete
+    match: ['(?i)^delete\b']
+    action: deny
+  - name: writes
+    risk: write
+    action: warn
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cluster := conn.NewKubernetesConnection("cluster", "dev")
+
+	tests := []struct {
+		name       string
+		input      Input
+		wantAction Action
+		wantRule   string
+	}{
+		{
+			name:       "Test Evaluate takes the deny of the project over the allow of the user",
+			input:      Input{Connection: cluster, Command: "DELETE FROM users", Risk: conn.RiskDestructive},
+			wantAction: ActionDeny,
+			wantRule:   "no-delete",
+		},
+		{
+			name:       "Test Evaluate takes the warning over the allow",
+			input:      Input{Connection: cluster, Command: "kubectl scale deploy api --replicas 2", Risk: conn.RiskWrite},
+			wantAction: ActionWarn,
+			wantRule:   "writes",
+		},
+		{
+			name:       "Test Evaluate allows what both policies allow",
+			input:      Input{Connection: cluster, Command: "kubectl get pods", Risk: conn.RiskRead},
+			wantAction: ActionAllow,
+			wantRule:   "allow-everything",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision := Policies{user, project}.Evaluate(tt.input)
+			if decision.Action != tt.wantAction || decision.Rule != tt.wantRule {
+				t.Errorf("Evaluate() = %v (%s), want %v (%s)", decision.Action, decision.Rule, tt.wantAction, tt.wantRule)
+			}
+		})
+	}
+}
@@ -0,0 +1,6 @@
+package project
+
+// This package finds the project directory: the nearest .pops directory above the working directory, which
+// lets a repository carry its own connections and policy. As its files can run commands, for example with
+// ${exec:...} references, a project directory is only used once it is trusted with 'pops project trust',
+// and again after its files change.
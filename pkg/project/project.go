@@ -0,0 +1,204 @@
+package project
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/prompt-ops/pops/pkg/workspace"
+)
+
+// DirName is the name of project directories.
+const DirName = ".pops"
+
+// EnvVar is the environment variable to use a project directory instead of searching for one,
+// or "none" to not use one.
+const EnvVar = "POPS_PROJECT_DIR"
+
+// Files are the files of a project directory pops reads. Trust covers their contents; other files,
+// like prompt templates, which pops doesn't have yet, are neither read nor trusted.
+var Files = []string{"connections.json", "policy.yaml"}
+
+// trustFile is the file in ~/.pops that keeps the trusted project directories with the hash of their files.
+const trustFile = "trusted-projects.json"
+
+// Project is a project directory.
+type Project struct {
+	// Dir is the path of the .pops directory.
+	Dir string
+}
+
+// Path returns the path of a file in the project directory.
+func (p *Project) Path(filename string) string {
+	return filepath.Join(p.Dir, filename)
+}
+
+// Find returns the nearest project directory in dir or its parents, or nil if there is none.
+// The search stops at the home directory, whose .pops directory holds the user's own settings.
+func Find(dir string) (*Project, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	homeDir, _ := os.UserHomeDir()
+	root := filepath.Clean(workspace.Root())
+	for {
+		if homeDir != "" && dir == filepath.Clean(homeDir) {
+			return nil, nil
+		}
+
+		candidate := filepath.Join(dir, DirName)
+		if info, err := os.Stat(candidate); err == nil && info.IsDir() && candidate != root {
+			return &Project{Dir: candidate}, nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, nil
+		}
+		dir = parent
+	}
+}
+
+// Current returns the project directory of the working directory, or the one in POPS_PROJECT_DIR,
+// whether or not it is trusted. It returns nil if there is none.
+func Current() (*Project, error) {
+	if value := strings.TrimSpace(os.Getenv(EnvVar)); value != "" {
+		if value == "none" {
+			return nil, nil
+		}
+		dir, err := filepath.Abs(value)
+		if err != nil {
+			return nil, err
+		}
+		return &Project{Dir: dir}, nil
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	return Find(wd)
+}
+
+// Trusted returns the project directory of the working directory if it is trusted, or nil otherwise.
+// An untrusted project directory is returned as the error ErrUntrusted, so it can be reported.
+func Trusted() (*Project, error) {
+	p, err := Current()
+	if err != nil || p == nil {
+		return nil, err
+	}
+
+	trusted, err := p.IsTrusted()
+	if err != nil {
+		return nil, err
+	}
+	if !trusted {
+		return nil, &UntrustedError{Dir: p.Dir}
+	}
+	return p, nil
+}
+
+// ErrUntrusted is wrapped by the error returned for untrusted project directories.
+var ErrUntrusted = errors.New("project directory is not trusted")
+
+// UntrustedError is returned for a project directory that is not trusted, or whose files changed since.
+type UntrustedError struct {
+	Dir string
+}
+
+func (e *UntrustedError) Error() string {
+	return fmt.Sprintf("%s: %s; run 'pops project trust' to use its connections and policy", ErrUntrusted, e.Dir)
+}
+
+func (e *UntrustedError) Unwrap() error {
+	return ErrUntrusted
+}
+
+// Hash returns the hash of the files of the project directory, which trust is given for.
+func (p *Project) Hash() (string, error) {
+	hash := sha256.New()
+	for _, filename := range Files {
+		data, err := os.ReadFile(p.Path(filename))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(hash, "%s\x00%d\x00", filename, len(data))
+		hash.Write(data)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// IsTrusted reports whether the project directory is trusted with its files as they are.
+func (p *Project) IsTrusted() (bool, error) {
+	trusted, err := loadTrusted()
+	if err != nil {
+		return false, err
+	}
+	hash, err := p.Hash()
+	if err != nil {
+		return false, err
+	}
+	return trusted[p.Dir] == hash, nil
+}
+
+// Trust trusts the project directory with its files as they are now.
+func (p *Project) Trust() error {
+	hash, err := p.Hash()
+	if err != nil {
+		return err
+	}
+	return updateTrusted(func(trusted map[string]string) {
+		trusted[p.Dir] = hash
+	})
+}
+
+// Untrust stops trusting the project directory.
+func (p *Project) Untrust() error {
+	return updateTrusted(func(trusted map[string]string) {
+		delete(trusted, p.Dir)
+	})
+}
+
+// loadTrusted reads the trusted project directories with the hash of their files.
+func loadTrusted() (map[string]string, error) {
+	trusted := map[string]string{}
+	data, err := os.ReadFile(filepath.Join(workspace.Root(), trustFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return trusted, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &trusted); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", trustFile, err)
+	}
+	return trusted, nil
+}
+
+// updateTrusted changes the trusted project directories.
+func updateTrusted(fn func(trusted map[string]string)) error {
+	trusted, err := loadTrusted()
+	if err != nil {
+		return err
+	}
+	fn(trusted)
+
+	data, err := json.MarshalIndent(trusted, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(workspace.Root(), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(workspace.Root(), trustFile), append(data, '\n'), 0600)
+}
@@ -0,0 +1,114 @@
+package project
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFind(t *testing.T) {
+	base := t.TempDir()
+	home := filepath.Join(base, "home")
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	for _, dir := range []string{
+		filepath.Join(home, DirName),
+		filepath.Join(home, "repo", DirName),
+		filepath.Join(home, "repo", "service", "cmd"),
+		filepath.Join(home, "other"),
+		filepath.Join(base, DirName),
+	} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		dir  string
+		want string
+	}{
+		{
+			name: "Test Find in the project",
+			dir:  filepath.Join(home, "repo"),
+			want: filepath.Join(home, "repo", DirName),
+		},
+		{
+			name: "Test Find below the project",
+			dir:  filepath.Join(home, "repo", "service", "cmd"),
+			want: filepath.Join(home, "repo", DirName),
+		},
+		{
+			name: "Test Find stops at the home directory",
+			dir:  filepath.Join(home, "other"),
+		},
+		{
+			name: "Test Find outside the home directory",
+			dir:  base,
+			want: filepath.Join(base, DirName),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Find(tt.dir)
+			if err != nil {
+				t.Fatalf("Find() error = %v", err)
+			}
+
+			got := ""
+			if p != nil {
+				got = p.Dir
+			}
+			if got != tt.want {
+				t.Errorf("Find() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTrust(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	dir := filepath.Join(t.TempDir(), DirName)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	connections := filepath.Join(dir, "connections.json")
+	if err := os.WriteFile(connections, []byte("[]"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(EnvVar, dir)
+
+	if _, err := Trusted(); !errors.Is(err, ErrUntrusted) {
+		t.Fatalf("Trusted() before trusting error = %v, want ErrUntrusted", err)
+	}
+
+	p, err := Current()
+	if err != nil || p == nil {
+		t.Fatalf("Current() = %v, %v", p, err)
+	}
+	if err := p.Trust(); err != nil {
+		t.Fatalf("Trust() error = %v", err)
+	}
+	if trusted, err := Trusted(); err != nil || trusted == nil {
+		t.Fatalf("Trusted() after trusting = %v, %v", trusted, err)
+	}
+
+	// Changed files have to be trusted again.
+	if err := os.WriteFile(connections, []byte(`[{"name": "new"}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Trusted(); !errors.Is(err, ErrUntrusted) {
+		t.Errorf("Trusted() after changing the files error = %v, want ErrUntrusted", err)
+	}
+
+	t.Setenv(EnvVar, "none")
+	if p, err := Current(); err != nil || p != nil {
+		t.Errorf("Current() with %s=none = %v, %v, want none", EnvVar, p, err)
+	}
+}
@@ -0,0 +1,5 @@
+package secret
+
+// This package keeps secrets of connections, like connection strings with passwords, out of connections.json.
+// Secrets are stored in the OS keyring where one is available, or otherwise in a passphrase-encrypted vault
+// (~/.pops/vault.json), and connections.json only keeps a reference to them.
@@ -0,0 +1,52 @@
+package secret
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/zalando/go-keyring"
+)
+
+const (
+	keyringStoreName = "keyring"
+
+	// keyringService is the service secrets are stored under in the OS keyring.
+	keyringService = "pops"
+)
+
+// keyringStore stores secrets in the OS keyring: the Keychain on macOS,
+// the Secret Service (GNOME Keyring, KWallet) on Linux and the Credential Manager on Windows.
+type keyringStore struct{}
+
+// newKeyringStore returns the keyring store if the OS keyring can be used.
+func newKeyringStore() (Store, error) {
+	// Reading a missing secret tells an available keyring from one that can't be reached.
+	if _, err := keyring.Get(keyringService, "pops-keyring-check"); err != nil && !errors.Is(err, keyring.ErrNotFound) {
+		return nil, fmt.Errorf("the OS keyring is not available: %w", err)
+	}
+	return keyringStore{}, nil
+}
+
+func (keyringStore) Name() string {
+	return keyringStoreName
+}
+
+func (keyringStore) Get(key string) (string, error) {
+	value, err := keyring.Get(keyringService, key)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return "", ErrNotFound
+	}
+	return value, err
+}
+
+func (keyringStore) Set(key, value string) error {
+	return keyring.Set(keyringService, key, value)
+}
+
+func (keyringStore) Delete(key string) error {
+	err := keyring.Delete(keyringService, key)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,136 @@
+package secret
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// StoreEnvVar is the environment variable to choose the secret store: "keyring" or "vault".
+// By default the keyring is used if it is available, and the vault otherwise.
+const StoreEnvVar = "POPS_SECRET_STORE"
+
+// ErrNotFound is returned for references to secrets that are not in their store.
+var ErrNotFound = errors.New("secret not found")
+
+// Store keeps secrets by key.
+type Store interface {
+	// Name is the name of the store in references, like "keyring" or "vault".
+	Name() string
+
+	// Get returns the secret stored under the key, or an error wrapping ErrNotFound.
+	Get(key string) (string, error)
+
+	// Set stores the secret under the key, replacing the secret stored under it before.
+	Set(key, value string) error
+
+	// Delete removes the secret stored under the key. Deleting a missing secret is not an error.
+	Delete(key string) error
+}
+
+var (
+	defaultStoreOnce sync.Once
+	defaultStore     Store
+	defaultStoreErr  error
+)
+
+// DefaultStore returns the store new secrets are saved in.
+func DefaultStore() (Store, error) {
+	defaultStoreOnce.Do(func() {
+		switch name := strings.ToLower(strings.TrimSpace(os.Getenv(StoreEnvVar))); name {
+		case keyringStoreName:
+			defaultStore, defaultStoreErr = newKeyringStore()
+		case vaultStoreName:
+			defaultStore, defaultStoreErr = newVaultStore(VaultPath())
+		case "":
+			if defaultStore, defaultStoreErr = newKeyringStore(); defaultStoreErr != nil {
+				defaultStore, defaultStoreErr = newVaultStore(VaultPath())
+				if defaultStoreErr != nil {
+					defaultStoreErr = fmt.Errorf("the OS keyring is not available and %w", defaultStoreErr)
+				}
+			}
+		default:
+			defaultStoreErr = fmt.Errorf("unknown secret store '%s' in %s (keyring or vault)", name, StoreEnvVar)
+		}
+	})
+	return defaultStore, defaultStoreErr
+}
+
+// storeByName returns the store a reference points to.
+func storeByName(name string) (Store, error) {
+	if store, err := DefaultStore(); err == nil && store.Name() == name {
+		return store, nil
+	}
+
+	switch name {
+	case keyringStoreName:
+		return newKeyringStore()
+	case vaultStoreName:
+		return newVaultStore(VaultPath())
+	default:
+		return nil, fmt.Errorf("unknown secret store '%s'", name)
+	}
+}
+
+// Ref is a reference to a stored secret, written as "<store>:<key>".
+// Example: "keyring:connection/prod-db".
+type Ref string
+
+// NewRef returns the reference to the secret stored under the key in the store.
+func NewRef(store Store, key string) Ref {
+	return Ref(store.Name() + ":" + key)
+}
+
+// Parse returns the store name and key of the reference.
+func (r Ref) Parse() (string, string, error) {
+	store, key, ok := strings.Cut(string(r), ":")
+	if !ok || store == "" || key == "" {
+		return "", "", fmt.Errorf("invalid secret reference '%s'", r)
+	}
+	return store, key, nil
+}
+
+// Save stores the secret under the key in the default store and returns the reference to it.
+func Save(key, value string) (Ref, error) {
+	store, err := DefaultStore()
+	if err != nil {
+		return "", err
+	}
+	if err := store.Set(key, value); err != nil {
+		return "", fmt.Errorf("failed to save the secret in the %s: %w", store.Name(), err)
+	}
+	return NewRef(store, key), nil
+}
+
+// Resolve returns the secret the reference points to.
+func Resolve(ref Ref) (string, error) {
+	storeName, key, err := ref.Parse()
+	if err != nil {
+		return "", err
+	}
+	store, err := storeByName(storeName)
+	if err != nil {
+		return "", err
+	}
+
+	value, err := store.Get(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the secret '%s' from the %s: %w", key, storeName, err)
+	}
+	return value, nil
+}
+
+// Delete removes the secret the reference points to.
+func Delete(ref Ref) error {
+	storeName, key, err := ref.Parse()
+	if err != nil {
+		return err
+	}
+	store, err := storeByName(storeName)
+	if err != nil {
+		return err
+	}
+	return store.Delete(key)
+}
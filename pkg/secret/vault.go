@@ -0,0 +1,221 @@
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	vaultStoreName = "vault"
+
+	// PassphraseEnvVar is the environment variable with the passphrase of the vault.
+	PassphraseEnvVar = "POPS_VAULT_PASSPHRASE"
+
+	// VaultPathEnvVar is the environment variable to use a different vault than ~/.pops/vault.json.
+	VaultPathEnvVar = "POPS_VAULT_FILE"
+
+	vaultVersion = 1
+
+	// scrypt parameters recommended for interactive use.
+	scryptN      = 1 << 15
+	scryptR      = 8
+	scryptP      = 1
+	vaultKeySize = 32
+)
+
+// vaultFile is the encrypted vault on disk. The secrets are a JSON object encrypted with AES-256-GCM,
+// with a key derived from the passphrase with scrypt.
+type vaultFile struct {
+	Version int    `json:"version"`
+	KDF     string `json:"kdf"`
+	Salt    []byte `json:"salt"`
+	Nonce   []byte `json:"nonce"`
+	Data    []byte `json:"data"`
+}
+
+// vaultStore stores secrets in a passphrase-encrypted file.
+type vaultStore struct {
+	path       string
+	passphrase string
+
+	mu sync.Mutex
+}
+
+// VaultPath returns the path of the vault.
+func VaultPath() string {
+	if value := os.Getenv(VaultPathEnvVar); value != "" {
+		return value
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "vault.json"
+	}
+	return filepath.Join(homeDir, ".pops", "vault.json")
+}
+
+// newVaultStore returns the vault at the path, encrypted with the passphrase from the environment.
+func newVaultStore(path string) (Store, error) {
+	passphrase := os.Getenv(PassphraseEnvVar)
+	if passphrase == "" {
+		return nil, fmt.Errorf("%s must be set to use the encrypted vault %s", PassphraseEnvVar, path)
+	}
+	return &vaultStore{
+		path:       path,
+		passphrase: passphrase,
+	}, nil
+}
+
+func (v *vaultStore) Name() string {
+	return vaultStoreName
+}
+
+func (v *vaultStore) Get(key string) (string, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	secrets, _, err := v.read()
+	if err != nil {
+		return "", err
+	}
+	value, ok := secrets[key]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+func (v *vaultStore) Set(key, value string) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	secrets, salt, err := v.read()
+	if err != nil {
+		return err
+	}
+	secrets[key] = value
+	return v.write(secrets, salt)
+}
+
+func (v *vaultStore) Delete(key string) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	secrets, salt, err := v.read()
+	if err != nil {
+		return err
+	}
+	if _, ok := secrets[key]; !ok {
+		return nil
+	}
+	delete(secrets, key)
+	return v.write(secrets, salt)
+}
+
+// read decrypts the vault. A missing vault is empty and gets a new salt.
+func (v *vaultStore) read() (map[string]string, []byte, error) {
+	data, err := os.ReadFile(v.path)
+	if errors.Is(err, os.ErrNotExist) {
+		salt := make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, nil, err
+		}
+		return map[string]string{}, salt, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var file vaultFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, nil, fmt.Errorf("invalid vault %s: %w", v.path, err)
+	}
+	if file.Version != vaultVersion || file.KDF != "scrypt" {
+		return nil, nil, fmt.Errorf("unsupported vault %s (version %d, kdf %s)", v.path, file.Version, file.KDF)
+	}
+
+	aead, err := v.cipher(file.Salt)
+	if err != nil {
+		return nil, nil, err
+	}
+	plaintext, err := aead.Open(nil, file.Nonce, file.Data, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decrypt the vault %s; check %s", v.path, PassphraseEnvVar)
+	}
+
+	secrets := map[string]string{}
+	if err := json.Unmarshal(plaintext, &secrets); err != nil {
+		return nil, nil, fmt.Errorf("invalid vault %s: %w", v.path, err)
+	}
+	return secrets, file.Salt, nil
+}
+
+// write encrypts the secrets with a new nonce and replaces the vault.
+func (v *vaultStore) write(secrets map[string]string, salt []byte) error {
+	plaintext, err := json.Marshal(secrets)
+	if err != nil {
+		return err
+	}
+
+	aead, err := v.cipher(salt)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(vaultFile{
+		Version: vaultVersion,
+		KDF:     "scrypt",
+		Salt:    salt,
+		Nonce:   nonce,
+		Data:    aead.Seal(nil, nonce, plaintext, nil),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(v.path), 0o700); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first, so a failed write doesn't lose the secrets already stored.
+	tmp, err := os.CreateTemp(filepath.Dir(v.path), ".vault-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), v.path)
+}
+
+// cipher derives the key from the passphrase and the salt.
+func (v *vaultStore) cipher(salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(v.passphrase), salt, scryptN, scryptR, scryptP, vaultKeySize)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
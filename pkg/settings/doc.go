@@ -0,0 +1,4 @@
+package settings
+
+// This package reads the settings file (~/.pops/config.yaml): defaults for the AI model, output, confirmations,
+// timeouts and the UI, validated against a schema, with overrides per workspace and per connection.
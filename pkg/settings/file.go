@@ -0,0 +1,246 @@
+package settings
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// connectionsKey is the key of the per-connection overrides in the settings file.
+const connectionsKey = "connections"
+
+// File is a settings file:
+//
+//	ai:
+//	  model: gpt-4o
+//	  temperature: 0.2
+//	connections:
+//	  prod-db:
+//	    confirmation:
+//	      strictness: all
+type File struct {
+	// Path is the path of the file.
+	Path string
+
+	// values are the settings by key.
+	values map[string]string
+
+	// connections are the overrides of the settings by connection name and key.
+	connections map[string]map[string]string
+}
+
+// LoadFile reads and validates a settings file. A missing file has no settings.
+func LoadFile(path string) (*File, error) {
+	file := &File{
+		Path:        path,
+		values:      map[string]string{},
+		connections: map[string]map[string]string{},
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return file, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var document map[string]interface{}
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("invalid settings file %s: %w", path, err)
+	}
+
+	for key, value := range document {
+		if key != connectionsKey {
+			if err := flatten(key, value, file.values); err != nil {
+				return nil, fmt.Errorf("invalid settings file %s: %w", path, err)
+			}
+			continue
+		}
+
+		overrides, ok := value.(map[string]interface{})
+		if !ok && value != nil {
+			return nil, fmt.Errorf("invalid settings file %s: %s must map connection names to settings", path, connectionsKey)
+		}
+		for name, settings := range overrides {
+			values := map[string]string{}
+			if err := flatten("", settings, values); err != nil {
+				return nil, fmt.Errorf("invalid settings file %s: connection '%s': %w", path, name, err)
+			}
+			file.connections[name] = values
+		}
+	}
+	return file, nil
+}
+
+// flatten adds the settings in value, a scalar or a nested mapping, to values by their dotted keys.
+func flatten(prefix string, value interface{}, values map[string]string) error {
+	if nested, ok := value.(map[string]interface{}); ok {
+		for key, child := range nested {
+			if prefix != "" {
+				key = prefix + "." + key
+			}
+			if err := flatten(key, child, values); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	setting, err := Lookup(prefix)
+	if err != nil {
+		return err
+	}
+	validated, err := setting.Validate(fmt.Sprint(value))
+	if err != nil {
+		return err
+	}
+	values[setting.Key] = validated
+	return nil
+}
+
+// Get returns the value of the setting in the file, for the connection if it is not empty.
+func (f *File) Get(key, connection string) (string, bool) {
+	values := f.values
+	if connection != "" {
+		values = f.connectionValues(connection)
+	}
+	value, ok := values[key]
+	return value, ok
+}
+
+// Set validates the value and sets the setting, for the connection if it is not empty.
+func (f *File) Set(key, value, connection string) error {
+	setting, err := Lookup(key)
+	if err != nil {
+		return err
+	}
+	validated, err := setting.Validate(value)
+	if err != nil {
+		return err
+	}
+
+	if connection == "" {
+		f.values[setting.Key] = validated
+		return nil
+	}
+
+	values := f.connectionValues(connection)
+	if values == nil {
+		values = map[string]string{}
+		f.connections[connection] = values
+	}
+	values[setting.Key] = validated
+	return nil
+}
+
+// Unset removes the setting, for the connection if it is not empty. Returns false if it wasn't set.
+func (f *File) Unset(key, connection string) (bool, error) {
+	setting, err := Lookup(key)
+	if err != nil {
+		return false, err
+	}
+
+	values := f.values
+	if connection != "" {
+		values = f.connectionValues(connection)
+	}
+	if _, ok := values[setting.Key]; !ok {
+		return false, nil
+	}
+	delete(values, setting.Key)
+	return true, nil
+}
+
+// connectionValues returns the overrides of the connection. Connection names are case insensitive.
+func (f *File) connectionValues(connection string) map[string]string {
+	for name, values := range f.connections {
+		if strings.EqualFold(name, connection) {
+			return values
+		}
+	}
+	return nil
+}
+
+// Save writes the file, replacing it as a whole.
+func (f *File) Save() error {
+	document := map[string]interface{}{}
+	nest(document, f.values)
+
+	overrides := map[string]interface{}{}
+	for name, values := range f.connections {
+		if len(values) == 0 {
+			continue
+		}
+		settings := map[string]interface{}{}
+		nest(settings, values)
+		overrides[name] = settings
+	}
+	if len(overrides) > 0 {
+		document[connectionsKey] = overrides
+	}
+
+	data, err := yaml.Marshal(document)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(f.Path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(f.Path), "."+filepath.Base(f.Path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), f.Path)
+}
+
+// nest adds the settings to the document as nested mappings, with numbers written as numbers.
+func nest(document map[string]interface{}, values map[string]string) {
+	for key, value := range values {
+		parts := strings.Split(key, ".")
+		parent := document
+		for _, part := range parts[:len(parts)-1] {
+			child, ok := parent[part].(map[string]interface{})
+			if !ok {
+				child = map[string]interface{}{}
+				parent[part] = child
+			}
+			parent = child
+		}
+		parent[parts[len(parts)-1]] = typed(key, value)
+	}
+}
+
+// typed returns the value as the type of the setting, so numbers aren't quoted in the file.
+func typed(key, value string) interface{} {
+	setting, err := Lookup(key)
+	if err != nil {
+		return value
+	}
+	switch setting.Kind {
+	case KindFloat:
+		if number, err := strconv.ParseFloat(value, 64); err == nil {
+			return number
+		}
+	case KindInt:
+		if number, err := strconv.Atoi(value); err == nil {
+			return number
+		}
+	}
+	return value
+}
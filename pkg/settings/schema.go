@@ -0,0 +1,178 @@
+package settings
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kind is the type of the value of a setting.
+type Kind string
+
+const (
+	KindString   Kind = "string"
+	KindEnum     Kind = "enum"
+	KindFloat    Kind = "float"
+	KindInt      Kind = "int"
+	KindDuration Kind = "duration"
+)
+
+// Setting describes a setting of the settings file.
+type Setting struct {
+	// Key is the dotted path of the setting, like "ai.model".
+	Key string
+
+	Kind Kind
+
+	// Default is the value used when the setting is not set.
+	Default string
+
+	// Values are the allowed values of enum settings.
+	Values []string
+
+	// Min and Max bound the values of number and duration settings. Durations are in seconds.
+	Min, Max float64
+
+	Description string
+}
+
+const (
+	KeyAIProvider          = "ai.provider"
+	KeyAIModel             = "ai.model"
+	KeyAITemperature       = "ai.temperature"
+	KeyOutputFormat        = "output.format"
+	KeyOutputRowLimit      = "output.rowLimit"
+	KeyConfirmation        = "confirmation.strictness"
+	KeyTimeoutAI           = "timeouts.ai"
+	KeyTimeoutSecretHelper = "timeouts.secretHelper"
+	KeyUITheme             = "ui.theme"
+	KeyUIPromptCharLimit   = "ui.promptCharLimit"
+)
+
+const (
+	// StrictnessDestructive has destructive commands confirmed by typing their target, and others with "y".
+	StrictnessDestructive = "destructive"
+
+	// StrictnessWrite has commands that change anything confirmed by typing their target.
+	StrictnessWrite = "write"
+
+	// StrictnessAll has every command confirmed by typing its target.
+	StrictnessAll = "all"
+)
+
+// Schema are the settings of the settings file. All of them can be overridden per connection.
+var Schema = []Setting{
+	{Key: KeyAIProvider, Kind: KindEnum, Default: "openai", Values: []string{"openai"}, Description: "AI provider that generates commands and answers"},
+	{Key: KeyAIModel, Kind: KindString, Default: "gpt-4o", Description: "Chat model of the AI provider"},
+	{Key: KeyAITemperature, Kind: KindFloat, Default: "0.2", Min: 0, Max: 2, Description: "Sampling temperature for generating commands and answers"},
+	{Key: KeyOutputFormat, Kind: KindEnum, Default: "table", Values: []string{"table", "json", "yaml", "csv", "markdown"}, Description: "Output format of results in the shell"},
+	{Key: KeyOutputRowLimit, Kind: KindInt, Default: "500", Min: 1, Max: 1000000, Description: "How many rows of a result are fetched at once, unless the connection sets rowLimit"},
+	{Key: KeyConfirmation, Kind: KindEnum, Default: StrictnessDestructive, Values: []string{StrictnessDestructive, StrictnessWrite, StrictnessAll}, Description: "Commands that are confirmed by typing their target instead of y"},
+	{Key: KeyTimeoutAI, Kind: KindDuration, Default: "2m", Min: 0, Max: 3600, Description: "How long a request to the AI provider may take; 0 for no limit"},
+	{Key: KeyTimeoutSecretHelper, Kind: KindDuration, Default: "30s", Min: 1, Max: 3600, Description: "How long a secret helper run by ${exec:...} may take"},
+	{Key: KeyUITheme, Kind: KindEnum, Default: "default", Values: []string{"default", "light", "mono"}, Description: "Colours of the shell: default for dark terminals, light for light ones, mono for none"},
+	{Key: KeyUIPromptCharLimit, Kind: KindInt, Default: "512", Min: 16, Max: 100000, Description: "Maximum length of prompts in the shell"},
+}
+
+// Lookup returns the setting with the key. Keys are case insensitive.
+func Lookup(key string) (Setting, error) {
+	for _, setting := range Schema {
+		if strings.EqualFold(setting.Key, key) {
+			return setting, nil
+		}
+	}
+	return Setting{}, fmt.Errorf("unknown setting '%s'; run 'pops config list' to see the settings", key)
+}
+
+// Validate checks the value against the setting and returns it in its canonical form.
+func (s Setting) Validate(value string) (string, error) {
+	value = strings.TrimSpace(value)
+
+	switch s.Kind {
+	case KindString:
+		if value == "" {
+			return "", fmt.Errorf("%s can't be empty", s.Key)
+		}
+		return value, nil
+
+	case KindEnum:
+		for _, allowed := range s.Values {
+			if strings.EqualFold(allowed, value) {
+				return allowed, nil
+			}
+		}
+		return "", fmt.Errorf("%s must be one of %s, not '%s'", s.Key, strings.Join(s.Values, ", "), value)
+
+	case KindFloat:
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return "", fmt.Errorf("%s must be a number, not '%s'", s.Key, value)
+		}
+		if number < s.Min || number > s.Max {
+			return "", fmt.Errorf("%s must be between %s and %s", s.Key, formatBound(s.Min), formatBound(s.Max))
+		}
+		return strconv.FormatFloat(number, 'g', -1, 64), nil
+
+	case KindInt:
+		number, err := strconv.Atoi(value)
+		if err != nil {
+			return "", fmt.Errorf("%s must be a whole number, not '%s'", s.Key, value)
+		}
+		if float64(number) < s.Min || float64(number) > s.Max {
+			return "", fmt.Errorf("%s must be between %s and %s", s.Key, formatBound(s.Min), formatBound(s.Max))
+		}
+		return strconv.Itoa(number), nil
+
+	case KindDuration:
+		duration, err := time.ParseDuration(value)
+		if err != nil && value == "0" {
+			duration, err = 0, nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("%s must be a duration like 30s or 2m, not '%s'", s.Key, value)
+		}
+		if duration.Seconds() < s.Min || duration.Seconds() > s.Max {
+			return "", fmt.Errorf("%s must be between %s and %s", s.Key, seconds(s.Min), seconds(s.Max))
+		}
+		return duration.String(), nil
+
+	default:
+		return "", fmt.Errorf("%s has an unknown kind '%s'", s.Key, s.Kind)
+	}
+}
+
+// Allowed describes the values the setting accepts, for help texts.
+func (s Setting) Allowed() string {
+	switch s.Kind {
+	case KindEnum:
+		return strings.Join(s.Values, ", ")
+	case KindFloat, KindInt:
+		return fmt.Sprintf("%s to %s", formatBound(s.Min), formatBound(s.Max))
+	case KindDuration:
+		return fmt.Sprintf("%s to %s", seconds(s.Min), seconds(s.Max))
+	default:
+		return "any text"
+	}
+}
+
+// formatBound formats a bound without an exponent.
+func formatBound(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// seconds formats a number of seconds as a duration.
+func seconds(value float64) string {
+	return (time.Duration(value) * time.Second).String()
+}
+
+// keys returns the keys of the schema, sorted.
+func keys() []string {
+	var keys []string
+	for _, setting := range Schema {
+		keys = append(keys, setting.Key)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,85 @@
+package settings
+
+import "testing"
+
+func TestSetting_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "Test Validate enum is case insensitive",
+			key:   "Output.Format",
+			value: "JSON",
+			want:  "json",
+		},
+		{
+			name:    "Test Validate enum rejects unknown values",
+			key:     KeyConfirmation,
+			value:   "never",
+			wantErr: true,
+		},
+		{
+			name:  "Test Validate float",
+			key:   KeyAITemperature,
+			value: " 0.70 ",
+			want:  "0.7",
+		},
+		{
+			name:    "Test Validate float out of range",
+			key:     KeyAITemperature,
+			value:   "2.5",
+			wantErr: true,
+		},
+		{
+			name:    "Test Validate int rejects fractions",
+			key:     KeyOutputRowLimit,
+			value:   "10.5",
+			wantErr: true,
+		},
+		{
+			name:  "Test Validate duration",
+			key:   KeyTimeoutAI,
+			value: "90s",
+			want:  "1m30s",
+		},
+		{
+			name:  "Test Validate duration accepts 0",
+			key:   KeyTimeoutAI,
+			value: "0",
+			want:  "0s",
+		},
+		{
+			name:    "Test Validate duration out of range",
+			key:     KeyTimeoutSecretHelper,
+			value:   "0s",
+			wantErr: true,
+		},
+		{
+			name:    "Test Validate empty string",
+			key:     KeyAIModel,
+			value:   " ",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setting, err := Lookup(tt.key)
+			if err != nil {
+				t.Fatalf("Lookup() error = %v", err)
+			}
+
+			got, err := setting.Validate(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Validate() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
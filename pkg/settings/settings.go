@@ -0,0 +1,166 @@
+package settings
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prompt-ops/pops/pkg/workspace"
+)
+
+// FileName is the name of settings files.
+const FileName = "config.yaml"
+
+// SourceDefault is the Source of values that aren't set in any settings file.
+const SourceDefault = "default"
+
+// Settings are the settings files that apply, in order of precedence, lowest first.
+type Settings struct {
+	Files []*File
+}
+
+// GlobalPath returns the path of the settings file of all workspaces, ~/.pops/config.yaml.
+func GlobalPath() string {
+	return filepath.Join(workspace.Root(), FileName)
+}
+
+// Path returns the path of the settings file of the current workspace.
+// It is GlobalPath in the default workspace.
+func Path() string {
+	return workspace.Path(FileName)
+}
+
+// Paths returns the paths of the settings files, lowest precedence first: GlobalPath,
+// and Path if the current workspace is not the default one.
+func Paths() []string {
+	paths := []string{GlobalPath()}
+	if path := Path(); filepath.Clean(path) != filepath.Clean(paths[0]) {
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+// Load reads the settings files.
+func Load() (*Settings, error) {
+	settings := &Settings{}
+	for _, path := range Paths() {
+		file, err := LoadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		settings.Files = append(settings.Files, file)
+	}
+	return settings, nil
+}
+
+// Value is the effective value of a setting.
+type Value struct {
+	Setting
+
+	Value string
+
+	// Source is where the value comes from: SourceDefault, or the path of a settings file,
+	// followed by the connection if it is an override of the connection.
+	Source string
+}
+
+// Get returns the effective value of the setting for the connection, or without connection overrides if
+// connection is empty. Overrides of the connection take precedence over the other settings of all files.
+func (s *Settings) Get(key, connection string) (Value, error) {
+	setting, err := Lookup(key)
+	if err != nil {
+		return Value{}, err
+	}
+
+	value := Value{Setting: setting, Value: setting.Default, Source: SourceDefault}
+	for _, file := range s.Files {
+		if v, ok := file.Get(setting.Key, ""); ok {
+			value.Value, value.Source = v, file.Path
+		}
+	}
+	if connection != "" {
+		for _, file := range s.Files {
+			if v, ok := file.Get(setting.Key, connection); ok {
+				value.Value, value.Source = v, fmt.Sprintf("%s (connection %s)", file.Path, connection)
+			}
+		}
+	}
+	return value, nil
+}
+
+// List returns the effective values of all settings for the connection, sorted by key.
+func (s *Settings) List(connection string) []Value {
+	var values []Value
+	for _, key := range keys() {
+		if value, err := s.Get(key, connection); err == nil {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// Values are the effective settings for a connection.
+type Values struct {
+	AIProvider          string
+	AIModel             string
+	AITemperature       float64
+	AITimeout           time.Duration
+	OutputFormat        string
+	OutputRowLimit      int
+	Confirmation        string
+	SecretHelperTimeout time.Duration
+	UITheme             string
+	UIPromptCharLimit   int
+}
+
+// warnInvalid prints the warning about invalid settings files once.
+var warnInvalid sync.Once
+
+// For returns the effective settings for the connection, or without connection overrides if it is empty.
+// If a settings file is invalid, a warning is printed and the defaults are used.
+func For(connection string) Values {
+	settings, err := Load()
+	if err != nil {
+		warnInvalid.Do(func() {
+			fmt.Fprintf(os.Stderr, "Warning: using the default settings: %v\n", err)
+		})
+		settings = &Settings{}
+	}
+
+	get := func(key string) string {
+		value, _ := settings.Get(key, connection)
+		return value.Value
+	}
+	return Values{
+		AIProvider:          get(KeyAIProvider),
+		AIModel:             get(KeyAIModel),
+		AITemperature:       parseFloat(get(KeyAITemperature)),
+		AITimeout:           parseDuration(get(KeyTimeoutAI)),
+		OutputFormat:        get(KeyOutputFormat),
+		OutputRowLimit:      parseInt(get(KeyOutputRowLimit)),
+		Confirmation:        get(KeyConfirmation),
+		SecretHelperTimeout: parseDuration(get(KeyTimeoutSecretHelper)),
+		UITheme:             get(KeyUITheme),
+		UIPromptCharLimit:   parseInt(get(KeyUIPromptCharLimit)),
+	}
+}
+
+// The values are validated when they are read, so parsing them doesn't fail.
+
+func parseFloat(value string) float64 {
+	number, _ := strconv.ParseFloat(value, 64)
+	return number
+}
+
+func parseInt(value string) int {
+	number, _ := strconv.Atoi(value)
+	return number
+}
+
+func parseDuration(value string) time.Duration {
+	duration, _ := time.ParseDuration(value)
+	return duration
+}
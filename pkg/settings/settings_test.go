@@ -0,0 +1,162 @@
+package settings
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prompt-ops/pops/pkg/workspace"
+)
+
+func TestSettings_Get(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv(workspace.EnvVar, "")
+	t.Cleanup(func() { _ = workspace.Select("") })
+
+	if err := workspace.Create("acme"); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if err := workspace.Select("acme"); err != nil {
+		t.Fatalf("Select() error = %v", err)
+	}
+
+	global := `
+ai:
+  model: gpt-4o-mini
+  temperature: 0.5
+output:
+  format: json
+connections:
+  prod-db:
+    confirmation:
+      strictness: all
+    ai:
+      temperature: 0
+`
+	if err := os.WriteFile(GlobalPath(), []byte(global), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	file, err := LoadFile(Path())
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	for _, set := range []struct{ key, value, connection string }{
+		{KeyAITemperature, "1", ""},
+		{KeyUITheme, "mono", ""},
+		{KeyOutputFormat, "yaml", "Prod-DB"},
+	} {
+		if err := file.Set(set.key, set.value, set.connection); err != nil {
+			t.Fatalf("Set() error = %v", err)
+		}
+	}
+	if err := file.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	settings, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		key        string
+		connection string
+		want       string
+		wantSource string
+	}{
+		{
+			name:       "Test Get default",
+			key:        KeyConfirmation,
+			want:       StrictnessDestructive,
+			wantSource: SourceDefault,
+		},
+		{
+			name:       "Test Get from the global file",
+			key:        KeyAIModel,
+			want:       "gpt-4o-mini",
+			wantSource: GlobalPath(),
+		},
+		{
+			name:       "Test Get from the workspace file over the global file",
+			key:        KeyAITemperature,
+			want:       "1",
+			wantSource: Path(),
+		},
+		{
+			name:       "Test Get connection override over the workspace file",
+			key:        KeyAITemperature,
+			connection: "prod-db",
+			want:       "0",
+			wantSource: GlobalPath() + " (connection prod-db)",
+		},
+		{
+			name:       "Test Get connection override of the workspace file",
+			key:        KeyOutputFormat,
+			connection: "prod-db",
+			want:       "yaml",
+			wantSource: Path() + " (connection prod-db)",
+		},
+		{
+			name:       "Test Get without overrides for other connections",
+			key:        KeyOutputFormat,
+			connection: "dev-db",
+			want:       "json",
+			wantSource: GlobalPath(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := settings.Get(tt.key, tt.connection)
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			if got.Value != tt.want || got.Source != tt.wantSource {
+				t.Errorf("Get() = %s from %s, want %s from %s", got.Value, got.Source, tt.want, tt.wantSource)
+			}
+		})
+	}
+
+	values := For("prod-db")
+	if values.AITemperature != 0 || values.Confirmation != StrictnessAll || values.UITheme != "mono" || values.AITimeout != 2*time.Minute {
+		t.Errorf("For() = %+v", values)
+	}
+}
+
+func TestLoadFile_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{
+			name:    "Test LoadFile with an unknown setting",
+			content: "ai:\n  modle: gpt-4o\n",
+		},
+		{
+			name:    "Test LoadFile with an invalid value",
+			content: "output:\n  rowLimit: lots\n",
+		},
+		{
+			name:    "Test LoadFile with an invalid connection override",
+			content: "connections:\n  prod:\n    ui:\n      theme: neon\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), FileName)
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("WriteFile() error = %v", err)
+			}
+
+			if _, err := LoadFile(path); err == nil {
+				t.Errorf("LoadFile() error = nil, want an error")
+			}
+		})
+	}
+}
@@ -22,6 +22,7 @@ var (
 
 const (
 	stepSelectProvider step = iota
+	stepSelectSubscription
 	stepEnterConnectionName
 	stepCreateSpinner
 	stepCreateDone
@@ -34,6 +35,10 @@ type (
 		Connection conn.Connection
 	}
 
+	subscriptionsMsg struct {
+		subscriptions []conn.AzureSubscription
+	}
+
 	errMsg struct {
 		err error
 	}
@@ -48,6 +53,13 @@ type createModel struct {
 
 	connection            conn.Connection
 	selectedCloudProvider conn.AvailableCloudConnectionType
+
+	subscriptions        []conn.AzureSubscription
+	subscriptionCursor   int
+	selectedSubscription conn.AzureSubscription
+
+	// readOnly restricts the new connection to commands that don't change anything.
+	readOnly bool
 }
 
 func NewCreateModel() *createModel {
@@ -93,6 +105,56 @@ func (m *createModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 			case "enter":
 				m.selectedCloudProvider = providers[m.cursor]
+				m.err = nil
+				if m.selectedCloudProvider == conn.AzureCloudConnection {
+					m.currentStep = stepSelectSubscription
+					return m, loadSubscriptionsCmd()
+				}
+				m.currentStep = stepEnterConnectionName
+				m.input.Focus()
+				return m, nil
+			case "q", "esc", "ctrl+c":
+				return m, tea.Quit
+			}
+		}
+
+	//----------------------------------------------------------------------
+	// stepSelectSubscription
+	//----------------------------------------------------------------------
+	case stepSelectSubscription:
+		switch msg := msg.(type) {
+		case subscriptionsMsg:
+			m.subscriptions = msg.subscriptions
+			if len(m.subscriptions) == 0 {
+				m.err = fmt.Errorf("no Azure subscriptions found, please run 'az login'")
+				return m, nil
+			}
+			// Preselect the default subscription of the az CLI.
+			for i, subscription := range m.subscriptions {
+				if subscription.IsDefault {
+					m.subscriptionCursor = i
+				}
+			}
+			m.err = nil
+			return m, nil
+		case errMsg:
+			m.err = msg.err
+			return m, nil
+		case tea.KeyMsg:
+			switch msg.String() {
+			case "up":
+				if m.subscriptionCursor > 0 {
+					m.subscriptionCursor--
+				}
+			case "down":
+				if m.subscriptionCursor < len(m.subscriptions)-1 {
+					m.subscriptionCursor++
+				}
+			case "enter":
+				if len(m.subscriptions) == 0 {
+					return m, nil
+				}
+				m.selectedSubscription = m.subscriptions[m.subscriptionCursor]
 				m.currentStep = stepEnterConnectionName
 				m.input.Focus()
 				m.err = nil
@@ -110,6 +172,9 @@ func (m *createModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case tea.KeyMsg:
 			m.input, cmd = m.input.Update(msg)
 			switch msg.String() {
+			case "tab":
+				m.readOnly = !m.readOnly
+				return m, nil
 			case "enter":
 				name := strings.TrimSpace(m.input.Value())
 				if name == "" {
@@ -122,6 +187,10 @@ func (m *createModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 
 				connection := conn.NewCloudConnection(name, m.selectedCloudProvider)
+				if m.selectedSubscription.ID != "" {
+					connection = conn.NewAzureCloudConnection(name, m.selectedSubscription)
+				}
+				connection.ReadOnly = m.readOnly
 				if err := config.SaveConnection(connection); err != nil {
 					m.err = err
 					return m, nil
@@ -191,6 +260,17 @@ func (m *createModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+// loadSubscriptionsCmd fetches the Azure subscriptions available to the az CLI
+func loadSubscriptionsCmd() tea.Cmd {
+	return func() tea.Msg {
+		subscriptions, err := conn.ListAzureSubscriptions()
+		if err != nil {
+			return errMsg{err}
+		}
+		return subscriptionsMsg{subscriptions: subscriptions}
+	}
+}
+
 func waitTwoSecondsCmd(conn conn.Connection) tea.Cmd {
 	return tea.Tick(2*time.Second, func(t time.Time) tea.Msg {
 		return doneWaitingMsg{
@@ -224,9 +304,34 @@ func (m *createModel) View() string {
 			lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render(footer),
 		)
 
+	case stepSelectSubscription:
+		title := "Select an Azure subscription (↑/↓, Enter to confirm):"
+		footer := ui.QuitMessage
+
+		var subscriptionSelection string
+		if m.err != nil {
+			subscriptionSelection = errorStyle.Render(fmt.Sprintf("Error: %v", m.err)) + "\n"
+		} else if m.subscriptions == nil {
+			subscriptionSelection = "Loading subscriptions...\n"
+		}
+		for i, subscription := range m.subscriptions {
+			cursor := "  "
+			if i == m.subscriptionCursor {
+				cursor = "→ "
+			}
+			subscriptionSelection += fmt.Sprintf("%s%s\n", cursor, promptStyle.Render(fmt.Sprintf("%s (%s, tenant %s)", subscription.Name, subscription.ID, subscription.TenantID)))
+		}
+
+		return fmt.Sprintf(
+			"%s\n\n%s\n%s",
+			titleStyle.Render(title),
+			subscriptionSelection,
+			lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render(footer),
+		)
+
 	case stepEnterConnectionName:
 		title := "Enter a name for the Cloud connection:"
-		footer := ui.QuitMessage
+		footer := ui.ReadOnlyToggleMessage(m.readOnly) + "\n" + ui.QuitMessage
 
 		if m.err != nil {
 			errorMessage := fmt.Sprintf("Error: %v", m.err)
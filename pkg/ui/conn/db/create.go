@@ -51,6 +51,9 @@ type createModel struct {
 
 	spinner spinner.Model
 
+	// readOnly restricts the new connection to statements that don't change anything.
+	readOnly bool
+
 	err error
 }
 
@@ -150,6 +153,9 @@ func (m *createModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, quitCmd
 			}
 			switch msg.String() {
+			case "tab":
+				m.readOnly = !m.readOnly
+				return m, nil
 			case "enter":
 				name := strings.TrimSpace(m.input.Value())
 				if name == "" {
@@ -163,6 +169,7 @@ func (m *createModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 
 				m.connection = conn.NewDatabaseConnection(name, m.selectedDatabaseConnection, m.connectionString)
+				m.connection.ReadOnly = m.readOnly
 				if err := config.SaveConnection(m.connection); err != nil {
 					m.err = err
 					m.currentStep = stepCreateDone
@@ -280,6 +287,7 @@ func (m *createModel) View() string {
 			s += "\n\n"
 		}
 		s += m.input.View()
+		s += "\n\n" + ui.ReadOnlyToggleMessage(m.readOnly)
 		s += "\n\nPress 'Enter' to save or 'q', 'esc' to quit."
 		return clearScreen + s
 
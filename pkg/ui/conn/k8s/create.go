@@ -22,6 +22,7 @@ var (
 
 const (
 	stepSelectContext step = iota
+	stepEnterNamespaces
 	stepEnterConnectionName
 	stepCreateSpinner
 	stepCreateDone
@@ -47,13 +48,18 @@ type createModel struct {
 	cursor      int
 	contexts    []string
 	selectedCtx string
+	namespaces  []string
 	input       textinput.Model
+	nsInput     textinput.Model
 	err         error
 
 	// Spinner for the 2-second wait
 	spinner spinner.Model
 
 	connection conn.Connection
+
+	// readOnly restricts the new connection to commands that don't change anything.
+	readOnly bool
 }
 
 // NewCreateModel initializes the createModel for Kubernetes
@@ -63,12 +69,18 @@ func NewCreateModel() *createModel {
 	ti.CharLimit = 256
 	ti.Width = 30
 
+	ni := textinput.New()
+	ni.Placeholder = "Comma separated namespaces (leave empty for all namespaces)"
+	ni.CharLimit = 512
+	ni.Width = 60
+
 	sp := spinner.New()
 	sp.Spinner = spinner.Dot
 
 	return &createModel{
 		currentStep: stepSelectContext,
 		input:       ti,
+		nsInput:     ni,
 		spinner:     sp,
 	}
 }
@@ -135,8 +147,8 @@ func (m *createModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			case "enter":
 				if len(m.contexts) > 0 && m.cursor >= 0 && m.cursor < len(m.contexts) {
 					m.selectedCtx = m.contexts[m.cursor]
-					m.currentStep = stepEnterConnectionName
-					m.input.Focus()
+					m.currentStep = stepEnterNamespaces
+					m.nsInput.Focus()
 
 					// Clear any previous errors when moving to a new step
 					m.err = nil
@@ -152,10 +164,33 @@ func (m *createModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.spinner, cmd = m.spinner.Update(msg)
 		return m, cmd
 
+	case stepEnterNamespaces:
+		switch msg := msg.(type) {
+		case tea.KeyMsg:
+			switch msg.String() {
+			case "enter":
+				m.namespaces = parseNamespaces(m.nsInput.Value())
+				m.currentStep = stepEnterConnectionName
+				m.input.Focus()
+				m.err = nil
+				return m, nil
+			case "esc", "ctrl+c":
+				return m, tea.Quit
+			}
+		case spinner.TickMsg:
+			return m, nil
+		}
+
+		m.nsInput, cmd = m.nsInput.Update(msg)
+		return m, cmd
+
 	case stepEnterConnectionName:
 		switch msg := msg.(type) {
 		case tea.KeyMsg:
 			switch msg.String() {
+			case "tab":
+				m.readOnly = !m.readOnly
+				return m, nil
 			case "enter":
 				name := strings.TrimSpace(m.input.Value())
 				if name == "" {
@@ -167,7 +202,8 @@ func (m *createModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					return m, nil
 				}
 
-				connection := conn.NewKubernetesConnection(name, m.selectedCtx)
+				connection := conn.NewKubernetesConnection(name, m.selectedCtx, m.namespaces...)
+				connection.ReadOnly = m.readOnly
 				if err := config.SaveConnection(connection); err != nil {
 					m.err = err
 					return m, nil
@@ -237,6 +273,18 @@ func (m *createModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+// parseNamespaces splits a comma separated list of namespaces.
+func parseNamespaces(value string) []string {
+	var namespaces []string
+	for _, ns := range strings.Split(value, ",") {
+		ns = strings.TrimSpace(ns)
+		if ns != "" {
+			namespaces = append(namespaces, ns)
+		}
+	}
+	return namespaces
+}
+
 func (m *createModel) View() string {
 	// Clear the terminal before rendering the UI
 	clearScreen := "\033[H\033[2J"
@@ -257,6 +305,13 @@ func (m *createModel) View() string {
 		s += "\n" + helpStyle.Render(ui.QuitMessage)
 		return clearScreen + s
 
+	case stepEnterNamespaces:
+		s := titleStyle.Render("Restrict the connection to namespaces (optional):")
+		s += "\n\n"
+		s += m.nsInput.View()
+		s += "\n" + helpStyle.Render("Press 'Enter' to continue or 'esc' to quit.")
+		return clearScreen + s
+
 	case stepEnterConnectionName:
 		s := titleStyle.Render("Enter a name for the Kubernetes connection:")
 		s += "\n\n"
@@ -265,6 +320,7 @@ func (m *createModel) View() string {
 			s += "\n"
 		}
 		s += m.input.View()
+		s += "\n\n" + ui.ReadOnlyToggleMessage(m.readOnly)
 		s += "\n" + helpStyle.Render(ui.QuitMessage)
 		return clearScreen + s
 
@@ -0,0 +1,272 @@
+package plugin
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	config "github.com/prompt-ops/pops/pkg/config"
+	"github.com/prompt-ops/pops/pkg/conn"
+	"github.com/prompt-ops/pops/pkg/ui"
+)
+
+// Styles
+var (
+	outputStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("10"))
+)
+
+const (
+	stepSelectPlugin step = iota
+	stepEnterConnectionName
+	stepCreateSpinner
+	stepCreateDone
+)
+
+type (
+	doneWaitingMsg struct {
+		Connection conn.Connection
+	}
+
+	pluginsMsg struct {
+		plugins []conn.AvailablePluginConnectionType
+	}
+
+	errMsg struct {
+		err error
+	}
+)
+
+// createModel defines the state of the UI
+type createModel struct {
+	currentStep    step
+	cursor         int
+	plugins        []conn.AvailablePluginConnectionType
+	selectedPlugin conn.AvailablePluginConnectionType
+	input          textinput.Model
+	err            error
+
+	// Spinner for the 2-second wait
+	spinner spinner.Model
+
+	connection conn.Connection
+
+	// readOnly tells the plugin to reject commands that could change anything.
+	readOnly bool
+}
+
+// NewCreateModel initializes the createModel for plugins
+func NewCreateModel() *createModel {
+	ti := textinput.New()
+	ti.Placeholder = ui.EnterConnectionNameMessage
+	ti.CharLimit = 256
+	ti.Width = 30
+
+	sp := spinner.New()
+	sp.Spinner = spinner.Dot
+
+	return &createModel{
+		currentStep: stepSelectPlugin,
+		input:       ti,
+		spinner:     sp,
+	}
+}
+
+// Init initializes the createModel
+func (m *createModel) Init() tea.Cmd {
+	return m.loadPluginsCmd()
+}
+
+// loadPluginsCmd discovers the plugins on PATH
+func (m *createModel) loadPluginsCmd() tea.Cmd {
+	return func() tea.Msg {
+		return pluginsMsg{plugins: conn.DiscoverPlugins()}
+	}
+}
+
+// waitTwoSecondsCmd simulates a delay for saving the connection asynchronously
+func waitTwoSecondsCmd(conn conn.Connection) tea.Cmd {
+	return tea.Tick(2*time.Second, func(t time.Time) tea.Msg {
+		return doneWaitingMsg{
+			Connection: conn,
+		}
+	})
+}
+
+// Update handles incoming messages and updates the createModel accordingly
+func (m *createModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch m.currentStep {
+	case stepSelectPlugin:
+		switch msg := msg.(type) {
+		case pluginsMsg:
+			m.plugins = msg.plugins
+			if len(m.plugins) == 0 {
+				m.err = fmt.Errorf("no plugins found on PATH (executables named %s<type>)", conn.PluginExecutablePrefix)
+				m.currentStep = stepCreateDone
+				return m, nil
+			}
+			m.err = nil
+			return m, nil
+		case tea.KeyMsg:
+			switch msg.String() {
+			case "up", "k":
+				if m.cursor > 0 {
+					m.cursor--
+				}
+			case "down", "j":
+				if m.cursor < len(m.plugins)-1 {
+					m.cursor++
+				}
+			case "enter":
+				if len(m.plugins) > 0 {
+					m.selectedPlugin = m.plugins[m.cursor]
+					m.currentStep = stepEnterConnectionName
+					m.input.Focus()
+					m.err = nil
+					return m, nil
+				}
+			case "q", "esc", "ctrl+c":
+				return m, tea.Quit
+			}
+		}
+		return m, nil
+
+	case stepEnterConnectionName:
+		switch msg := msg.(type) {
+		case tea.KeyMsg:
+			switch msg.String() {
+			case "tab":
+				m.readOnly = !m.readOnly
+				return m, nil
+			case "enter":
+				name := strings.TrimSpace(m.input.Value())
+				if name == "" {
+					m.err = fmt.Errorf("connection name can't be empty")
+					return m, nil
+				}
+				if config.CheckIfNameExists(name) {
+					m.err = fmt.Errorf("connection name already exists")
+					return m, nil
+				}
+
+				connection := conn.NewPluginConnection(name, m.selectedPlugin, nil)
+				connection.ReadOnly = m.readOnly
+				if err := config.SaveConnection(connection); err != nil {
+					m.err = err
+					return m, nil
+				}
+				m.currentStep = stepCreateSpinner
+				m.err = nil
+				return m, tea.Batch(
+					m.spinner.Tick,
+					waitTwoSecondsCmd(connection),
+				)
+			case "esc", "ctrl+c":
+				return m, tea.Quit
+			}
+		}
+
+		m.input, cmd = m.input.Update(msg)
+		return m, cmd
+
+	case stepCreateSpinner:
+		switch msg := msg.(type) {
+		case spinner.TickMsg:
+			m.spinner, cmd = m.spinner.Update(msg)
+			return m, cmd
+
+		case doneWaitingMsg:
+			m.connection = msg.Connection
+			m.currentStep = stepCreateDone
+			m.err = nil
+			return m, nil
+
+		case errMsg:
+			m.err = msg.err
+			m.currentStep = stepCreateDone
+			m.connection = conn.Connection{}
+			return m, nil
+
+		case tea.KeyMsg:
+			switch msg.String() {
+			case "q", "esc", "ctrl+c":
+				return m, tea.Quit
+			}
+		}
+
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+
+	case stepCreateDone:
+		switch msg := msg.(type) {
+		case tea.KeyMsg:
+			switch msg.String() {
+			case "enter":
+				if m.err != nil {
+					return m, tea.Quit
+				}
+				return m, func() tea.Msg {
+					return ui.TransitionToShellMsg{
+						Connection: m.connection,
+					}
+				}
+			case "q", "esc", "ctrl+c":
+				return m, tea.Quit
+			}
+		}
+	}
+
+	return m, cmd
+}
+
+func (m *createModel) View() string {
+	// Clear the terminal before rendering the UI
+	clearScreen := "\033[H\033[2J"
+
+	switch m.currentStep {
+	case stepSelectPlugin:
+		s := titleStyle.Render("Select a plugin (↑/↓, Enter to confirm):")
+		s += "\n\n"
+		for i, plugin := range m.plugins {
+			cursor := "  "
+			if i == m.cursor {
+				cursor = "→ "
+				s += selectedStyle.Render(cursor+plugin.Subtype) + "\n"
+				continue
+			}
+			s += unselectedStyle.Render(cursor+plugin.Subtype) + "\n"
+		}
+		s += "\n" + helpStyle.Render(ui.QuitMessage)
+		return clearScreen + s
+
+	case stepEnterConnectionName:
+		s := titleStyle.Render("Enter a name for the plugin connection:")
+		s += "\n\n"
+		if m.err != nil {
+			s += errorStyle.Render(fmt.Sprintf("Error: %v", m.err))
+			s += "\n"
+		}
+		s += m.input.View()
+		s += "\n\n" + ui.ReadOnlyToggleMessage(m.readOnly)
+		s += "\n" + helpStyle.Render(ui.QuitMessage)
+		return clearScreen + s
+
+	case stepCreateSpinner:
+		return clearScreen + outputStyle.Render("Saving connection... ") + m.spinner.View()
+
+	case stepCreateDone:
+		if m.err != nil {
+			return clearScreen + errorStyle.Render(fmt.Sprintf("❌ Error: %v\n\nPress 'Enter' or 'q'/'esc' to quit.", m.err))
+		}
+
+		return clearScreen + outputStyle.Render("✅ Plugin connection created!\n\nPress 'Enter' or 'q'/'esc' to exit.")
+
+	default:
+		return clearScreen
+	}
+}
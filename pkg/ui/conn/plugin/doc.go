@@ -0,0 +1,3 @@
+package plugin
+
+// This package provides plugin connection UI components and utilities.
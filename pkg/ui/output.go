@@ -0,0 +1,39 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/prompt-ops/pops/pkg/conn"
+)
+
+// PrintTable prints the rows of a table in an output format, instead of showing the interactive table.
+// Used by the non-interactive commands with the --output flag.
+func PrintTable(columns []table.Column, rows []table.Row, format string) error {
+	formatter, err := conn.GetResultFormatter(format)
+	if err != nil {
+		return err
+	}
+
+	result := &conn.ResultSet{
+		Columns: make([]conn.Column, len(columns)),
+		Rows:    make([][]interface{}, len(rows)),
+	}
+	for i, column := range columns {
+		result.Columns[i] = conn.Column{Name: column.Title}
+	}
+	for i, row := range rows {
+		result.Rows[i] = make([]interface{}, len(row))
+		for j, value := range row {
+			result.Rows[i][j] = value
+		}
+	}
+
+	output, err := formatter(result)
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(output)
+	return nil
+}
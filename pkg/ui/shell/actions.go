@@ -1,6 +1,20 @@
 package shell
 
-import tea "github.com/charmbracelet/bubbletea"
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/prompt-ops/pops/pkg/ai"
+	"github.com/prompt-ops/pops/pkg/audit"
+	"github.com/prompt-ops/pops/pkg/cache"
+	"github.com/prompt-ops/pops/pkg/config"
+	"github.com/prompt-ops/pops/pkg/conn"
+	"github.com/prompt-ops/pops/pkg/policy"
+)
 
 func (m shellModel) runInitialChecks() tea.Msg {
 	err := m.popsConnection.CheckAuthentication()
@@ -8,14 +22,59 @@ func (m shellModel) runInitialChecks() tea.Msg {
 		return errMsg{err}
 	}
 
+	// Recording the use is best effort; failing to write it must not prevent using the connection.
+	_ = config.RecordUse(m.connection.Name)
+
+	// Use the cached context if there is one; it is refreshed in the background once expired.
+	if entry := cache.LoadContext(m.popsConnection); entry != nil {
+		return checkPassedMsg{cached: entry}
+	}
+
 	err = m.popsConnection.SetContext()
 	if err != nil {
 		return errMsg{err}
 	}
 
+	// The cache is best effort; failing to write it must not prevent using the connection.
+	_ = cache.Store(m.popsConnection)
+
 	return checkPassedMsg{}
 }
 
+// refreshContext gathers the context on a separate connection, so the shell stays usable meanwhile.
+// The gathered context is imported into the shell's connection once it is idle.
+func (m shellModel) refreshContext() tea.Cmd {
+	connection := m.connection
+	return func() tea.Msg {
+		popsConn, err := conn.GetConnection(connection)
+		if err != nil {
+			return contextRefreshedMsg{err: err}
+		}
+		if closer, ok := popsConn.(io.Closer); ok {
+			defer closer.Close()
+		}
+
+		if err := popsConn.SetContext(); err != nil {
+			return contextRefreshedMsg{err: err}
+		}
+
+		context, err := popsConn.ExportContext()
+		if err != nil {
+			return contextRefreshedMsg{err: err}
+		}
+
+		// The cache is best effort; the refreshed context is used either way.
+		if cache.Enabled() {
+			_ = cache.Save(connection, context)
+		}
+
+		return contextRefreshedMsg{
+			context:   context,
+			updatedAt: time.Now(),
+		}
+	}
+}
+
 func (m shellModel) generateCommand(prompt string) tea.Cmd {
 	return func() tea.Msg {
 		cmd, err := m.popsConnection.GetCommand(prompt)
@@ -23,25 +82,117 @@ func (m shellModel) generateCommand(prompt string) tea.Cmd {
 			return errMsg{err}
 		}
 
+		// Reject commands that run other tools before asking for confirmation.
+		if err := conn.ValidateCommand(m.popsConnection, cmd); err != nil {
+			return errMsg{err}
+		}
+
+		// Denied commands are not offered for confirmation; warnings are shown with the command.
+		decision := policy.Check(m.popsConnection, cmd)
+		if err := decision.Err(); err != nil {
+			return errMsg{err}
+		}
+
+		risk := m.popsConnection.ClassifyCommand(cmd)
+		if conn.AIRiskReviewEnabled() {
+			risk = conn.ReviewRisk(m.popsConnection, cmd, risk)
+		}
+
 		return commandMsg{
 			command: cmd,
+			risk:    risk,
+			policy:  decision,
 		}
 	}
 }
 
-func (m shellModel) runCommand(command string) tea.Cmd {
+func (m shellModel) runCommand(command, confirmation string) tea.Cmd {
+	entry := m.auditEntry(audit.ActionRun, command, confirmation)
 	return func() tea.Msg {
+		// The policy is checked again, as the policy file can change while the command waits for confirmation.
+		// Commands it blocks are recorded too, as attempts to run them.
+		if err := policy.Check(m.popsConnection, command).Err(); err != nil {
+			entry.SetDenied(err)
+			if auditErr := audit.Record(entry); auditErr != nil {
+				err = errors.Join(err, fmt.Errorf("failed to write the audit log: %w", auditErr))
+			}
+			return errMsg{err}
+		}
+
+		started := time.Now()
 		out, err := m.popsConnection.ExecuteCommand(command)
+		entry.SetResult(out, err, time.Since(started))
+		auditErr := audit.Record(entry)
 		if err != nil {
+			if auditErr != nil {
+				err = errors.Join(err, fmt.Errorf("failed to write the audit log: %w", auditErr))
+			}
 			return errMsg{err}
 		}
 
-		outStr, err := m.popsConnection.FormatResultAsTable(out)
+		outStr, err := conn.FormatResult(m.popsConnection, out, m.format)
 		if err != nil {
+			out.Close()
 			return errMsg{err}
 		}
 
 		return outputMsg{
+			output:   outStr,
+			result:   out,
+			auditErr: auditErr,
+		}
+	}
+}
+
+// previewCommand shows what the command would change without applying it.
+// Previews run commands as well, so they are recorded in the audit log.
+func (m shellModel) previewCommand(command string) tea.Cmd {
+	entry := m.auditEntry(audit.ActionPreview, command, "p")
+	return func() tea.Msg {
+		started := time.Now()
+		preview, err := m.popsConnection.PreviewCommand(command)
+		entry.SetResult(nil, err, time.Since(started))
+		if err == nil && strings.TrimSpace(preview) == "" {
+			preview = "The preview reported no changes."
+		}
+
+		var auditErr error
+		if !errors.Is(err, conn.ErrPreviewNotSupported) {
+			auditErr = audit.Record(entry)
+		}
+
+		return previewMsg{
+			preview:  preview,
+			err:      err,
+			auditErr: auditErr,
+		}
+	}
+}
+
+// auditEntry returns the audit log entry for running the command to confirm.
+func (m shellModel) auditEntry(action, command, confirmation string) audit.Entry {
+	entry := audit.NewEntry(m.connection, action, command)
+	entry.Prompt = strings.TrimSpace(m.promptInput.Value())
+	entry.Model = ai.ModelName(m.settings.AIModel)
+	entry.Confirmation = confirmation
+	entry.Risk = m.risk.Risk.String()
+	return entry
+}
+
+// fetchMore fetches the next rows of the result and formats the whole result again.
+func (m shellModel) fetchMore() tea.Cmd {
+	result := m.result
+	return func() tea.Msg {
+		if err := result.FetchMore(); err != nil {
+			return moreOutputMsg{err: err}
+		}
+
+		outStr, err := conn.FormatResult(m.popsConnection, result, m.format)
+		if err != nil {
+			return moreOutputMsg{err: err}
+		}
+
+		return moreOutputMsg{
 			output: outStr,
 		}
 	}
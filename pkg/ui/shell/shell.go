@@ -1,13 +1,19 @@
 package shell
 
 import (
+	"fmt"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/prompt-ops/pops/pkg/cache"
 	"github.com/prompt-ops/pops/pkg/conn"
+	"github.com/prompt-ops/pops/pkg/policy"
+	"github.com/prompt-ops/pops/pkg/settings"
 	"golang.org/x/term"
 )
 
@@ -56,18 +62,70 @@ type shellModel struct {
 	checkPassed    bool
 	mode           queryMode
 	windowWidth    int
+	windowHeight   int
+
+	// result is the result of the last command; it can have more rows to fetch.
+	result *conn.ResultSet
+
+	// fetching is true while more rows of the result are fetched.
+	fetching bool
+
+	// outputViewport scrolls the output of the last command or answer.
+	outputViewport viewport.Model
+
+	// format is the output format of command results in this session.
+	// Example: "table", "json", "yaml", "csv", "markdown".
+	format string
+
+	// notice is feedback on the last slash command.
+	notice string
+
+	// contextUpdatedAt is when the context in use was gathered.
+	contextUpdatedAt time.Time
+
+	// refreshing is true while the context is refreshed in the background.
+	refreshing bool
+
+	// refreshedContext is a refreshed context waiting for the shell to be idle to be imported.
+	refreshedContext *contextRefreshedMsg
+
+	// refreshErr is the error of the last background refresh, if it failed.
+	refreshErr error
+
+	// previewing is true while the command to confirm is previewed.
+	previewing bool
+
+	// preview is the preview of the command to confirm, or the error previewing it.
+	preview    string
+	previewErr error
+
+	// risk is how risky the command to confirm is. Depending on the confirmation.strictness setting,
+	// risky commands are confirmed by typing risk.Target.
+	risk conn.RiskAssessment
+
+	// confirmHint explains why the last confirmation was not accepted.
+	confirmHint string
+
+	// policy is the decision of the policy file on the command to confirm.
+	policy policy.Decision
+
+	// settings are the settings of the connection.
+	settings settings.Values
 }
 
 func NewShellModel(connection conn.Connection) shellModel {
+	values := settings.For(connection.Name)
+	setTheme(values.UITheme)
+
 	ti := textinput.New()
 	ti.Placeholder = "Define the command or query to be generated via Prompt-Ops..."
 	ti.Focus()
-	ti.CharLimit = 512
+	ti.CharLimit = values.UIPromptCharLimit
 	ti.Width = 100
 
 	ci := textinput.New()
-	ci.Placeholder = "Y/n"
-	ci.CharLimit = 3
+	ci.Placeholder = "Y/n/p"
+	ci.CharLimit = 256
 	ci.Width = 100
 	ci.PromptStyle.Padding(0, 1)
 
@@ -89,6 +147,9 @@ func NewShellModel(connection conn.Connection) shellModel {
 		popsConnection: popsConn,
 		spinner:        sp,
 		mode:           modeCommand,
+		outputViewport: viewport.New(80, 20),
+		format:         values.OutputFormat,
+		settings:       values,
 	}
 }
 
@@ -103,19 +164,53 @@ func (m shellModel) Init() tea.Cmd {
 
 func (m shellModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	if key, ok := msg.(tea.KeyMsg); ok && key.Type == tea.KeyCtrlC {
+		m.closeResult()
 		return m, tea.Quit
 	}
 
+	m.importRefreshedContext()
+
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		m.windowWidth = msg.Width
+		m.windowHeight = msg.Height
+		m.resizeOutputViewport()
+		return m, nil
+
+	case moreOutputMsg:
+		m.fetching = false
+		if msg.err != nil {
+			m.closeResult()
+			m.err = msg.err
+			return m, nil
+		}
+		m.setOutput(msg.output)
 		return m, nil
 
 	case checkPassedMsg:
 		m.checkPassed = true
 		m.step = stepEnterPrompt
+		if msg.cached == nil {
+			m.contextUpdatedAt = time.Now()
+			return m, textinput.Blink
+		}
+
+		m.contextUpdatedAt = msg.cached.UpdatedAt
+		if msg.cached.Expired(cache.TTL()) {
+			m.refreshing = true
+			return m, tea.Batch(textinput.Blink, m.refreshContext())
+		}
 		return m, textinput.Blink
 
+	case contextRefreshedMsg:
+		m.refreshing = false
+		m.refreshErr = msg.err
+		if msg.err == nil {
+			m.refreshedContext = &msg
+			m.importRefreshedContext()
+		}
+		return m, nil
+
 	case errMsg:
 		m.err = msg.err
 		m.step = stepDone
@@ -133,6 +228,10 @@ func (m shellModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case tea.KeyMsg:
 			if msg.Type == tea.KeyF1 {
 				m.step = stepEnterPrompt
+			} else if msg.Type == tea.KeyF5 && !m.refreshing {
+				m.refreshing = true
+				m.refreshErr = nil
+				return m, m.refreshContext()
 			}
 		}
 		return m, nil
@@ -170,8 +269,18 @@ func (m shellModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 				m.updatePromptInputPlaceholder()
 
+			case tea.KeyF2:
+				m.cycleFormat()
+
 			case tea.KeyEnter:
 				prompt := strings.TrimSpace(m.promptInput.Value())
+				if strings.HasPrefix(prompt, "/") {
+					m.runSlashCommand(prompt)
+					m.promptInput.Reset()
+					return m, nil
+				}
+
+				m.notice = ""
 				if prompt != "" {
 					if m.mode == modeCommand {
 						m.step = stepGenerateCommand
@@ -185,6 +294,13 @@ func (m shellModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			case tea.KeyCtrlC, tea.KeyEsc:
 				return m, tea.Quit
 
+			case tea.KeyF5:
+				if !m.refreshing {
+					m.refreshing = true
+					m.refreshErr = nil
+					return m, m.refreshContext()
+				}
+
 			case tea.KeyF1:
 				m.step = stepShowContext
 				output, err := m.popsConnection.GetFormattedContext()
@@ -201,6 +317,15 @@ func (m shellModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case stepGenerateCommand:
 		if cmdMsg, ok := msg.(commandMsg); ok {
 			m.command = cmdMsg.command
+			m.risk = cmdMsg.risk
+			m.policy = cmdMsg.policy
+			m.preview = ""
+			m.previewErr = nil
+			m.confirmHint = ""
+			m.confirmInput.Placeholder = "Y/n/p"
+			if m.typedConfirmation() {
+				m.confirmInput.Placeholder = fmt.Sprintf("Type %s to run, n to cancel, p to preview", m.confirmTarget())
+			}
 			m.step = stepConfirmRun
 			m.confirmInput.Focus()
 			return m, textinput.Blink
@@ -209,33 +334,59 @@ func (m shellModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case stepGetAnswer:
 		if ansMsg, ok := msg.(answerMsg); ok {
-			m.output = ansMsg.answer
+			m.setOutput(ansMsg.answer)
+			m.outputViewport.GotoTop()
 			m.step = stepDone
 			return m, nil
 		}
 		return m, nil
 
 	case stepConfirmRun:
+		if previewMsg, ok := msg.(previewMsg); ok {
+			m.previewing = false
+			m.preview = previewMsg.preview
+			m.previewErr = previewMsg.err
+			if previewMsg.auditErr != nil {
+				m.notice = "Failed to write the audit log: " + previewMsg.auditErr.Error()
+			}
+			return m, nil
+		}
+
 		var cmd tea.Cmd
 		m.confirmInput, cmd = m.confirmInput.Update(msg)
-		if key, ok := msg.(tea.KeyMsg); ok && key.Type == tea.KeyEnter {
-			val := m.confirmInput.Value()
-			if val == "Y" || val == "y" {
+		if key, ok := msg.(tea.KeyMsg); ok && key.Type == tea.KeyEnter && !m.previewing {
+			val := strings.TrimSpace(m.confirmInput.Value())
+			m.confirmHint = ""
+			if val == "P" || val == "p" {
+				m.previewing = true
+				m.preview = ""
+				m.previewErr = nil
+				m.confirmInput.Reset()
+				return m, m.previewCommand(m.command)
+			} else if m.confirmed(val) {
 				m.step = stepRunCommand
-				return m, m.runCommand(m.command)
+				return m, m.runCommand(m.command, val)
 			} else if val == "N" || val == "n" {
 				m.step = stepEnterPrompt
 				m.promptInput.Reset()
 				m.confirmInput.Reset()
 				m.historyIndex = len(m.history)
 				return m, textinput.Blink
+			} else if m.typedConfirmation() && val != "" {
+				m.confirmHint = fmt.Sprintf("Type %s exactly to run this %s command.", m.confirmTarget(), m.risk.Risk)
+				m.confirmInput.Reset()
 			}
 		}
 		return m, cmd
 
 	case stepRunCommand:
 		if outMsg, ok := msg.(outputMsg); ok {
-			m.output = outMsg.output
+			m.result = outMsg.result
+			if outMsg.auditErr != nil {
+				m.notice = "Failed to write the audit log: " + outMsg.auditErr.Error()
+			}
+			m.setOutput(outMsg.output)
+			m.outputViewport.GotoTop()
 			m.step = stepDone
 			return m, nil
 		}
@@ -260,8 +411,28 @@ func (m shellModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if key, ok := msg.(tea.KeyMsg); ok {
 			switch key.String() {
 			case "q", "esc", "ctrl+c":
+				if !m.fetching {
+					m.closeResult()
+				}
 				return m, tea.Quit
+			case "m":
+				if m.result != nil && m.result.HasMore() && !m.fetching {
+					m.fetching = true
+					return m, m.fetchMore()
+				}
+				return m, nil
+			case "f2":
+				if !m.fetching {
+					m.cycleFormat()
+					m.reformatResult()
+				}
+				return m, nil
 			case "enter":
+				if m.fetching {
+					return m, nil
+				}
+				m.closeResult()
+
 				mode := "Command"
 				if m.mode == modeAnswer {
 					mode = "Answer"
@@ -283,7 +454,10 @@ func (m shellModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		}
 
-		return m, nil
+		// Other keys scroll the output.
+		var cmd tea.Cmd
+		m.outputViewport, cmd = m.outputViewport.Update(msg)
+		return m, cmd
 
 	default:
 		return m, tea.Quit
@@ -327,6 +501,9 @@ func (m shellModel) View() string {
 		content = ""
 	}
 
+	if m.connection.IsProduction() {
+		return lipgloss.JoinVertical(lipgloss.Top, m.viewProductionBanner(), historyView, content)
+	}
 	return lipgloss.JoinVertical(lipgloss.Top, historyView, content)
 }
 
@@ -343,6 +520,138 @@ func requestWindowSize() tea.Cmd {
 	}
 }
 
+// runSlashCommand runs a shell command like "/format json" typed at the prompt.
+func (m *shellModel) runSlashCommand(input string) {
+	fields := strings.Fields(input)
+	switch fields[0] {
+	case "/format", "/output":
+		if len(fields) != 2 {
+			m.notice = fmt.Sprintf("Usage: /format <%s>", strings.Join(conn.AvailableResultFormats(), "|"))
+			return
+		}
+		if _, err := conn.GetResultFormatter(fields[1]); err != nil {
+			m.notice = err.Error()
+			return
+		}
+		m.format = strings.ToLower(fields[1])
+		m.notice = fmt.Sprintf("Output format set to %s.", m.format)
+	default:
+		m.notice = fmt.Sprintf("Unknown command %s. Available: /format <%s>", fields[0], strings.Join(conn.AvailableResultFormats(), "|"))
+	}
+}
+
+// cycleFormat switches to the next available output format.
+func (m *shellModel) cycleFormat() {
+	formats := conn.AvailableResultFormats()
+	next := 0
+	for i, format := range formats {
+		if format == m.format {
+			next = (i + 1) % len(formats)
+			break
+		}
+	}
+	m.format = formats[next]
+	m.notice = fmt.Sprintf("Output format set to %s.", m.format)
+}
+
+// reformatResult renders the result of the last command again in the current output format.
+func (m *shellModel) reformatResult() {
+	if m.result == nil {
+		return
+	}
+
+	output, err := conn.FormatResult(m.popsConnection, m.result, m.format)
+	if err != nil {
+		m.closeResult()
+		m.err = err
+		return
+	}
+	m.setOutput(output)
+}
+
+// setOutput sets the output shown when done, keeping the scroll position.
+func (m *shellModel) setOutput(output string) {
+	m.output = output
+	m.outputViewport.SetContent(outputStyle.Render(output))
+}
+
+// resizeOutputViewport fits the output viewport to the window, leaving room for the footer.
+func (m *shellModel) resizeOutputViewport() {
+	m.outputViewport.Width = m.calculateShareViewWidth()
+	m.outputViewport.Height = m.windowHeight - 5
+	if m.outputViewport.Height < 5 {
+		m.outputViewport.Height = 5
+	}
+}
+
+// closeResult stops fetching the result of the last command, like a running query or command.
+func (m *shellModel) closeResult() {
+	if m.result != nil {
+		_ = m.result.Close()
+		m.result = nil
+	}
+}
+
+// importRefreshedContext imports a context refreshed in the background into the connection.
+// It waits until no command or answer is being generated or run, as those read the context concurrently.
+func (m *shellModel) importRefreshedContext() {
+	if m.refreshedContext == nil {
+		return
+	}
+
+	switch m.step {
+	case stepInitialChecks, stepGenerateCommand, stepGetAnswer, stepRunCommand:
+		return
+	}
+	if m.previewing {
+		return
+	}
+
+	refreshed := m.refreshedContext
+	m.refreshedContext = nil
+	if err := m.popsConnection.ImportContext(refreshed.context); err != nil {
+		m.refreshErr = err
+		return
+	}
+	m.contextUpdatedAt = refreshed.updatedAt
+
+	if m.step == stepShowContext {
+		if output, err := m.popsConnection.GetFormattedContext(); err == nil {
+			m.output = output
+		}
+	}
+}
+
+// confirmed reports whether the answer confirms running the command.
+// Risky commands are only confirmed by typing their target, so a reflexive "y" doesn't run them.
+func (m shellModel) confirmed(answer string) bool {
+	if m.typedConfirmation() {
+		return answer == m.confirmTarget()
+	}
+	return answer == "Y" || answer == "y"
+}
+
+// typedConfirmation reports whether the command has to be confirmed by typing its target,
+// which depends on its risk and the confirmation.strictness setting.
+func (m shellModel) typedConfirmation() bool {
+	switch m.settings.Confirmation {
+	case settings.StrictnessAll:
+		return true
+	case settings.StrictnessWrite:
+		return m.risk.Risk >= conn.RiskWrite
+	default:
+		return m.risk.Risk == conn.RiskDestructive
+	}
+}
+
+// confirmTarget returns what has to be typed to confirm the command: its target, or the connection name.
+func (m shellModel) confirmTarget() string {
+	if m.risk.Target != "" {
+		return m.risk.Target
+	}
+	return m.connection.Name
+}
+
 func (m *shellModel) updatePromptInputPlaceholder() {
 	if m.mode == modeAnswer {
 		m.promptInput.Placeholder = "Ask a question via Prompt-Ops..."
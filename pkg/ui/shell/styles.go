@@ -1,54 +1,132 @@
 package shell
 
-import "github.com/charmbracelet/lipgloss"
+import (
+	"github.com/charmbracelet/lipgloss"
+	"github.com/prompt-ops/pops/pkg/conn"
+)
+
+// palette are the colours of a theme.
+type palette struct {
+	title, text, border, label lipgloss.TerminalColor
+	read, write, destructive   lipgloss.TerminalColor
+
+	// banner is the text colour of the production banner, which has the destructive colour as background.
+	banner lipgloss.TerminalColor
+}
+
+// themes are the colours of the shell by the ui.theme setting.
+var themes = map[string]palette{
+	// default is for dark terminals.
+	"default": {
+		title: lipgloss.Color("15"), text: lipgloss.Color("10"), border: lipgloss.Color("240"), label: lipgloss.Color("212"),
+		read: lipgloss.Color("10"), write: lipgloss.Color("11"), destructive: lipgloss.Color("9"),
+		banner: lipgloss.Color("15"),
+	},
+	"light": {
+		title: lipgloss.Color("0"), text: lipgloss.Color("22"), border: lipgloss.Color("245"), label: lipgloss.Color("127"),
+		read: lipgloss.Color("28"), write: lipgloss.Color("130"), destructive: lipgloss.Color("160"),
+		banner: lipgloss.Color("15"),
+	},
+	"mono": {
+		title: lipgloss.NoColor{}, text: lipgloss.NoColor{}, border: lipgloss.NoColor{}, label: lipgloss.NoColor{},
+		read: lipgloss.NoColor{}, write: lipgloss.NoColor{}, destructive: lipgloss.NoColor{},
+		banner: lipgloss.NoColor{},
+	},
+}
 
 var (
+	titleStyle                       lipgloss.Style
+	promptStyle                      lipgloss.Style
+	commandConfirmationTitleStyle    lipgloss.Style
+	commandConfirmationContentStyle  lipgloss.Style
+	commandConfirmationResponseStyle lipgloss.Style
+	outputStyle                      lipgloss.Style
+	errorStyle                       lipgloss.Style
+	footerStyle                      lipgloss.Style
+
+	// History related styles
+	historyContainerStyle lipgloss.Style
+	historyLabelStyle     lipgloss.Style
+	historyCommandStyle   lipgloss.Style
+
+	// productionBannerStyle highlights that the connection is a production one.
+	productionBannerStyle lipgloss.Style
+
+	// riskStyles colour the risk of a command: green for reads, yellow for writes and red for destructive commands.
+	riskStyles map[conn.Risk]lipgloss.Style
+)
+
+func init() {
+	setTheme("default")
+}
+
+// setTheme sets the styles to the colours of the theme. Unknown themes are the default one.
+func setTheme(name string) {
+	colors, ok := themes[name]
+	if !ok {
+		colors = themes["default"]
+	}
+
 	titleStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(lipgloss.Color("15")).
-			Padding(0, 1)
+		Bold(true).
+		Foreground(colors.title).
+		Padding(0, 1)
 
 	promptStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("10")).
-			Padding(0, 1)
+		Foreground(colors.text).
+		Padding(0, 1)
 
 	commandConfirmationTitleStyle = lipgloss.NewStyle().
-					Bold(true).
-					Foreground(lipgloss.Color("15")).
-					Padding(0, 1)
+		Bold(true).
+		Foreground(colors.title).
+		Padding(0, 1)
 
 	commandConfirmationContentStyle = lipgloss.NewStyle().
-					Foreground(lipgloss.Color("10")).
-					Padding(0, 1)
+		Foreground(colors.text).
+		Padding(0, 1)
 
 	commandConfirmationResponseStyle = lipgloss.NewStyle().
-						Foreground(lipgloss.Color("10")).
-						Padding(0, 1)
+		Foreground(colors.text).
+		Padding(0, 1)
 
 	outputStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("10")).
-			Padding(0, 1)
+		Foreground(colors.text).
+		Padding(0, 1)
 
 	errorStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("10")).
-			Padding(0, 1)
+		Foreground(colors.text).
+		Padding(0, 1)
 
 	footerStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(lipgloss.Color("10")).
-			Padding(0, 1)
+		Bold(true).
+		Foreground(colors.text).
+		Padding(0, 1)
 
-		// History related styles
 	historyContainerStyle = lipgloss.NewStyle().
-				Border(lipgloss.RoundedBorder()).
-				BorderForeground(lipgloss.Color("240")).
-				Padding(0, 1).
-				Margin(1, 0)
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colors.border).
+		Padding(0, 1).
+		Margin(1, 0)
 
 	historyLabelStyle = lipgloss.NewStyle().
-				Bold(true).
-				Foreground(lipgloss.Color("212"))
+		Bold(true).
+		Foreground(colors.label)
 
 	historyCommandStyle = lipgloss.NewStyle().
-				Foreground(lipgloss.Color("10"))
-)
+		Foreground(colors.text)
+
+	productionBannerStyle = lipgloss.NewStyle().
+		Bold(true).
+		Foreground(colors.banner).
+		Background(colors.destructive).
+		Padding(0, 1)
+	if _, mono := colors.destructive.(lipgloss.NoColor); mono {
+		productionBannerStyle = productionBannerStyle.Reverse(true)
+	}
+
+	riskStyles = map[conn.Risk]lipgloss.Style{
+		conn.RiskRead:        lipgloss.NewStyle().Bold(true).Foreground(colors.read).Padding(0, 1),
+		conn.RiskWrite:       lipgloss.NewStyle().Bold(true).Foreground(colors.write).Padding(0, 1),
+		conn.RiskDestructive: lipgloss.NewStyle().Bold(true).Foreground(colors.destructive).Padding(0, 1),
+	}
+}
@@ -1,11 +1,44 @@
 package shell
 
+import (
+	"time"
+
+	"github.com/prompt-ops/pops/pkg/cache"
+	"github.com/prompt-ops/pops/pkg/conn"
+	"github.com/prompt-ops/pops/pkg/policy"
+)
+
 type commandMsg struct {
 	command string
+
+	// risk is how risky the command is.
+	risk conn.RiskAssessment
+
+	// policy is the decision of the policy file on the command.
+	policy policy.Decision
 }
 
 type outputMsg struct {
 	output string
+
+	// result is the result the output was formatted from, if the command produced one.
+	result *conn.ResultSet
+
+	// auditErr is the error recording the command in the audit log, if it failed.
+	auditErr error
+}
+
+// moreOutputMsg carries the output after more rows of the result were fetched.
+type moreOutputMsg struct {
+	output string
+	err    error
+}
+
+// previewMsg carries the preview of the command to confirm.
+type previewMsg struct {
+	preview  string
+	err      error
+	auditErr error
 }
 
 type answerMsg struct {
@@ -13,6 +46,15 @@ type answerMsg struct {
 }
 
 type checkPassedMsg struct {
+	// cached is the cache entry the context was loaded from, or nil if it was gathered.
+	cached *cache.Entry
+}
+
+// contextRefreshedMsg carries a context gathered in the background.
+type contextRefreshedMsg struct {
+	context   []byte
+	updatedAt time.Time
+	err       error
 }
 
 type errMsg struct {
@@ -2,14 +2,33 @@ package shell
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/prompt-ops/pops/pkg/conn"
+	"github.com/prompt-ops/pops/pkg/policy"
 )
 
 func (m shellModel) renderFooter(text string) string {
 	return footerStyle.Render(text)
 }
 
+// viewProductionBanner returns the banner shown across the top of the shell for production connections.
+func (m shellModel) viewProductionBanner() string {
+	text := fmt.Sprintf("🚨 PRODUCTION: %s", m.connection.Name)
+	if m.connection.Description != "" {
+		text += " (" + m.connection.Description + ")"
+	}
+	text += ". Commands run against production."
+
+	style := productionBannerStyle
+	if m.windowWidth > 2 {
+		style = style.Width(m.windowWidth - 2)
+	}
+	return lipgloss.NewStyle().Margin(0, 1).Render(style.Render(text))
+}
+
 func (m shellModel) viewInitialChecks() string {
 	if m.checkPassed {
 		return outputStyle.Render("✅ Authentication passed!\n\n")
@@ -33,7 +52,16 @@ func (m shellModel) viewEnterPrompt() string {
 		modeStr = "answer"
 	}
 
-	footer := m.renderFooter("Use ←/→ to switch between modes (currently " + modeStr + "). Press Enter when ready.\n\nPress F1 to show context.")
+	footerText := "Use ←/→ to switch between modes (currently " + modeStr + "). Press Enter when ready.\n\n" +
+		"Press F1 to show context, F5 to refresh it. " + m.contextStatus() + "\n" +
+		"Output format: " + m.format + " (F2 or /format <name> to change)."
+	if m.connection.ReadOnly {
+		footerText += "\nThis connection is read-only; commands that could change anything are blocked."
+	}
+	if m.notice != "" {
+		footerText += "\n" + m.notice
+	}
+	footer := m.renderFooter(footerText)
 
 	return fmt.Sprintf(
 		"%s\n\n%s\n\n%s",
@@ -44,7 +72,7 @@ func (m shellModel) viewEnterPrompt() string {
 }
 
 func (m shellModel) viewShowContext() string {
-	footer := m.renderFooter("Press F1 to return to prompt.")
+	footer := m.renderFooter("Press F1 to return to prompt, F5 to refresh the context. " + m.contextStatus())
 
 	return fmt.Sprintf(
 		"%s\n\n%s",
@@ -53,6 +81,20 @@ func (m shellModel) viewShowContext() string {
 	) + "\n\n" + footer
 }
 
+// contextStatus describes how old the context is and whether it is being refreshed.
+func (m shellModel) contextStatus() string {
+	if m.refreshing {
+		return "Refreshing context..."
+	}
+	if m.refreshErr != nil {
+		return fmt.Sprintf("Failed to refresh context: %v", m.refreshErr)
+	}
+	if m.contextUpdatedAt.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf("Context gathered %s ago.", time.Since(m.contextUpdatedAt).Round(time.Second))
+}
+
 func (m shellModel) viewGenerateCommand() string {
 	return titleStyle.Render("🤖 Generating command...")
 }
@@ -62,12 +104,54 @@ func (m shellModel) viewGetAnswer() string {
 }
 
 func (m shellModel) viewConfirmRun() string {
-	return fmt.Sprintf(
+	title := "🚀 Would you like to run the following command? (Y/n, or p to preview)"
+	if m.risk.Risk == conn.RiskDestructive {
+		title = fmt.Sprintf("⚠️ This command is destructive. Type %s to run it (n to cancel, p to preview)", m.confirmTarget())
+	} else if m.typedConfirmation() {
+		title = fmt.Sprintf("🚀 Would you like to run the following command? Type %s to run it (n to cancel, p to preview)", m.confirmTarget())
+	}
+
+	view := fmt.Sprintf(
 		"%s\n\n%s\n\n%s",
-		commandConfirmationTitleStyle.Render("🚀 Would you like to run the following command? (Y/n)"),
+		commandConfirmationTitleStyle.Render(title),
 		commandConfirmationContentStyle.Render("🐳 "+m.command),
-		commandConfirmationResponseStyle.Render(m.confirmInput.View()),
+		renderRisk(m.risk),
 	)
+
+	if m.policy.Action == policy.ActionWarn {
+		view += "\n\n" + riskStyles[conn.RiskWrite].Render(fmt.Sprintf("⚠️ Policy warning (rule '%s'): %s", m.policy.Rule, m.policy.Message))
+	}
+
+	switch {
+	case m.previewing:
+		view += "\n\n" + titleStyle.Render("🔍 Previewing command...")
+	case m.previewErr != nil:
+		view += "\n\n" + errorStyle.Render(fmt.Sprintf("Preview failed: %v", m.previewErr))
+	case m.preview != "":
+		width := m.calculateShareViewWidth()
+		view += "\n\n" + titleStyle.Render("🔍 Preview") + "\n" +
+			outputStyle.Width(width).MaxWidth(width).Render(strings.TrimRight(m.preview, "\n"))
+	}
+
+	if m.confirmHint != "" {
+		view += "\n\n" + errorStyle.Render(m.confirmHint)
+	}
+
+	return view + "\n\n" + commandConfirmationResponseStyle.Render(m.confirmInput.View())
+}
+
+// renderRisk shows the risk of a command in its colour, with the reason.
+func renderRisk(risk conn.RiskAssessment) string {
+	style, ok := riskStyles[risk.Risk]
+	if !ok {
+		style = riskStyles[conn.RiskWrite]
+	}
+
+	text := "Risk: " + strings.ToUpper(risk.Risk.String())
+	if risk.Reason != "" {
+		text += " - " + risk.Reason
+	}
+	return style.Render(text)
 }
 
 func (m shellModel) viewRunCommand() string {
@@ -81,18 +165,40 @@ func (m shellModel) viewDone() string {
 		Width(width).
 		MaxWidth(width)
 
-	var content string
 	if m.err != nil {
-		content = fmt.Sprintf("%v\n", m.err)
-		content = errorStyle.Render(content)
-	} else {
-		content = fmt.Sprintf("%s\n", m.output)
-		content = outputStyle.Render(content)
+		content := fmt.Sprintf("%v\n", m.err)
+		content = outStyle.Render(errorStyle.Render(content))
+		footer := m.renderFooter("Press 'q' or 'esc' or Ctrl+C to quit, or enter a new prompt.")
+		return lipgloss.JoinVertical(lipgloss.Top, content, footer)
+	}
+
+	footerText := "Press 'q' or 'esc' or Ctrl+C to quit, or enter a new prompt."
+	if m.result != nil {
+		footerText = fmt.Sprintf("Output format: %s (F2 to change). ", m.format) + footerText
+	}
+	if m.outputViewport.TotalLineCount() > m.outputViewport.Height {
+		footerText = fmt.Sprintf("Use ↑/↓ or PgUp/PgDn to scroll (%d%%). ", int(m.outputViewport.ScrollPercent()*100)) + footerText
+	}
+	if status := m.resultStatus(); status != "" {
+		footerText = status + "\n" + footerText
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Top, m.outputViewport.View(), m.renderFooter(footerText))
+}
+
+// resultStatus tells how many rows were fetched if the result has more rows to fetch.
+func (m shellModel) resultStatus() string {
+	if m.fetching {
+		return "Fetching more rows..."
+	}
+	if m.result == nil || !m.result.HasMore() {
+		return ""
 	}
 
-	content = outStyle.Render(content)
-	footer := m.renderFooter("Press 'q' or 'esc' or Ctrl+C to quit, or enter a new prompt.")
-	return lipgloss.JoinVertical(lipgloss.Top, content, footer)
+	if m.result.IsTabular() {
+		return fmt.Sprintf("Showing the first %d rows. Press 'm' to fetch more.", len(m.result.Rows))
+	}
+	return fmt.Sprintf("Showing the first %d lines. Press 'm' to fetch more.", strings.Count(m.result.Text, "\n"))
 }
 
 func (m shellModel) viewHistory() string {
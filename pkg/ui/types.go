@@ -19,3 +19,11 @@ var (
 	// QuitMessage is the message displayed to show user how to quit the application.
 	QuitMessage = "Press 'q' or 'esc' or Ctrl+C to quit."
 )
+
+// ReadOnlyToggleMessage shows whether a new connection will be read-only and how to change it.
+func ReadOnlyToggleMessage(readOnly bool) string {
+	if readOnly {
+		return "Read-only: yes (press Tab to toggle)"
+	}
+	return "Read-only: no (press Tab to toggle)"
+}
@@ -0,0 +1,5 @@
+package workspace
+
+// This package selects the workspace pops works in. Each workspace is a directory with its own connections,
+// context cache, audit log and settings: the default workspace is ~/.pops, and named workspaces are
+// ~/.pops/workspaces/<name>.
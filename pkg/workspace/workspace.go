@@ -0,0 +1,200 @@
+package workspace
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Default is the name of the workspace used when no other workspace is selected.
+// Its directory is ~/.pops itself, so connections created before workspaces existed belong to it.
+const Default = "default"
+
+// EnvVar is the environment variable to select a workspace. --workspace takes precedence over it.
+const EnvVar = "POPS_WORKSPACE"
+
+// currentFile is the file in ~/.pops that keeps the workspace selected with 'pops workspace use'.
+const currentFile = "workspace"
+
+// ErrNotFound is returned for workspaces that don't exist.
+var ErrNotFound = errors.New("workspace does not exist")
+
+var validName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+var (
+	mu       sync.RWMutex
+	selected string
+)
+
+// ValidateName returns an error if the name can't be used for a workspace.
+func ValidateName(name string) error {
+	if !validName.MatchString(name) || len(name) > 64 {
+		return fmt.Errorf("invalid workspace name '%s': use up to 64 letters, digits, '.', '_' or '-', starting with a letter or digit", name)
+	}
+	return nil
+}
+
+// Select makes the workspace the current one for this process, like --workspace does.
+// An empty name clears the selection.
+func Select(name string) error {
+	if name != "" {
+		if err := ValidateName(name); err != nil {
+			return err
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	selected = name
+	return nil
+}
+
+// Current returns the name of the current workspace: the one selected with --workspace,
+// otherwise the one in POPS_WORKSPACE, otherwise the one chosen with 'pops workspace use', otherwise Default.
+func Current() string {
+	name, _ := CurrentWithSource()
+	return name
+}
+
+// CurrentWithSource returns the name of the current workspace and how it was selected.
+func CurrentWithSource() (string, string) {
+	mu.RLock()
+	name := selected
+	mu.RUnlock()
+	if name != "" {
+		return name, "--workspace"
+	}
+
+	if name := strings.TrimSpace(os.Getenv(EnvVar)); name != "" && ValidateName(name) == nil {
+		return name, EnvVar
+	}
+
+	if data, err := os.ReadFile(filepath.Join(Root(), currentFile)); err == nil {
+		if name := strings.TrimSpace(string(data)); name != "" && ValidateName(name) == nil {
+			return name, "pops workspace use"
+		}
+	}
+
+	return Default, "default"
+}
+
+// Root returns ~/.pops, the directory of the default workspace that holds the named workspaces,
+// or the working directory if the home directory is unknown.
+func Root() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "."
+	}
+	return filepath.Join(homeDir, ".pops")
+}
+
+// Dir returns the directory of the workspace.
+func Dir(name string) string {
+	if name == "" || name == Default {
+		return Root()
+	}
+	return filepath.Join(Root(), "workspaces", name)
+}
+
+// Path returns the path of a file in the current workspace.
+func Path(filename string) string {
+	return filepath.Join(Dir(Current()), filename)
+}
+
+// Lookup returns the path of a settings file in the current workspace if it exists there,
+// otherwise its path in ~/.pops, so workspaces share the settings they don't override.
+func Lookup(filename string) string {
+	path := Path(filename)
+	if _, err := os.Stat(path); err == nil {
+		return path
+	}
+	return filepath.Join(Root(), filename)
+}
+
+// Exists reports whether the workspace exists. The default workspace always exists.
+func Exists(name string) bool {
+	if name == Default {
+		return true
+	}
+	info, err := os.Stat(Dir(name))
+	return err == nil && info.IsDir()
+}
+
+// List returns the names of the workspaces, the default workspace first.
+func List() ([]string, error) {
+	names := []string{Default}
+
+	entries, err := os.ReadDir(filepath.Join(Root(), "workspaces"))
+	if errors.Is(err, os.ErrNotExist) {
+		return names, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var named []string
+	for _, entry := range entries {
+		if entry.IsDir() && entry.Name() != Default && ValidateName(entry.Name()) == nil {
+			named = append(named, entry.Name())
+		}
+	}
+	sort.Strings(named)
+	return append(names, named...), nil
+}
+
+// Create creates the directory of the workspace.
+func Create(name string) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+	if Exists(name) {
+		return fmt.Errorf("workspace '%s' already exists", name)
+	}
+	return os.MkdirAll(Dir(name), 0700)
+}
+
+// Use makes the workspace the current one for later pops commands that don't select another one.
+func Use(name string) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+	if !Exists(name) {
+		return fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+
+	if err := os.MkdirAll(Root(), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(Root(), currentFile), []byte(name+"\n"), 0600)
+}
+
+// Remove deletes the directory of the workspace with everything in it.
+// If the workspace was chosen with 'pops workspace use', the default workspace is used again.
+func Remove(name string) error {
+	if name == Default {
+		return fmt.Errorf("the default workspace can't be deleted")
+	}
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+	if !Exists(name) {
+		return fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+
+	if err := os.RemoveAll(Dir(name)); err != nil {
+		return err
+	}
+
+	currentPath := filepath.Join(Root(), currentFile)
+	if data, err := os.ReadFile(currentPath); err == nil && strings.TrimSpace(string(data)) == name {
+		if err := os.Remove(currentPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package workspace
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestCurrent(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv(EnvVar, "")
+	t.Cleanup(func() { _ = Select("") })
+
+	if err := Create("acme"); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if err := Create("globex"); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		use      string
+		env      string
+		selected string
+		want     string
+	}{
+		{
+			name: "Test Current without a selection",
+			want: Default,
+		},
+		{
+			name: "Test Current with pops workspace use",
+			use:  "acme",
+			want: "acme",
+		},
+		{
+			name: "Test Current with the environment variable over pops workspace use",
+			use:  "acme",
+			env:  "globex",
+			want: "globex",
+		},
+		{
+			name:     "Test Current with --workspace over the environment variable",
+			use:      "acme",
+			env:      "globex",
+			selected: Default,
+			want:     Default,
+		},
+		{
+			name: "Test Current ignores invalid names in the environment variable",
+			env:  "../etc",
+			want: Default,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			use := tt.use
+			if use == "" {
+				use = Default
+			}
+			if err := Use(use); err != nil {
+				t.Fatalf("Use() error = %v", err)
+			}
+			t.Setenv(EnvVar, tt.env)
+			if err := Select(tt.selected); err != nil {
+				t.Fatalf("Select() error = %v", err)
+			}
+
+			if got := Current(); got != tt.want {
+				t.Errorf("Current() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWorkspaces(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv(EnvVar, "")
+
+	if got, want := Dir(Default), filepath.Join(home, ".pops"); got != want {
+		t.Errorf("Dir(%s) = %s, want %s", Default, got, want)
+	}
+	if got, want := Dir("acme"), filepath.Join(home, ".pops", "workspaces", "acme"); got != want {
+		t.Errorf("Dir(acme) = %s, want %s", got, want)
+	}
+
+	for _, name := range []string{"b", "a"} {
+		if err := Create(name); err != nil {
+			t.Fatalf("Create(%s) error = %v", name, err)
+		}
+	}
+	if err := Create("a"); err == nil {
+		t.Error("Create() of an existing workspace error = nil")
+	}
+	if err := Create("../a"); err == nil {
+		t.Error("Create() of an invalid name error = nil")
+	}
+	if err := Use("missing"); err == nil {
+		t.Error("Use() of a missing workspace error = nil")
+	}
+
+	names, err := List()
+	if err != nil || len(names) != 3 || names[0] != Default || names[1] != "a" || names[2] != "b" {
+		t.Fatalf("List() = %v, %v, want [default a b]", names, err)
+	}
+
+	if err := Use("a"); err != nil {
+		t.Fatalf("Use() error = %v", err)
+	}
+	if err := Remove("a"); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if Exists("a") || Current() != Default {
+		t.Errorf("after Remove() Exists(a) = %v, Current() = %s, want false and %s", Exists("a"), Current(), Default)
+	}
+	if err := Remove(Default); err == nil {
+		t.Error("Remove() of the default workspace error = nil")
+	}
+}